@@ -3,24 +3,32 @@ package main
 import (
     "context"
     "log"
+    "net/http"
     "os"
     "os/signal"
+    "slices"
     "syscall"
     "time"
 
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
     "github.com/you/wallet_transaction_notifier/internal/config"
+    "github.com/you/wallet_transaction_notifier/internal/domain"
     "github.com/you/wallet_transaction_notifier/internal/adapters/blockchain"
     "github.com/you/wallet_transaction_notifier/internal/adapters/notifiers"
+    "github.com/you/wallet_transaction_notifier/internal/adapters/prices"
     "github.com/you/wallet_transaction_notifier/internal/infra/eventbus"
     "github.com/you/wallet_transaction_notifier/internal/infra/httpserver"
+    "github.com/you/wallet_transaction_notifier/internal/infra/matcher"
     "github.com/you/wallet_transaction_notifier/internal/infra/repository"
+    "github.com/you/wallet_transaction_notifier/internal/infra/rules"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
     "github.com/you/wallet_transaction_notifier/internal/services"
 )
 
 func main() {
     cfg := config.Load()
 
-    eb := eventbus.NewInMemoryEventBus()
+    eb := newEventBus(cfg)
     walletsRepo, err := repository.NewMongoWalletRepository(cfg.MongoURI, cfg.DatabaseName)
     if err != nil {
         log.Printf("❌ Failed to create wallet repository: %v", err)
@@ -33,63 +41,334 @@ func main() {
     if err != nil {
         log.Printf("❌ Failed to create subscription repository: %v", err)
     }
+    // Dispatching relies on the notifications to send each alert once, so it cannot run without them.
     notifRepo, err := repository.NewMongoNotificationRepository(cfg.MongoURI, cfg.DatabaseName)
     if err != nil {
-        log.Printf("❌ Failed to create notification repository: %v", err)
-        log.Printf("MongoURI: %s, DatabaseName: %s", cfg.MongoURI, cfg.DatabaseName)
+        log.Fatalf("❌ Failed to create notification repository (database %s): %v", cfg.DatabaseName, err)
+    }
+    log.Printf("✅ Notification repository created successfully")
+    deliveryQueue, err := repository.NewMongoDeliveryQueue(cfg.MongoURI, cfg.DatabaseName)
+    if err != nil {
+        log.Printf("❌ Failed to create delivery queue, alerts are sent without retries: %v", err)
+    }
+    alertsRepo, err := repository.NewMongoAlertRepository(cfg.MongoURI, cfg.DatabaseName)
+    if err != nil {
+        log.Printf("❌ Failed to create alert repository, alert channels are disabled: %v", err)
+    }
+    var chatRules *services.ChatRuleService
+    if rulesRepo, err := repository.NewMongoChatRuleRepository(cfg.MongoURI, cfg.DatabaseName); err != nil {
+        log.Printf("❌ Failed to create chat rule repository: %v", err)
     } else {
-        log.Printf("✅ Notification repository created successfully")
+        chatRules = services.NewChatRuleService(rulesRepo, rules.NewEngine())
+        chatRules.UseCache(cfg.RuleCacheTTL)
     }
-    srv := httpserver.NewServer(cfg, eb, walletsRepo)
+    // graceful shutdown
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
 
-    // Start services: Ethereum watcher and notifier dispatcher
-    eth := blockchain.NewEthereumEventAdapter(eb, cfg.EthWSURL, subsRepo)
-    go func() {
-        if err := eth.Run(context.Background()); err != nil {
-            log.Printf("ethereum adapter error: %v", err)
+    // Share subscription changes with the watchers of other processes through the bus.
+    broadcaster, broadcasts := eb.(ports.ChangeBroadcaster)
+    if broadcasts && subsRepo != nil {
+        local, unsubscribeLocal := subsRepo.SubscribeChanges()
+        defer unsubscribeLocal()
+        go func() {
+            for change := range local {
+                broadcaster.PublishChange(change)
+            }
+        }()
+    }
+    // Changes are followed from the bus when it carries them, which includes this process's.
+    var subscribeChanges func() (<-chan domain.SubscriptionChange, func())
+    if subsRepo != nil {
+        subscribeChanges = subsRepo.SubscribeChanges
+        if broadcasts {
+            subscribeChanges = broadcaster.SubscribeChanges
         }
-    }()
+    }
 
-    // Start Bitcoin watcher
-    // btc := blockchain.NewBitcoinEventAdapter(eb, cfg.BitcoinRPCURL, cfg.BitcoinRPCUser, cfg.BitcoinRPCPass, subsRepo)
-    // go func() {
-    //     if err := btc.Run(context.Background()); err != nil {
-    //         log.Printf("bitcoin adapter error: %v", err)
-    //     }
-    // }()
+    // Start chain watchers for the configured blockchains
+    chainsDone := make(chan struct{})
+    if cfg.HasRole("watcher") {
+        chains := blockchain.NewRegistry()
+        for _, chain := range cfg.Chains {
+            switch chain {
+            case "ethereum":
+                eth := blockchain.NewEthereumEventAdapter(eb, cfg.EthWSURL, subsRepo, newAddressMatcher(cfg))
+                if slices.Contains(cfg.PendingChains, chain) {
+                    eth.WatchPending()
+                }
+                eth.TrackConfirmations(cfg.EthConfirmations)
+                chains.Register(eth)
+            case "bitcoin":
+                btc := blockchain.NewBitcoinEventAdapter(eb, cfg.BitcoinRPCURL, cfg.BitcoinRPCUser, cfg.BitcoinRPCPass, subsRepo, newAddressMatcher(cfg))
+                if slices.Contains(cfg.PendingChains, chain) {
+                    btc.WatchPending()
+                }
+                btc.TrackConfirmations(cfg.BtcConfirmations)
+                chains.Register(btc)
+            default:
+                log.Printf("unknown blockchain %q in CHAINS, skipping", chain)
+            }
+        }
+        go func() {
+            chains.Run(ctx)
+            close(chainsDone)
+        }()
+        if subscribeChanges != nil {
+            changes, unsubscribeChanges := subscribeChanges()
+            defer unsubscribeChanges()
+            go chains.Follow(ctx, changes, cfg.WatchlistRefresh)
+        }
+    } else {
+        close(chainsDone)
+    }
 
-    notifier, err := notifiers.NewTelegramNotifier(cfg.TelegramBotToken, subsRepo)
+    // The dispatcher delivers through the notifiers, the API and the bot send test alerts.
+    templates, err := notifiers.LoadTemplates(cfg.TemplatesDir)
     if err != nil {
-        log.Printf("failed to create telegram notifier: %v", err)
+        log.Fatalf("❌ Failed to load message templates: %v", err)
+    }
+    var (
+        telegram *tgbotapi.BotAPI
+        senders  []ports.Notifier
+        alertSvc *services.AlertService
+    )
+    if cfg.HasRole("dispatcher") || cfg.HasRole("api") || cfg.HasRole("bot") {
+        // One client serves both the notifier and the bot.
+        telegram = newTelegramClient(cfg)
+        senders = newNotifiers(cfg, telegram, alertsRepo, sessionsRepo, templates)
+    }
+    if alertsRepo != nil {
+        alertSvc = services.NewAlertService(alertsRepo, subsRepo)
+        alertSvc.UseNotifiers(senders...)
     }
-    app := services.NewAppService(eb, subsRepo, notifRepo, notifier)
-    go app.Run(context.Background())
 
-    // Telegram bot long polling
-    bot, err := services.NewTelegramBotService(cfg.TelegramBotToken, sessionsRepo, subsRepo, notifRepo)
-    if err != nil {
-        log.Printf("failed to create telegram bot: %v", err)
-    } else {
-        go bot.Run(context.Background())
+    var throttle *services.ChatThrottle
+    if cfg.HasRole("dispatcher") {
+        app := services.NewAppService(eb, subsRepo, notifRepo, senders...)
+        if alertsRepo != nil {
+            app.UseAlerts(alertsRepo)
+        }
+        if chatRules != nil {
+            app.UseChatRules(chatRules)
+        }
+        if sessionsRepo != nil {
+            app.UseChatSettings(sessionsRepo)
+        }
+        if priceProvider := newPriceProvider(cfg); priceProvider != nil {
+            app.UsePrices(priceProvider)
+        }
+        if deliveryQueue != nil {
+            app.UseDeliveryQueue(deliveryQueue)
+            worker := services.NewDeliveryWorker(deliveryQueue, notifRepo, services.DeliveryOptions{
+                MaxAttempts: cfg.DeliveryAttempts,
+                BaseBackoff: cfg.DeliveryBackoff,
+                MaxBackoff:  cfg.DeliveryMaxWait,
+            }, senders...)
+            go worker.Run(ctx)
+        }
+        if digestRepo, err := repository.NewMongoDigestRepository(cfg.MongoURI, cfg.DatabaseName); err != nil {
+            log.Printf("❌ Failed to create digest repository, digest subscriptions are notified instantly: %v", err)
+        } else {
+            app.UseDigests(digestRepo)
+            go services.NewDigestScheduler(digestRepo, notifRepo, time.Minute, senders...).Run(ctx)
+            if cfg.ChatRateLimit > 0 {
+                throttle = services.NewChatThrottle(cfg.ChatRateLimit, cfg.ChatRateBurst, cfg.BurstWindow)
+                app.UseThrottle(throttle)
+            }
+        }
+        go app.Run(ctx)
     }
 
-    // graceful shutdown
-    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-    defer stop()
+    // Telegram bot, by long polling or, with TELEGRAM_WEBHOOK_URL, through the API's webhook
+    var bot *services.TelegramBotService
+    if cfg.HasRole("bot") {
+        bot = services.NewTelegramBotServiceWithAPI(telegram, sessionsRepo, subsRepo, notifRepo)
+        if chatRules != nil {
+            bot.UseChatRules(chatRules)
+        }
+        if alertSvc != nil {
+            bot.UseAlerts(alertSvc)
+        }
+        bot.UseTemplateSets(templates.Sets())
+        switch {
+        case cfg.TelegramWebhookURL == "":
+            go bot.Run(ctx)
+        case !cfg.HasRole("api"):
+            log.Printf("❌ TELEGRAM_WEBHOOK_URL needs the api role next to the bot role, the bot receives no updates")
+        default:
+            if err := bot.RegisterWebhook(cfg.TelegramWebhookURL, cfg.TelegramWebhookSecret); err != nil {
+                log.Printf("❌ Failed to register the Telegram webhook, the bot receives no updates: %v", err)
+            }
+        }
+    }
 
-    go func() {
-        if err := srv.Start(); err != nil {
-            log.Fatalf("server failed to start: %v", err)
+    var srv *httpserver.Server
+    if cfg.HasRole("api") {
+        srv = httpserver.NewServer(cfg, eb, walletsRepo, notifRepo)
+        if deliveryQueue != nil {
+            srv.UseDeliveryQueue(deliveryQueue)
         }
-    }()
+        if chatRules != nil {
+            srv.UseChatRules(chatRules)
+        }
+        if throttle != nil {
+            srv.RegisterMetrics(throttle)
+        }
+        if alertSvc != nil {
+            srv.UseAlerts(alertSvc)
+        }
+        if bot != nil && cfg.TelegramWebhookURL != "" {
+            srv.UseTelegramBot(bot)
+        }
+        if walletsRepo != nil {
+            feed := services.NewLiveFeed(walletsRepo, cfg.StreamBuffer)
+            go feed.Run(ctx, eb)
+            if subscribeChanges != nil {
+                changes, unsubscribeChanges := subscribeChanges()
+                defer unsubscribeChanges()
+                go feed.Follow(ctx, changes)
+            }
+            srv.UseLiveFeed(feed)
+            srv.RegisterMetrics(feed)
+        }
+        go func() {
+            if err := srv.Start(); err != nil {
+                log.Fatalf("server failed to start: %v", err)
+            }
+        }()
+    }
 
     <-ctx.Done()
     shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
-    if err := srv.Stop(shutdownCtx); err != nil {
-        log.Printf("graceful shutdown error: %v", err)
+    if srv != nil {
+        if err := srv.Stop(shutdownCtx); err != nil {
+            log.Printf("graceful shutdown error: %v", err)
+        }
+    }
+    select {
+    case <-chainsDone:
+    case <-shutdownCtx.Done():
+        log.Printf("chain adapters did not stop in time")
     }
     _ = os.Stdout.Sync()
 }
 
+// newTelegramClient connects to the Telegram bot API, or returns nil without a bot token or
+// when the token is rejected. The notifier and the bot share it, and with it the limit of
+// TELEGRAM_MAX_PER_SECOND messages.
+func newTelegramClient(cfg config.Config) *tgbotapi.BotAPI {
+    if cfg.TelegramBotToken == "" {
+        return nil
+    }
+    httpClient := notifiers.NewRateLimitedTelegramClient(&http.Client{}, cfg.TelegramRate)
+    client, err := tgbotapi.NewBotAPIWithClient(cfg.TelegramBotToken, tgbotapi.APIEndpoint, httpClient)
+    if err != nil {
+        log.Printf("❌ Failed to create telegram client, Telegram is disabled: %v", err)
+        return nil
+    }
+    log.Printf("Authorized on account %s", client.Self.UserName)
+    return client
+}
+
+// newNotifiers creates the Telegram notifier on the client, a notifier sending nothing
+// without one, and next to it the notifiers of the alert channel types that can be served.
+func newNotifiers(cfg config.Config, client *tgbotapi.BotAPI, alertsRepo ports.AlertRepository, sessionsRepo ports.SessionRepository, templates *notifiers.Templates) []ports.Notifier {
+    notifier := &notifiers.TelegramNotifier{}
+    if client != nil {
+        notifier = notifiers.NewTelegramNotifierWithAPI(client)
+        notifier.UseTemplates(templates, sessionsRepo)
+    }
+    senders := []ports.Notifier{notifier}
+    if alertsRepo == nil {
+        return senders
+    }
+    senders = append(senders,
+        notifiers.NewWebhookNotifier(alertsRepo, cfg.WebhookTimeout, cfg.WebhookFailures),
+        notifiers.NewSlackNotifier(alertsRepo, cfg.WebhookTimeout, cfg.WebhookFailures),
+        notifiers.NewDiscordNotifier(alertsRepo, cfg.WebhookTimeout, cfg.WebhookFailures),
+    )
+    if cfg.SMTPHost != "" {
+        email := notifiers.NewEmailNotifier(notifiers.EmailConfig{
+            Host:     cfg.SMTPHost,
+            Port:     cfg.SMTPPort,
+            Username: cfg.SMTPUsername,
+            Password: cfg.SMTPPassword,
+            From:     cfg.SMTPFrom,
+            Security: cfg.SMTPSecurity,
+            Timeout:  cfg.SMTPTimeout,
+        }, alertsRepo)
+        email.UseTemplates(templates)
+        senders = append(senders, email)
+    }
+    return senders
+}
+
+// newPriceProvider creates the configured price provider behind a cache, or nil when
+// transfers are not valued.
+func newPriceProvider(cfg config.Config) ports.PriceProvider {
+    var provider ports.PriceProvider
+    switch cfg.PriceProvider {
+    case "coingecko":
+        provider = prices.NewCoinGecko(cfg.CoinGeckoURL, cfg.CoinGeckoAPIKey, cfg.PriceTimeout)
+    case "static":
+        static, err := prices.LoadStaticPrices(cfg.PriceFile)
+        if err != nil {
+            log.Printf("❌ Failed to load PRICE_FILE, transfers are not valued: %v", err)
+            return nil
+        }
+        provider = static
+    case "none", "":
+        return nil
+    default:
+        log.Printf("unknown PRICE_PROVIDER %q, transfers are not valued", cfg.PriceProvider)
+        return nil
+    }
+    return prices.NewCache(provider, 5*time.Minute, cfg.PriceCacheTTL)
+}
 
+func newAddressMatcher(cfg config.Config) ports.AddressMatcher {
+    if cfg.AddressMatcher == "exact" {
+        return matcher.NewSetMatcher()
+    }
+    return matcher.NewBloomMatcher(cfg.MatcherCapacity, 0.01)
+}
+
+// newEventBus creates the configured bus. Falling back to the in-memory bus would silently
+// disconnect processes that run different roles, so a broker that cannot be reached is fatal.
+func newEventBus(cfg config.Config) ports.EventBus {
+    var (
+        eb  ports.EventBus
+        err error
+    )
+    switch cfg.EventBus {
+    case "memory":
+        base := eventbus.SubscriberOptions{
+            BufferSize:   cfg.EventBufferSize,
+            Overflow:     eventbus.OverflowPolicy(cfg.EventOverflow),
+            BlockTimeout: cfg.EventBlockWait,
+            SpillDir:     cfg.EventSpillDir,
+        }
+        subscribers, err := eventbus.ParseSubscriberOptions(cfg.EventSubscribers, base)
+        if err != nil {
+            log.Fatalf("invalid EVENT_BUS_SUBSCRIBERS: %v", err)
+        }
+        return eventbus.NewInMemoryEventBusWithOptions(eventbus.MemoryOptions{Default: base, Subscribers: subscribers})
+    case "mongo":
+        eb, err = eventbus.NewMongoEventBus(cfg.MongoURI, cfg.DatabaseName, eventbus.MongoOptions{
+            AckTimeout: cfg.EventAckTimeout,
+            Retention:  cfg.EventRetention,
+        })
+    case "nats":
+        eb, err = eventbus.NewNATSEventBus(cfg.NATSURL, cfg.EventAckTimeout, cfg.EventRetention)
+    case "redis":
+        eb, err = eventbus.NewRedisEventBus(cfg.RedisURL, cfg.EventAckTimeout, cfg.EventRetention)
+    default:
+        log.Fatalf("unknown EVENT_BUS %q", cfg.EventBus)
+    }
+    if err != nil {
+        log.Fatalf("❌ Failed to create %s event bus: %v", cfg.EventBus, err)
+    }
+    return eb
+}
@@ -23,28 +23,69 @@ type BitcoinEventAdapter struct {
     rpcUser   string
     rpcPass   string
     client    *rpcclient.Client
-    eb        ports.EventBus
-    addresses map[string]struct{} // Bitcoin addresses as strings
+    events    *eventStream
+    addresses ports.AddressMatcher // Bitcoin addresses, lower-cased like the stored subscriptions
     subsRepo  ports.SubscriptionRepository
+    mempool   *mempool // nil unless pending transactions are watched
+    confirmed *confirmations // nil unless confirmations are counted
 }
 
-func NewBitcoinEventAdapter(eb ports.EventBus, rpcURL, rpcUser, rpcPass string, subsRepo ports.SubscriptionRepository) *BitcoinEventAdapter {
+// minedIn is the block a transaction was found in.
+type minedIn struct {
+    height uint64
+    hash   string
+}
+
+var _ ports.BlockchainAdapter = (*BitcoinEventAdapter)(nil)
+
+func NewBitcoinEventAdapter(eb ports.EventBus, rpcURL, rpcUser, rpcPass string, subsRepo ports.SubscriptionRepository, addresses ports.AddressMatcher) *BitcoinEventAdapter {
     return &BitcoinEventAdapter{
         rpcURL:    rpcURL,
         rpcUser:   rpcUser,
         rpcPass:   rpcPass,
-        eb:        eb,
-        addresses: make(map[string]struct{}),
+        events:    newEventStream(eb),
+        addresses: addresses,
         subsRepo:  subsRepo,
     }
 }
 
+// TrackConfirmations reports mined transfers as confirmed once and again when target blocks
+// are on top of theirs, as long as their block is still part of the chain.
+func (a *BitcoinEventAdapter) TrackConfirmations(target int) {
+    if target > 0 {
+        a.confirmed = newConfirmations(target)
+    }
+}
+
+func (a *BitcoinEventAdapter) Chain() string {
+    return "bitcoin"
+}
+
+// Subscribe starts monitoring address. It takes effect from the next processed block.
+func (a *BitcoinEventAdapter) Subscribe(address string) error {
+    address = strings.TrimSpace(address)
+    if address == "" {
+        return fmt.Errorf("empty bitcoin address")
+    }
+    a.addresses.Add(strings.ToLower(address))
+    fmt.Printf("Added address to monitoring: %s\n", address)
+    return nil
+}
+
+// Unsubscribe stops monitoring address.
+func (a *BitcoinEventAdapter) Unsubscribe(address string) error {
+    a.addresses.Remove(strings.ToLower(strings.TrimSpace(address)))
+    fmt.Printf("Removed address from monitoring: %s\n", address)
+    return nil
+}
+
 func (a *BitcoinEventAdapter) Events() <-chan domain.TransactionEvent {
-    ch, _ := a.eb.Subscribe()
-    return ch
+    return a.events.ch
 }
 
 func (a *BitcoinEventAdapter) Run(ctx context.Context) error {
+    defer a.events.close()
+
     // Create RPC client configuration
     connCfg := &rpcclient.ConnConfig{
         Host:         a.rpcURL,
@@ -115,6 +156,9 @@ func (a *BitcoinEventAdapter) Run(ctx context.Context) error {
             return nil
         case <-ticker.C:
             a.checkForNewBlocks(ctx, lastHash)
+            if a.mempool != nil {
+                a.checkMempool()
+            }
             // Update lastHash to current best block
             currentHash, err := client.GetBestBlockHash()
             if err == nil {
@@ -139,15 +183,15 @@ func (a *BitcoinEventAdapter) loadAddressesFromDB(ctx context.Context) {
         return
     }
     
-    // Clear current addresses and add new ones
-    a.addresses = make(map[string]struct{})
-    
+    // Bring current addresses in line with the stored ones
+    keys := make([]string, 0, len(addresses))
     for _, addrStr := range addresses {
-        a.addresses[addrStr] = struct{}{}
+        keys = append(keys, strings.ToLower(addrStr))
         fmt.Printf("Added address to monitoring: %s\n", addrStr)
     }
+    a.addresses.Sync(keys)
     
-    fmt.Printf("Loaded %d Bitcoin addresses from database\n", len(a.addresses))
+    fmt.Printf("Loaded %d Bitcoin addresses from database\n", a.addresses.Len())
 }
 
 // RefreshAddresses reloads addresses from the database
@@ -166,19 +210,19 @@ func (a *BitcoinEventAdapter) RefreshAddresses(ctx context.Context) {
         return
     }
     
-    // Clear current addresses and add new ones
-    a.addresses = make(map[string]struct{})
-    
+    // Bring current addresses in line with the stored ones
+    keys := make([]string, 0, len(addresses))
     for _, addrStr := range addresses {
-        a.addresses[addrStr] = struct{}{}
+        keys = append(keys, strings.ToLower(addrStr))
     }
+    added, removed := a.addresses.Sync(keys)
     
-    fmt.Printf("Refreshed address list: %d addresses\n", len(a.addresses))
+    fmt.Printf("Refreshed address list: %d addresses (+%d/-%d)\n", a.addresses.Len(), added, removed)
 }
 
 func (a *BitcoinEventAdapter) checkForNewBlocks(ctx context.Context, lastHash *chainhash.Hash) {
     // Only process if we have addresses to monitor
-    if len(a.addresses) == 0 {
+    if a.addresses.Len() == 0 {
         return
     }
 
@@ -203,16 +247,51 @@ func (a *BitcoinEventAdapter) checkForNewBlocks(ctx context.Context, lastHash *c
         return
     }
 
+    header, err := a.client.GetBlockHeaderVerbose(currentHash)
+    if err != nil {
+        fmt.Printf("Failed to get header of block %s: %v\n", currentHash.String(), err)
+        return
+    }
+    mined := &minedIn{height: uint64(header.Height), hash: currentHash.String()}
+
     fmt.Printf("Processing Bitcoin block with %d transactions\n", len(block.Transactions))
 
     // Process each transaction in the block
     for _, tx := range block.Transactions {
-        a.processTransaction(ctx, tx)
+        a.processTransaction(tx, mined)
+    }
+
+    if a.confirmed != nil {
+        a.confirmed.publishDue(mined.height, func(height uint64) (string, error) {
+            hash, err := a.client.GetBlockHash(int64(height))
+            if err != nil {
+                return "", err
+            }
+            return hash.String(), nil
+        }, a.events.publish)
     }
 }
 
-func (a *BitcoinEventAdapter) processTransaction(ctx context.Context, tx *wire.MsgTx) {
+// processTransaction publishes the payments tx makes to monitored addresses. block is where
+// tx was mined, nil for transactions from the mempool, which are reported as pending.
+func (a *BitcoinEventAdapter) processTransaction(tx *wire.MsgTx, block *minedIn) {
     msgTx := tx
+
+    if a.mempool != nil {
+        // A transaction spending the same coins, mined or in the mempool, replaced a
+        // pending one that was reported.
+        for _, evt := range a.mempool.replaced(tx, block != nil) {
+            evt.Status = domain.StatusReplaced
+            evt.Timestamp = time.Now().Unix()
+            fmt.Printf("Publishing replaced Bitcoin transaction event: %s %s (tx: %s, replaced by %s)\n",
+                evt.Direction, evt.WalletID, evt.TxHash, tx.TxHash().String())
+            a.events.publish(evt)
+        }
+    }
+    status := domain.StatusPending
+    if block != nil {
+        status = ""
+    }
     
     // Check if any of the monitored addresses are involved in this transaction
     var involvedAddresses []string
@@ -257,16 +336,23 @@ func (a *BitcoinEventAdapter) processTransaction(ctx context.Context, tx *wire.M
             WalletID:   strings.ToLower(addr),
             Blockchain: "bitcoin",
             TxHash:     txHash,
+            LogIndex:   domain.NativeTransfer,
             Direction:  direction,
             Amount:     amount,
             Currency:   "BTC",
             Timestamp:  time.Now().Unix(),
+            Status:     status,
+        }
+        if block == nil {
+            a.mempool.remember(tx, evt)
+        } else if a.confirmed != nil {
+            evt = a.confirmed.track(block.height, block.hash, evt)
         }
 
         fmt.Printf("Publishing Bitcoin transaction event: %s %s %.8f BTC\n", 
             direction, addr, amount)
         
-        a.eb.Publish(evt)
+        a.events.publish(evt)
     }
 }
 
@@ -312,6 +398,5 @@ func (a *BitcoinEventAdapter) calculateAmountForAddress(tx *wire.MsgTx, addr str
 }
 
 func (a *BitcoinEventAdapter) match(addr string) bool {
-    _, ok := a.addresses[addr]
-    return ok
+    return a.addresses.Match(strings.ToLower(addr))
 }
@@ -0,0 +1,109 @@
+package blockchain
+
+import (
+    "fmt"
+
+    "github.com/btcsuite/btcd/chaincfg/chainhash"
+    "github.com/btcsuite/btcd/wire"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// mempool remembers the transactions seen in the node's mempool at the last poll, and the
+// coins spent by those that were reported, to tell when another transaction replaces one.
+type mempool struct {
+    seen   map[chainhash.Hash]struct{}
+    primed bool // the first poll only records what was already waiting
+
+    reported map[chainhash.Hash]reportedTx
+    spends   map[wire.OutPoint]chainhash.Hash // coin -> reported transaction spending it
+}
+
+// reportedTx is a pending transaction whose events were published.
+type reportedTx struct {
+    inputs []wire.OutPoint
+    events []domain.TransactionEvent
+}
+
+// WatchPending also reports transactions paying monitored addresses while they wait in
+// the node's mempool, as pending events.
+func (a *BitcoinEventAdapter) WatchPending() {
+    a.mempool = &mempool{
+        seen:     make(map[chainhash.Hash]struct{}),
+        reported: make(map[chainhash.Hash]reportedTx),
+        spends:   make(map[wire.OutPoint]chainhash.Hash),
+    }
+}
+
+// checkMempool reports the transactions that entered the mempool since the last poll and
+// forgets those that left it.
+func (a *BitcoinEventAdapter) checkMempool() {
+    hashes, err := a.client.GetRawMempool()
+    if err != nil {
+        fmt.Printf("Failed to get mempool: %v\n", err)
+        return
+    }
+    current := make(map[chainhash.Hash]struct{}, len(hashes))
+    for _, hash := range hashes {
+        current[*hash] = struct{}{}
+        if _, ok := a.mempool.seen[*hash]; ok || !a.mempool.primed || a.addresses.Len() == 0 {
+            continue
+        }
+        tx, err := a.client.GetRawTransaction(hash)
+        if err != nil {
+            // Mined or evicted since the listing.
+            continue
+        }
+        a.processTransaction(tx.MsgTx(), nil)
+    }
+    // Replacements were reported above, while the transactions they replace were still known.
+    for hash := range a.mempool.reported {
+        if _, ok := current[hash]; !ok {
+            a.mempool.forget(hash)
+        }
+    }
+    a.mempool.seen = current
+    a.mempool.primed = true
+}
+
+// remember records evt as published for the pending transaction tx.
+func (m *mempool) remember(tx *wire.MsgTx, evt domain.TransactionEvent) {
+    hash := tx.TxHash()
+    reported, ok := m.reported[hash]
+    if !ok {
+        for _, in := range tx.TxIn {
+            reported.inputs = append(reported.inputs, in.PreviousOutPoint)
+            m.spends[in.PreviousOutPoint] = hash
+        }
+    }
+    reported.events = append(reported.events, evt)
+    m.reported[hash] = reported
+}
+
+// replaced returns the events of the reported transactions that spend a coin tx spends too,
+// which tx replaced, and forgets them. A mined transaction is forgotten as well.
+func (m *mempool) replaced(tx *wire.MsgTx, mined bool) []domain.TransactionEvent {
+    hash := tx.TxHash()
+    var events []domain.TransactionEvent
+    for _, in := range tx.TxIn {
+        spender, ok := m.spends[in.PreviousOutPoint]
+        if !ok || spender == hash {
+            continue
+        }
+        events = append(events, m.reported[spender].events...)
+        m.forget(spender)
+    }
+    if mined {
+        m.forget(hash)
+    }
+    return events
+}
+
+func (m *mempool) forget(hash chainhash.Hash) {
+    for _, in := range m.reported[hash].inputs {
+        if m.spends[in] == hash {
+            delete(m.spends, in)
+        }
+    }
+    delete(m.reported, hash)
+}
@@ -0,0 +1,50 @@
+package blockchain
+
+import (
+    "testing"
+
+    "github.com/btcsuite/btcd/chaincfg/chainhash"
+    "github.com/btcsuite/btcd/wire"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+func spending(coins ...wire.OutPoint) *wire.MsgTx {
+    tx := wire.NewMsgTx(wire.TxVersion)
+    for _, coin := range coins {
+        tx.AddTxIn(wire.NewTxIn(&coin, nil, nil))
+    }
+    tx.AddTxOut(wire.NewTxOut(int64(len(coins)), nil))
+    return tx
+}
+
+func TestMempoolReplaced(t *testing.T) {
+    a := &BitcoinEventAdapter{}
+    a.WatchPending()
+    m := a.mempool
+    coin1 := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+    coin2 := wire.OutPoint{Hash: chainhash.Hash{2}, Index: 1}
+
+    original := spending(coin1, coin2)
+    m.remember(original, domain.TransactionEvent{TxHash: original.TxHash().String(), Status: domain.StatusPending})
+    if got := m.replaced(original, false); got != nil {
+        t.Errorf("transaction replaced itself: %v", got)
+    }
+    if got := m.replaced(spending(wire.OutPoint{Hash: chainhash.Hash{3}}), true); got != nil {
+        t.Errorf("unrelated transaction replaced it: %v", got)
+    }
+    if got := m.replaced(spending(coin2), false); len(got) != 1 || got[0].TxHash != original.TxHash().String() {
+        t.Errorf("replaced = %v, want the original's event", got)
+    }
+    if len(m.reported) != 0 || len(m.spends) != 0 {
+        t.Errorf("replaced transaction still remembered: %v, %v", m.reported, m.spends)
+    }
+
+    m.remember(original, domain.TransactionEvent{TxHash: original.TxHash().String()})
+    if got := m.replaced(original, true); got != nil {
+        t.Errorf("mined transaction replaced itself: %v", got)
+    }
+    if len(m.reported) != 0 || len(m.spends) != 0 {
+        t.Errorf("mined transaction still remembered: %v, %v", m.reported, m.spends)
+    }
+}
@@ -0,0 +1,87 @@
+package blockchain
+
+import (
+    "fmt"
+    "sync"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// confirmations remembers the events of recently mined blocks until the chain has grown
+// target blocks past them, to report them again as confirmed that many times.
+type confirmations struct {
+    target int
+
+    mu     sync.Mutex
+    blocks map[uint64]minedBlock
+}
+
+// minedBlock is a block whose events wait for their confirmations.
+type minedBlock struct {
+    number uint64
+    hash   string
+    events []domain.TransactionEvent
+}
+
+func newConfirmations(target int) *confirmations {
+    return &confirmations{target: target, blocks: make(map[uint64]minedBlock)}
+}
+
+// track returns evt, mined in the block number with hash, as confirmed once and remembers it
+// unless its state is final.
+func (c *confirmations) track(number uint64, hash string, evt domain.TransactionEvent) domain.TransactionEvent {
+    if evt.Status.Final() {
+        return evt
+    }
+    evt.Confirmations = 1
+    if c.target <= 1 {
+        return evt
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    b := c.blocks[number]
+    if b.hash != hash {
+        // A block that replaced an earlier one at this height in a reorg.
+        b = minedBlock{number: number, hash: hash}
+    }
+    b.events = append(b.events, evt)
+    c.blocks[number] = b
+    return evt
+}
+
+// due removes and returns the blocks that reached target confirmations with head as the
+// latest block.
+func (c *confirmations) due(head uint64) []minedBlock {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    var blocks []minedBlock
+    for number, b := range c.blocks {
+        if head+1 >= number+uint64(c.target) {
+            blocks = append(blocks, b)
+            delete(c.blocks, number)
+        }
+    }
+    return blocks
+}
+
+// publishDue publishes the events of the blocks that reached target confirmations again,
+// with that count. canonical returns the hash of the chain's block at a height; events of
+// blocks that were reorganised out of the chain are dropped.
+func (c *confirmations) publishDue(head uint64, canonical func(number uint64) (string, error), publish func(domain.TransactionEvent)) {
+    for _, b := range c.due(head) {
+        hash, err := canonical(b.number)
+        if err != nil {
+            fmt.Printf("Failed to check block %d for confirmations: %v\n", b.number, err)
+            continue
+        }
+        if hash != b.hash {
+            fmt.Printf("Block %d (%s) left the chain, dropping %d events\n", b.number, b.hash, len(b.events))
+            continue
+        }
+        for _, evt := range b.events {
+            evt.Confirmations = c.target
+            fmt.Printf("📤 Publishing %d confirmations of tx %s\n", evt.Confirmations, evt.TxHash)
+            publish(evt)
+        }
+    }
+}
@@ -0,0 +1,42 @@
+package blockchain
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+func TestConfirmations(t *testing.T) {
+    c := newConfirmations(3)
+    if evt := c.track(10, "a", domain.TransactionEvent{TxHash: "0x1"}); evt.Confirmations != 1 {
+        t.Errorf("mined event has %d confirmations, want 1", evt.Confirmations)
+    }
+    c.track(11, "b", domain.TransactionEvent{TxHash: "0x2"})
+    c.track(11, "b", domain.TransactionEvent{TxHash: "0x3", Status: domain.StatusReverted})
+    c.track(12, "c", domain.TransactionEvent{TxHash: "0x4"})
+
+    chain := map[uint64]string{10: "a", 11: "b2", 12: "c"}
+    canonical := func(number uint64) (string, error) {
+        if number == 12 {
+            return "", errors.New("node unavailable")
+        }
+        return chain[number], nil
+    }
+    var published []domain.TransactionEvent
+    publish := func(evt domain.TransactionEvent) { published = append(published, evt) }
+
+    c.publishDue(11, canonical, publish)
+    if len(published) != 0 {
+        t.Fatalf("published %v before any block had 3 confirmations", published)
+    }
+    c.publishDue(12, canonical, publish)
+    if len(published) != 1 || published[0].TxHash != "0x1" || published[0].Confirmations != 3 {
+        t.Fatalf("published %v, want 0x1 with 3 confirmations", published)
+    }
+    // Block 11 left the chain and block 12 cannot be checked: both are dropped.
+    c.publishDue(20, canonical, publish)
+    if len(published) != 1 {
+        t.Errorf("published %v, want nothing more", published[1:])
+    }
+}
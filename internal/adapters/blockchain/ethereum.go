@@ -2,6 +2,7 @@ package blockchain
 
 import (
     "context"
+    "encoding/hex"
     "fmt"
     "math/big"
     "strings"
@@ -20,27 +21,71 @@ import (
 type EthereumEventAdapter struct {
     wsURL     string
     client    *ethclient.Client
-    eb        ports.EventBus
-    addresses map[common.Address]struct{}
+    events    *eventStream
+    addresses ports.AddressMatcher // lower-case hex addresses
     subsRepo  ports.SubscriptionRepository
+    tokens    tokenCache
+    pending   *pendingTxs // nil unless pending transactions are watched
+    confirmed *confirmations // nil unless confirmations are counted
 }
 
-func NewEthereumEventAdapter(eb ports.EventBus, wsURL string, subsRepo ports.SubscriptionRepository) *EthereumEventAdapter {
+var _ ports.BlockchainAdapter = (*EthereumEventAdapter)(nil)
+
+func NewEthereumEventAdapter(eb ports.EventBus, wsURL string, subsRepo ports.SubscriptionRepository, addresses ports.AddressMatcher) *EthereumEventAdapter {
     return &EthereumEventAdapter{
         wsURL:     wsURL,
-        eb:        eb,
-        addresses: make(map[common.Address]struct{}),
+        events:    newEventStream(eb),
+        addresses: addresses,
         subsRepo:  subsRepo,
     }
 }
 
+// WatchPending also reports transactions of monitored addresses while they wait in the
+// mempool, as pending events. The node has to stream full pending transactions over the
+// WebSocket connection.
+func (a *EthereumEventAdapter) WatchPending() {
+    a.pending = newPendingTxs()
+}
+
+// TrackConfirmations reports mined transfers as confirmed once and again when target blocks
+// are on top of theirs, as long as their block is still part of the chain.
+func (a *EthereumEventAdapter) TrackConfirmations(target int) {
+    if target > 0 {
+        a.confirmed = newConfirmations(target)
+    }
+}
+
+func (a *EthereumEventAdapter) Chain() string {
+    return "ethereum"
+}
+
+// Subscribe starts monitoring address. It takes effect from the next processed block.
+func (a *EthereumEventAdapter) Subscribe(address string) error {
+    if !common.IsHexAddress(address) {
+        return fmt.Errorf("invalid ethereum address %q", address)
+    }
+    a.addresses.Add(ethAddressKey(common.HexToAddress(address)))
+    fmt.Printf("Added address to monitoring: %s\n", address)
+    return nil
+}
+
+// Unsubscribe stops monitoring address.
+func (a *EthereumEventAdapter) Unsubscribe(address string) error {
+    if !common.IsHexAddress(address) {
+        return fmt.Errorf("invalid ethereum address %q", address)
+    }
+    a.addresses.Remove(ethAddressKey(common.HexToAddress(address)))
+    fmt.Printf("Removed address from monitoring: %s\n", address)
+    return nil
+}
 
 func (a *EthereumEventAdapter) Events() <-chan domain.TransactionEvent {
-    ch, _ := a.eb.Subscribe()
-    return ch
+    return a.events.ch
 }
 
 func (a *EthereumEventAdapter) Run(ctx context.Context) error {
+    defer a.events.close()
+
     // Try to connect with retry logic
     var client *ethclient.Client
     var err error
@@ -94,6 +139,10 @@ func (a *EthereumEventAdapter) Run(ctx context.Context) error {
         fmt.Println("Node is fully synced")
     }
     
+    if a.pending != nil {
+        go a.runPending(ctx, client)
+    }
+
     // Use HTTP polling for more reliable block retrieval
     fmt.Println("Using HTTP polling for reliable block processing...")
     return a.runWithPolling(ctx, client)
@@ -159,6 +208,15 @@ func (a *EthereumEventAdapter) runWithPolling(ctx context.Context, client *ethcl
                 }
                 
                 lastBlockNumber = currentBlockNumber
+                if a.confirmed != nil {
+                    a.confirmed.publishDue(currentBlockNumber, func(number uint64) (string, error) {
+                        header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+                        if err != nil {
+                            return "", err
+                        }
+                        return header.Hash().Hex(), nil
+                    }, a.events.publish)
+                }
             }
         }
     }
@@ -179,16 +237,16 @@ func (a *EthereumEventAdapter) loadAddressesFromDB(ctx context.Context) {
         return
     }
     
-    // Clear current addresses and add new ones
-    a.addresses = make(map[common.Address]struct{})
-    
+    // Bring current addresses in line with the stored ones
+    keys := make([]string, 0, len(addresses))
     for _, addrStr := range addresses {
         addr := common.HexToAddress(addrStr)
-        a.addresses[addr] = struct{}{}
+        keys = append(keys, ethAddressKey(addr))
         fmt.Printf("Added address to monitoring: %s\n", addr.Hex())
     }
+    a.addresses.Sync(keys)
     
-    fmt.Printf("Loaded %d Ethereum addresses from database\n", len(a.addresses))
+    fmt.Printf("Loaded %d Ethereum addresses from database\n", a.addresses.Len())
 }
 
 // RefreshAddresses reloads addresses from the database
@@ -207,27 +265,26 @@ func (a *EthereumEventAdapter) RefreshAddresses(ctx context.Context) {
         return
     }
     
-    // Clear current addresses and add new ones
-    a.addresses = make(map[common.Address]struct{})
-    
+    // Bring current addresses in line with the stored ones
+    keys := make([]string, 0, len(addresses))
     for _, addrStr := range addresses {
-        addr := common.HexToAddress(addrStr)
-        a.addresses[addr] = struct{}{}
+        keys = append(keys, ethAddressKey(common.HexToAddress(addrStr)))
     }
+    added, removed := a.addresses.Sync(keys)
     
-    fmt.Printf("Refreshed address list: %d addresses\n", len(a.addresses))
+    fmt.Printf("Refreshed address list: %d addresses (+%d/-%d)\n", a.addresses.Len(), added, removed)
 }
 
 func (a *EthereumEventAdapter) onNewBlock(ctx context.Context, header *types.Header) {
     fmt.Printf("onNewBlock called for block %d\n", header.Number.Uint64())
     
     // Only process if we have addresses to monitor
-    if len(a.addresses) == 0 {
+    if a.addresses.Len() == 0 {
         fmt.Println("No addresses to monitor, skipping block processing")
         return
     }
     
-    fmt.Printf("Processing block %d with %d monitored addresses\n", header.Number.Uint64(), len(a.addresses))
+    fmt.Printf("Processing block %d with %d monitored addresses\n", header.Number.Uint64(), a.addresses.Len())
 
     // Get block with retry mechanism
     block, err := a.getBlockWithRetry(ctx, header)
@@ -236,6 +293,8 @@ func (a *EthereumEventAdapter) onNewBlock(ctx context.Context, header *types.Hea
         return
     }
 
+    a.processTransferLogs(ctx, block.Header())
+
     // Get chain ID once per block
     chainID, err := a.client.ChainID(ctx)
     if err != nil {
@@ -265,7 +324,7 @@ func (a *EthereumEventAdapter) onNewBlock(ctx context.Context, header *types.Hea
                 }
             }()
             
-            a.processTransaction(ctx, tx, signer)
+            a.processTransaction(ctx, tx, signer, block.Header())
             processedCount++
         }()
     }
@@ -276,7 +335,10 @@ func (a *EthereumEventAdapter) onNewBlock(ctx context.Context, header *types.Hea
     }
 }
 
-func (a *EthereumEventAdapter) processTransaction(ctx context.Context, tx *types.Transaction, signer types.Signer) {
+// processTransaction publishes the transfer of the chain's own currency made by tx if it
+// involves a monitored address. header is the block tx was mined in, nil for transactions
+// from the mempool, which are reported as pending.
+func (a *EthereumEventAdapter) processTransaction(ctx context.Context, tx *types.Transaction, signer types.Signer, header *types.Header) {
     // Handle transaction processing with error recovery
     defer func() {
         if r := recover(); r != nil {
@@ -329,6 +391,18 @@ func (a *EthereumEventAdapter) processTransaction(ctx context.Context, tx *types
         }
     }
 
+    if a.pending != nil {
+        // Another transaction with the same nonce, mined or in the mempool, replaced a
+        // pending one that was reported.
+        for _, evt := range a.pending.replaced(fromAddr, tx.Nonce(), tx.Hash(), header != nil) {
+            evt.Status = domain.StatusReplaced
+            evt.Timestamp = time.Now().Unix()
+            fmt.Printf("📤 Publishing replaced transaction event: %s %s (tx: %s, replaced by %s)\n",
+                evt.Direction, evt.WalletID, evt.TxHash, tx.Hash().Hex())
+            a.events.publish(evt)
+        }
+    }
+
     to := tx.To()
     var toAddr common.Address
     if to != nil {
@@ -345,11 +419,32 @@ func (a *EthereumEventAdapter) processTransaction(ctx context.Context, tx *types
 
     // Determine transaction direction and wallet
     direction := domain.DirectionOutgoing
-    wallet := strings.ToLower(fromAddr.Hex())
+    wallet := ethAddressKey(fromAddr)
+    counterparty := ""
+    if to != nil {
+        counterparty = ethAddressKey(toAddr)
+    }
     
     if isToMonitored {
         direction = domain.DirectionIncoming
-        wallet = strings.ToLower(toAddr.Hex())
+        wallet = ethAddressKey(toAddr)
+        counterparty = ethAddressKey(fromAddr)
+    }
+
+    // A transaction that failed moved nothing, but its sender still wants to know.
+    status := domain.StatusPending
+    if header != nil {
+        status = ""
+        receipt, err := a.client.TransactionReceipt(ctx, tx.Hash())
+        if err != nil {
+            fmt.Printf("Failed to get receipt of tx %s: %v\n", tx.Hash().Hex(), err)
+        } else if receipt.Status == types.ReceiptStatusFailed {
+            status = domain.StatusReverted
+        }
+    }
+    // Calls without value, such as token transfers, are reported through their logs.
+    if tx.Value().Sign() == 0 && status != domain.StatusReverted {
+        return
     }
 
     // Convert wei to ETH with safety check
@@ -360,23 +455,31 @@ func (a *EthereumEventAdapter) processTransaction(ctx context.Context, tx *types
         WalletID:   wallet,
         Blockchain: "ethereum",
         TxHash:     tx.Hash().Hex(),
+        LogIndex:   domain.NativeTransfer,
         Direction:  direction,
+        Counterparty: counterparty,
         Amount:     amountEth,
         Currency:   "ETH",
         Timestamp:  time.Now().Unix(),
+        Status:     status,
+    }
+    if header == nil {
+        a.pending.remember(fromAddr, tx.Nonce(), tx.Hash(), evt)
+    } else {
+        evt = a.mined(header, evt)
     }
 
-    fmt.Printf("📤 Publishing transaction event: %s %s %.6f ETH (tx: %s)\n", 
-        direction, wallet, amountEth, tx.Hash().Hex())
+    fmt.Printf("📤 Publishing transaction event: %s %s %.6f ETH %s (tx: %s)\n", 
+        direction, wallet, amountEth, status, tx.Hash().Hex())
     
-    a.eb.Publish(evt)
+    a.events.publish(evt)
 }
 
 func (a *EthereumEventAdapter) onNewBlockHeaderOnly(ctx context.Context, header *types.Header) {
     fmt.Printf("Processing block header only for block %d (limited transaction support)\n", header.Number.Uint64())
     
     // Only process if we have addresses to monitor
-    if len(a.addresses) == 0 {
+    if a.addresses.Len() == 0 {
         fmt.Println("No addresses to monitor, skipping block processing")
         return
     }
@@ -386,7 +489,18 @@ func (a *EthereumEventAdapter) onNewBlockHeaderOnly(ctx context.Context, header
     // This is a simplified approach that may miss some transactions but keeps the system running
     
     fmt.Printf("Block %d monitoring active (limited mode) - watching %d addresses\n", 
-        header.Number.Uint64(), len(a.addresses))
+        header.Number.Uint64(), a.addresses.Len())
+
+    // Token transfers come from the logs, which do not need the transactions decoded.
+    a.processTransferLogs(ctx, header)
+}
+
+// mined returns evt, mined in the block of header, with its confirmations if they are counted.
+func (a *EthereumEventAdapter) mined(header *types.Header, evt domain.TransactionEvent) domain.TransactionEvent {
+    if a.confirmed == nil {
+        return evt
+    }
+    return a.confirmed.track(header.Number.Uint64(), header.Hash().Hex(), evt)
 }
 
 func (a *EthereumEventAdapter) getBlockWithRetry(ctx context.Context, header *types.Header) (*types.Block, error) {
@@ -409,8 +523,13 @@ func (a *EthereumEventAdapter) getBlockWithRetry(ctx context.Context, header *ty
 }
 
 func (a *EthereumEventAdapter) match(addr common.Address) bool {
-    _, ok := a.addresses[addr]
-    return ok
+    return a.addresses.Match(ethAddressKey(addr))
+}
+
+// ethAddressKey normalizes an address to the lower-case hex form stored in subscriptions.
+// It skips the EIP-55 checksum that Hex() computes, as this runs for every transaction.
+func ethAddressKey(addr common.Address) string {
+    return "0x" + hex.EncodeToString(addr[:])
 }
 
 func weiToETH(wei *big.Int) float64 {
@@ -0,0 +1,167 @@
+package blockchain
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/ethereum/go-ethereum/common"
+    "github.com/ethereum/go-ethereum/core/types"
+    "github.com/ethereum/go-ethereum/ethclient"
+    "github.com/ethereum/go-ethereum/ethclient/gethclient"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+const (
+    // pendingRetryDelay is how long to wait before subscribing to pending transactions again.
+    pendingRetryDelay = 10 * time.Second
+    // pendingTTL is how long a pending transaction is remembered so it is only reported once.
+    pendingTTL = time.Hour
+)
+
+// pendingTxs remembers the pending transactions already seen and, by sender and nonce, the
+// events reported for them, to tell when another transaction replaces one.
+type pendingTxs struct {
+    mu      sync.Mutex
+    seen    map[common.Hash]time.Time
+    byNonce map[nonceKey]pendingTx
+}
+
+type nonceKey struct {
+    from  common.Address
+    nonce uint64
+}
+
+// pendingTx is a pending transaction whose events were published.
+type pendingTx struct {
+    hash   common.Hash
+    events []domain.TransactionEvent
+    seen   time.Time
+}
+
+func newPendingTxs() *pendingTxs {
+    return &pendingTxs{seen: make(map[common.Hash]time.Time), byNonce: make(map[nonceKey]pendingTx)}
+}
+
+// add records hash and reports whether it had not been seen yet.
+func (p *pendingTxs) add(hash common.Hash, now time.Time) bool {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if _, ok := p.seen[hash]; ok {
+        return false
+    }
+    p.seen[hash] = now
+    return true
+}
+
+// remember records evt as published for the pending transaction hash from sender from.
+func (p *pendingTxs) remember(from common.Address, nonce uint64, hash common.Hash, evt domain.TransactionEvent) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    key := nonceKey{from: from, nonce: nonce}
+    tx := p.byNonce[key]
+    if tx.hash != hash {
+        tx = pendingTx{hash: hash, seen: time.Now()}
+    }
+    tx.events = append(tx.events, evt)
+    p.byNonce[key] = tx
+}
+
+// replaced returns the events of the pending transaction with the same sender and nonce as
+// the transaction hash if that is another transaction, which hash replaced, and forgets it.
+// A mined transaction settles its sender's nonce, so its own entry is forgotten as well.
+func (p *pendingTxs) replaced(from common.Address, nonce uint64, hash common.Hash, mined bool) []domain.TransactionEvent {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    key := nonceKey{from: from, nonce: nonce}
+    tx, ok := p.byNonce[key]
+    if !ok || tx.hash == hash && !mined {
+        return nil
+    }
+    delete(p.byNonce, key)
+    if tx.hash == hash {
+        return nil
+    }
+    return tx.events
+}
+
+// prune forgets the transactions seen before cutoff.
+func (p *pendingTxs) prune(cutoff time.Time) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    for hash, seen := range p.seen {
+        if seen.Before(cutoff) {
+            delete(p.seen, hash)
+        }
+    }
+    for key, tx := range p.byNonce {
+        if tx.seen.Before(cutoff) {
+            delete(p.byNonce, key)
+        }
+    }
+}
+
+// runPending reports the transfers of monitored addresses waiting in the mempool until ctx
+// is done, subscribing again whenever the subscription fails. Only transfers of the chain's
+// own currency are seen: token transfers are known from their logs once mined.
+func (a *EthereumEventAdapter) runPending(ctx context.Context, client *ethclient.Client) {
+    gc := gethclient.New(client.Client())
+    prune := time.NewTicker(pendingTTL)
+    defer prune.Stop()
+
+    var signer types.Signer
+    for {
+        if signer == nil {
+            if chainID, err := client.ChainID(ctx); err != nil {
+                fmt.Printf("Failed to get chain ID for pending transactions: %v\n", err)
+            } else {
+                signer = types.LatestSignerForChainID(chainID)
+            }
+        }
+        if signer != nil {
+            txs := make(chan *types.Transaction, 256)
+            sub, err := gc.SubscribeFullPendingTransactions(ctx, txs)
+            if err != nil {
+                fmt.Printf("Failed to subscribe to pending transactions: %v\n", err)
+            } else {
+                fmt.Println("Watching pending transactions")
+                err = a.watchPending(ctx, sub.Err(), txs, signer, prune.C)
+                sub.Unsubscribe()
+                if err == nil {
+                    return
+                }
+                fmt.Printf("Pending transaction subscription failed: %v\n", err)
+            }
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(pendingRetryDelay):
+        }
+    }
+}
+
+// watchPending handles the transactions of one subscription. It returns nil once ctx is done
+// and the subscription's error otherwise.
+func (a *EthereumEventAdapter) watchPending(ctx context.Context, subErr <-chan error, txs <-chan *types.Transaction, signer types.Signer, prune <-chan time.Time) error {
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case err := <-subErr:
+            if err == nil {
+                err = fmt.Errorf("subscription closed")
+            }
+            return err
+        case now := <-prune:
+            a.pending.prune(now.Add(-pendingTTL))
+        case tx := <-txs:
+            if a.addresses.Len() == 0 || !a.pending.add(tx.Hash(), time.Now()) {
+                continue
+            }
+            a.processTransaction(ctx, tx, signer, nil)
+        }
+    }
+}
@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+    "testing"
+    "time"
+
+    "github.com/ethereum/go-ethereum/common"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+func TestPendingTxsReportsOnce(t *testing.T) {
+    p := newPendingTxs()
+    hash := common.HexToHash("0x01")
+    now := time.Now()
+    if !p.add(hash, now) {
+        t.Fatal("new transaction reported as seen")
+    }
+    if p.add(hash, now) {
+        t.Error("transaction reported twice")
+    }
+    p.prune(now.Add(time.Second))
+    if !p.add(hash, now) {
+        t.Error("pruned transaction still seen")
+    }
+}
+
+func TestPendingTxsReplaced(t *testing.T) {
+    original, replacement := common.HexToHash("0x01"), common.HexToHash("0x02")
+    evt := domain.TransactionEvent{TxHash: original.Hex(), Status: domain.StatusPending}
+
+    p := newPendingTxs()
+    p.remember(testFrom, 7, original, evt)
+    if got := p.replaced(testFrom, 7, original, false); got != nil {
+        t.Errorf("transaction replaced itself: %v", got)
+    }
+    if got := p.replaced(testFrom, 8, replacement, true); got != nil {
+        t.Errorf("another nonce replaced the transaction: %v", got)
+    }
+    if got := p.replaced(testFrom, 7, replacement, false); len(got) != 1 || got[0].TxHash != original.Hex() {
+        t.Errorf("replaced = %v, want the original's event", got)
+    }
+    if got := p.replaced(testFrom, 7, replacement, true); got != nil {
+        t.Errorf("replacement reported twice: %v", got)
+    }
+
+    p.remember(testFrom, 9, original, evt)
+    if got := p.replaced(testFrom, 9, original, true); got != nil {
+        t.Errorf("mined transaction replaced itself: %v", got)
+    }
+    if got := p.replaced(testFrom, 9, replacement, true); got != nil {
+        t.Errorf("mined transaction still remembered: %v", got)
+    }
+}
@@ -0,0 +1,216 @@
+package blockchain
+
+import (
+    "context"
+    "fmt"
+    "math/big"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/ethereum/go-ethereum"
+    "github.com/ethereum/go-ethereum/common"
+    "github.com/ethereum/go-ethereum/core/types"
+    "github.com/ethereum/go-ethereum/crypto"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+var (
+    // transferTopic is the Transfer event of ERC-20 tokens and, with the token ID as a third
+    // indexed argument, of ERC-721 NFTs.
+    transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+    // transferSingleTopic is the transfer event of ERC-1155 tokens, which are reported as NFTs.
+    transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+)
+
+// Selectors of the ERC-20 metadata functions.
+var (
+    symbolSelector   = crypto.Keccak256([]byte("symbol()"))[:4]
+    decimalsSelector = crypto.Keccak256([]byte("decimals()"))[:4]
+)
+
+// tokenTransfer is a transfer decoded from an ERC-20, ERC-721 or ERC-1155 log.
+type tokenTransfer struct {
+    contract common.Address
+    from     common.Address
+    to       common.Address
+    value    *big.Int // raw amount, 1 for ERC-721
+    tokenID  *big.Int // nil for ERC-20
+    kind     domain.EventKind
+}
+
+// parseTransferLog decodes a transfer log; other logs and malformed ones are reported as not ok.
+func parseTransferLog(l types.Log) (tokenTransfer, bool) {
+    if l.Removed || len(l.Topics) == 0 {
+        return tokenTransfer{}, false
+    }
+    t := tokenTransfer{contract: l.Address}
+    switch {
+    case l.Topics[0] == transferTopic && len(l.Topics) == 3 && len(l.Data) >= 32:
+        t.from, t.to = topicAddress(l.Topics[1]), topicAddress(l.Topics[2])
+        t.value = new(big.Int).SetBytes(l.Data[:32])
+        t.kind = domain.KindToken
+    case l.Topics[0] == transferTopic && len(l.Topics) == 4:
+        t.from, t.to = topicAddress(l.Topics[1]), topicAddress(l.Topics[2])
+        t.value = big.NewInt(1)
+        t.tokenID = l.Topics[3].Big()
+        t.kind = domain.KindNFT
+    case l.Topics[0] == transferSingleTopic && len(l.Topics) == 4 && len(l.Data) >= 64:
+        t.from, t.to = topicAddress(l.Topics[2]), topicAddress(l.Topics[3])
+        t.tokenID = new(big.Int).SetBytes(l.Data[:32])
+        t.value = new(big.Int).SetBytes(l.Data[32:64])
+        t.kind = domain.KindNFT
+    default:
+        return tokenTransfer{}, false
+    }
+    return t, true
+}
+
+func topicAddress(topic common.Hash) common.Address {
+    return common.BytesToAddress(topic[12:])
+}
+
+// tokenInfo is the metadata of a token contract used to show its transfers.
+type tokenInfo struct {
+    symbol   string
+    decimals int
+}
+
+// tokenCache remembers the metadata of the token contracts seen so far, including the
+// contracts that have none, so each is only asked once.
+type tokenCache struct {
+    mu     sync.Mutex
+    tokens map[common.Address]tokenInfo
+}
+
+// processTransferLogs publishes the token and NFT transfers of a block to or from monitored
+// addresses. Reverted transactions leave no logs, so these transfers all succeeded.
+func (a *EthereumEventAdapter) processTransferLogs(ctx context.Context, header *types.Header) {
+    blockHash := header.Hash()
+    logs, err := a.client.FilterLogs(ctx, ethereum.FilterQuery{
+        BlockHash: &blockHash,
+        Topics:    [][]common.Hash{{transferTopic, transferSingleTopic}},
+    })
+    if err != nil {
+        fmt.Printf("Failed to get transfer logs of block %s: %v\n", blockHash.Hex(), err)
+        return
+    }
+    for _, l := range logs {
+        t, ok := parseTransferLog(l)
+        if !ok {
+            continue
+        }
+        sides := []struct {
+            wallet, counterparty common.Address
+            direction            domain.Direction
+        }{
+            {t.to, t.from, domain.DirectionIncoming},
+            {t.from, t.to, domain.DirectionOutgoing},
+        }
+        for _, side := range sides {
+            if !a.match(side.wallet) {
+                continue
+            }
+            info := a.tokenInfo(ctx, t.contract, t.kind)
+            evt := domain.TransactionEvent{
+                WalletID:      ethAddressKey(side.wallet),
+                Blockchain:    "ethereum",
+                TxHash:        l.TxHash.Hex(),
+                LogIndex:      int(l.Index),
+                Direction:     side.direction,
+                Counterparty:  ethAddressKey(side.counterparty),
+                Amount:        tokenAmount(t.value, info.decimals),
+                Currency:      info.symbol,
+                Timestamp:     time.Now().Unix(),
+                Kind:          t.kind,
+                TokenContract: ethAddressKey(t.contract),
+            }
+            if t.tokenID != nil {
+                evt.TokenID = t.tokenID.String()
+            }
+            evt = a.mined(header, evt)
+            fmt.Printf("📤 Publishing %s transfer event: %s %s %g %s (tx: %s)\n",
+                t.kind, side.direction, evt.WalletID, evt.Amount, evt.Currency, evt.TxHash)
+            a.events.publish(evt)
+        }
+    }
+}
+
+// tokenInfo returns the symbol and decimals of a token contract. Contracts without them get
+// a generic symbol; ERC-20 tokens default to 18 decimals, NFTs have none.
+func (a *EthereumEventAdapter) tokenInfo(ctx context.Context, contract common.Address, kind domain.EventKind) tokenInfo {
+    a.tokens.mu.Lock()
+    info, ok := a.tokens.tokens[contract]
+    a.tokens.mu.Unlock()
+    if ok {
+        return info
+    }
+
+    info = tokenInfo{symbol: "TOKEN", decimals: 18}
+    if kind == domain.KindNFT {
+        info = tokenInfo{symbol: "NFT"}
+    }
+    if out, err := a.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: symbolSelector}, nil); err == nil {
+        if symbol := decodeABIString(out); symbol != "" {
+            info.symbol = symbol
+        }
+    }
+    if kind == domain.KindToken {
+        out, err := a.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: decimalsSelector}, nil)
+        if err == nil && len(out) >= 32 {
+            if d := new(big.Int).SetBytes(out[:32]); d.IsInt64() && d.Int64() <= 77 {
+                info.decimals = int(d.Int64())
+            }
+        }
+    }
+
+    a.tokens.mu.Lock()
+    if a.tokens.tokens == nil {
+        a.tokens.tokens = make(map[common.Address]tokenInfo)
+    }
+    a.tokens.tokens[contract] = info
+    a.tokens.mu.Unlock()
+    return info
+}
+
+// decodeABIString decodes the result of symbol(): an ABI encoded string, or the bytes32 that
+// some early tokens return instead.
+func decodeABIString(out []byte) string {
+    var raw []byte
+    switch {
+    case len(out) >= 64:
+        offset := new(big.Int).SetBytes(out[:32])
+        if !offset.IsInt64() || offset.Int64()+32 > int64(len(out)) {
+            return ""
+        }
+        start := int(offset.Int64()) + 32
+        length := new(big.Int).SetBytes(out[start-32 : start])
+        if !length.IsInt64() || int64(start)+length.Int64() > int64(len(out)) {
+            return ""
+        }
+        raw = out[start : start+int(length.Int64())]
+    case len(out) == 32:
+        raw = out
+    default:
+        return ""
+    }
+    symbol := strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+    if len(symbol) > 16 || strings.ContainsFunc(symbol, func(r rune) bool { return r < ' ' || r == '�' }) {
+        return ""
+    }
+    return symbol
+}
+
+// tokenAmount converts a raw token amount to units of the token.
+func tokenAmount(value *big.Int, decimals int) float64 {
+    if value == nil {
+        return 0
+    }
+    amount := new(big.Rat).SetInt(value)
+    if decimals > 0 {
+        amount.Quo(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+    }
+    f, _ := amount.Float64()
+    return f
+}
@@ -0,0 +1,124 @@
+package blockchain
+
+import (
+    "math/big"
+    "testing"
+
+    "github.com/ethereum/go-ethereum/common"
+    "github.com/ethereum/go-ethereum/core/types"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+var (
+    testContract = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+    testFrom     = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+    testTo       = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+)
+
+func word(n int64) []byte {
+    return common.BigToHash(big.NewInt(n)).Bytes()
+}
+
+func TestParseTransferLog(t *testing.T) {
+    operator := common.HexToAddress("0x00000000000000000000000000000000000000d3")
+    tests := []struct {
+        name    string
+        log     types.Log
+        ok      bool
+        kind    domain.EventKind
+        value   int64
+        tokenID int64 // -1 for none
+    }{
+        {
+            name: "erc20",
+            log: types.Log{Topics: []common.Hash{transferTopic, common.BytesToHash(testFrom.Bytes()), common.BytesToHash(testTo.Bytes())},
+                Data: word(1500)},
+            ok: true, kind: domain.KindToken, value: 1500, tokenID: -1,
+        },
+        {
+            name: "erc721",
+            log:  types.Log{Topics: []common.Hash{transferTopic, common.BytesToHash(testFrom.Bytes()), common.BytesToHash(testTo.Bytes()), common.BigToHash(big.NewInt(7))}},
+            ok:   true, kind: domain.KindNFT, value: 1, tokenID: 7,
+        },
+        {
+            name: "erc1155",
+            log: types.Log{Topics: []common.Hash{transferSingleTopic, common.BytesToHash(operator.Bytes()), common.BytesToHash(testFrom.Bytes()), common.BytesToHash(testTo.Bytes())},
+                Data: append(word(9), word(3)...)},
+            ok: true, kind: domain.KindNFT, value: 3, tokenID: 9,
+        },
+        {
+            name: "erc20 without amount",
+            log:  types.Log{Topics: []common.Hash{transferTopic, common.BytesToHash(testFrom.Bytes()), common.BytesToHash(testTo.Bytes())}},
+        },
+        {
+            name: "removed by a reorg",
+            log: types.Log{Topics: []common.Hash{transferTopic, common.BytesToHash(testFrom.Bytes()), common.BytesToHash(testTo.Bytes())},
+                Data: word(1), Removed: true},
+        },
+        {
+            name: "other event",
+            log:  types.Log{Topics: []common.Hash{common.HexToHash("0x01"), common.BytesToHash(testFrom.Bytes()), common.BytesToHash(testTo.Bytes())}, Data: word(1)},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.log.Address = testContract
+            got, ok := parseTransferLog(tt.log)
+            if ok != tt.ok {
+                t.Fatalf("ok = %v, want %v", ok, tt.ok)
+            }
+            if !ok {
+                return
+            }
+            if got.contract != testContract || got.from != testFrom || got.to != testTo {
+                t.Errorf("contract, from, to = %s, %s, %s", got.contract, got.from, got.to)
+            }
+            if got.kind != tt.kind || got.value.Int64() != tt.value {
+                t.Errorf("kind, value = %s, %s; want %s, %d", got.kind, got.value, tt.kind, tt.value)
+            }
+            if tt.tokenID < 0 && got.tokenID != nil || tt.tokenID >= 0 && (got.tokenID == nil || got.tokenID.Int64() != tt.tokenID) {
+                t.Errorf("token ID = %v, want %d", got.tokenID, tt.tokenID)
+            }
+        })
+    }
+}
+
+func TestDecodeABIString(t *testing.T) {
+    abiString := append(append(word(32), word(4)...), common.RightPadBytes([]byte("USDT"), 32)...)
+    tests := []struct {
+        name string
+        out  []byte
+        want string
+    }{
+        {"abi string", abiString, "USDT"},
+        {"bytes32", common.RightPadBytes([]byte("MKR"), 32), "MKR"},
+        {"empty", nil, ""},
+        {"offset out of range", append(word(4096), word(4)...), ""},
+        {"length out of range", append(word(32), word(4096)...), ""},
+        {"control characters", common.RightPadBytes([]byte("A\nB"), 32), ""},
+    }
+    for _, tt := range tests {
+        if got := decodeABIString(tt.out); got != tt.want {
+            t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestTokenAmount(t *testing.T) {
+    tests := []struct {
+        value    *big.Int
+        decimals int
+        want     float64
+    }{
+        {big.NewInt(1500000), 6, 1.5},
+        {new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil), 18, 1},
+        {big.NewInt(3), 0, 3},
+        {nil, 18, 0},
+    }
+    for _, tt := range tests {
+        if got := tokenAmount(tt.value, tt.decimals); got != tt.want {
+            t.Errorf("tokenAmount(%v, %d) = %v, want %v", tt.value, tt.decimals, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,156 @@
+package blockchain
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Registry owns the blockchain adapters of the process. It runs them with a shared
+// lifecycle and routes subscription changes to the adapter of the matching chain.
+type Registry struct {
+    mu       sync.RWMutex
+    adapters map[string]ports.BlockchainAdapter
+}
+
+func NewRegistry(adapters ...ports.BlockchainAdapter) *Registry {
+    r := &Registry{adapters: make(map[string]ports.BlockchainAdapter)}
+    for _, a := range adapters {
+        r.Register(a)
+    }
+    return r
+}
+
+// Register adds an adapter, replacing any adapter previously registered for the same chain.
+func (r *Registry) Register(a ports.BlockchainAdapter) {
+    r.mu.Lock()
+    r.adapters[a.Chain()] = a
+    r.mu.Unlock()
+}
+
+// Get returns the adapter registered for the given chain.
+func (r *Registry) Get(blockchain string) (ports.BlockchainAdapter, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    a, ok := r.adapters[blockchain]
+    return a, ok
+}
+
+// Chains lists the chains that have a registered adapter.
+func (r *Registry) Chains() []string {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    chains := make([]string, 0, len(r.adapters))
+    for chain := range r.adapters {
+        chains = append(chains, chain)
+    }
+    return chains
+}
+
+// Run starts every registered adapter and blocks until all of them have returned.
+// Cancelling ctx stops the adapters.
+func (r *Registry) Run(ctx context.Context) {
+    r.mu.RLock()
+    adapters := make([]ports.BlockchainAdapter, 0, len(r.adapters))
+    for _, a := range r.adapters {
+        adapters = append(adapters, a)
+    }
+    r.mu.RUnlock()
+
+    var wg sync.WaitGroup
+    for _, a := range adapters {
+        wg.Add(1)
+        go func(a ports.BlockchainAdapter) {
+            defer wg.Done()
+            log.Printf("starting %s adapter", a.Chain())
+            if err := a.Run(ctx); err != nil {
+                log.Printf("%s adapter error: %v", a.Chain(), err)
+            }
+            log.Printf("%s adapter stopped", a.Chain())
+        }(a)
+    }
+    wg.Wait()
+}
+
+// Watch subscribes the adapter of the given chain to address.
+func (r *Registry) Watch(blockchain string, address string) error {
+    a, ok := r.Get(blockchain)
+    if !ok {
+        return fmt.Errorf("no adapter registered for blockchain %q", blockchain)
+    }
+    return a.Subscribe(address)
+}
+
+// Unwatch removes address from the adapter of the given chain.
+func (r *Registry) Unwatch(blockchain string, address string) error {
+    a, ok := r.Get(blockchain)
+    if !ok {
+        return fmt.Errorf("no adapter registered for blockchain %q", blockchain)
+    }
+    return a.Unsubscribe(address)
+}
+
+// refresher is implemented by adapters that can reload their watch list from storage.
+type refresher interface {
+    RefreshAddresses(ctx context.Context)
+}
+
+// Follow applies subscription changes to the adapters until ctx is cancelled or changes is closed.
+// Every refreshEvery it also reconciles the adapters with the stored subscriptions, which picks up
+// writes made by other processes; zero disables the reconciliation.
+func (r *Registry) Follow(ctx context.Context, changes <-chan domain.SubscriptionChange, refreshEvery time.Duration) {
+    var tick <-chan time.Time
+    if refreshEvery > 0 {
+        ticker := time.NewTicker(refreshEvery)
+        defer ticker.Stop()
+        tick = ticker.C
+    }
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case change, ok := <-changes:
+            if !ok {
+                return
+            }
+            r.apply(change)
+        case <-tick:
+            r.refresh(ctx)
+        }
+    }
+}
+
+func (r *Registry) apply(change domain.SubscriptionChange) {
+    sub := change.Subscription
+    if _, ok := r.Get(sub.Blockchain); !ok {
+        return // chain not watched by this process
+    }
+    var err error
+    switch change.Type {
+    case domain.SubscriptionAdded:
+        err = r.Watch(sub.Blockchain, sub.Address)
+    case domain.SubscriptionRemoved:
+        if change.Remaining > 0 {
+            return // other chats still watch this address
+        }
+        err = r.Unwatch(sub.Blockchain, sub.Address)
+    }
+    if err != nil {
+        log.Printf("failed to apply %s subscription for %s %s: %v", change.Type, sub.Blockchain, sub.Address, err)
+    }
+}
+
+func (r *Registry) refresh(ctx context.Context) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    for _, a := range r.adapters {
+        if rf, ok := a.(refresher); ok {
+            rf.RefreshAddresses(ctx)
+        }
+    }
+}
@@ -0,0 +1,33 @@
+package blockchain
+
+import (
+    "sync"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// eventStream publishes adapter events to the shared event bus and mirrors them on the
+// adapter's own Events channel. The mirror is best-effort: events are dropped when it is not drained.
+type eventStream struct {
+    eb     ports.EventBus
+    ch     chan domain.TransactionEvent
+    closed sync.Once
+}
+
+func newEventStream(eb ports.EventBus) *eventStream {
+    return &eventStream{eb: eb, ch: make(chan domain.TransactionEvent, 64)}
+}
+
+func (s *eventStream) publish(evt domain.TransactionEvent) {
+    evt = evt.WithID()
+    s.eb.Publish(evt)
+    select {
+    case s.ch <- evt:
+    default:
+    }
+}
+
+func (s *eventStream) close() {
+    s.closed.Do(func() { close(s.ch) })
+}
@@ -0,0 +1,118 @@
+package notifiers
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/netguard"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// alertChannel loads the alerts a notifier delivers to and keeps count of their failures,
+// disabling an alert after maxFailures failed deliveries in a row; zero never disables.
+type alertChannel struct {
+    alerts      ports.AlertRepository
+    maxFailures int
+}
+
+// load returns the alert with ID to.ID. Deleted and disabled alerts are permanent failures.
+func (c alertChannel) load(ctx context.Context, to domain.Recipient) (domain.Alert, error) {
+    alert, err := c.alerts.Get(ctx, to.ID)
+    if errors.Is(err, domain.ErrAlertNotFound) {
+        return domain.Alert{}, domain.PermanentDeliveryError(fmt.Errorf("%s alert %s was deleted", to.Channel, to.ID))
+    }
+    if err != nil {
+        return domain.Alert{}, err
+    }
+    if alert.Disabled {
+        return domain.Alert{}, domain.PermanentDeliveryError(fmt.Errorf("%s alert %s is disabled", to.Channel, to.ID))
+    }
+    return alert, nil
+}
+
+// track records the outcome of a delivery to alert.
+func (c alertChannel) track(ctx context.Context, alert domain.Alert, err error) {
+    if err == nil {
+        if alert.Failures > 0 {
+            if err := c.alerts.RecordSuccess(ctx, alert.ID); err != nil {
+                fmt.Printf("failed to reset failures of %s alert %s: %v\n", alert.Type, alert.ID, err)
+            }
+        }
+        return
+    }
+    if ctx.Err() != nil {
+        return
+    }
+    disabled, rerr := c.alerts.RecordFailure(ctx, alert.ID, err.Error(), c.maxFailures)
+    if rerr != nil {
+        fmt.Printf("failed to record failure of %s alert %s: %v\n", alert.Type, alert.ID, rerr)
+        return
+    }
+    if disabled {
+        fmt.Printf("disabled %s alert %s of %s after %d failures in a row, last: %v\n", alert.Type, alert.ID, alert.UserID, c.maxFailures, err)
+    }
+}
+
+// newAlertHTTPClient returns the client HTTP based channels post with. It only connects to
+// public addresses, so an alert URL cannot reach the service's own network even when its
+// host resolves differently than when the alert was registered.
+func newAlertHTTPClient(timeout time.Duration) *http.Client {
+    if timeout <= 0 {
+        timeout = 10 * time.Second
+    }
+    return &http.Client{
+        Timeout:   timeout,
+        Transport: netguard.NewTransport(),
+        // A redirect would resend the alert somewhere the owner did not register.
+        CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+    }
+}
+
+// postJSON POSTs body to url and returns the response body of a 2xx response. Other
+// responses are classified for the delivery queue.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) ([]byte, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+    if err != nil {
+        return nil, domain.PermanentDeliveryError(fmt.Errorf("invalid url: %w", err))
+    }
+    for k, v := range header {
+        req.Header[k] = v
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+    if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+        return respBody, nil
+    }
+    return nil, classifyHTTPResponse(resp)
+}
+
+// classifyHTTPResponse tells the delivery queue which responses to retry: timeouts, rate
+// limits and server errors are, other client errors and redirects are not.
+func classifyHTTPResponse(resp *http.Response) error {
+    err := fmt.Errorf("%s responded %s", resp.Request.URL.Host, resp.Status)
+    switch {
+    case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+        // Retry-After is in seconds; Discord sends fractions.
+        if seconds, perr := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); perr == nil && seconds > 0 {
+            return domain.RetryDeliveryAfter(err, time.Duration(seconds*float64(time.Second)))
+        }
+        return err
+    case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+        return err
+    }
+    return domain.PermanentDeliveryError(err)
+}
@@ -0,0 +1,179 @@
+package notifiers
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Embed colors: green for incoming, red for outgoing, blue for digests.
+const (
+    discordColorIncoming = 0x2ECC71
+    discordColorOutgoing = 0xE74C3C
+    discordColorDigest   = 0x3498DB
+)
+
+// DiscordNotifier posts alerts and digests as embeds to the Discord webhooks of discord
+// alerts.
+type DiscordNotifier struct {
+    alertChannel
+    client *http.Client
+}
+
+var (
+    _ ports.Notifier       = (*DiscordNotifier)(nil)
+    _ ports.DigestNotifier = (*DiscordNotifier)(nil)
+)
+
+// NewDiscordNotifier sends with the given request timeout and disables a webhook after
+// maxFailures failed deliveries in a row; zero never disables.
+func NewDiscordNotifier(alerts ports.AlertRepository, timeout time.Duration, maxFailures int) *DiscordNotifier {
+    return &DiscordNotifier{
+        alertChannel: alertChannel{alerts: alerts, maxFailures: maxFailures},
+        client:       newAlertHTTPClient(timeout),
+    }
+}
+
+func (d *DiscordNotifier) Channel() string {
+    return domain.ChannelDiscord
+}
+
+// Send posts event to the Discord webhook of the alert with ID to.ID.
+func (d *DiscordNotifier) Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (string, error) {
+    return d.post(ctx, to, createDiscordAlert(event))
+}
+
+// SendDigest posts a digest as one embed to the Discord webhook of the alert with ID to.ID.
+func (d *DiscordNotifier) SendDigest(ctx context.Context, to domain.Recipient, digest domain.Digest) (string, error) {
+    return d.post(ctx, to, createDiscordDigest(digest))
+}
+
+// post asks Discord to wait for the message to be created, so its ID can be returned.
+func (d *DiscordNotifier) post(ctx context.Context, to domain.Recipient, msg discordMessage) (string, error) {
+    alert, err := d.load(ctx, to)
+    if err != nil {
+        return "", err
+    }
+    target, err := url.Parse(alert.ConfigString("url"))
+    if err != nil {
+        return "", domain.PermanentDeliveryError(fmt.Errorf("discord alert %s has an invalid url: %w", alert.ID, err))
+    }
+    query := target.Query()
+    query.Set("wait", "true")
+    target.RawQuery = query.Encode()
+
+    body, err := json.Marshal(msg)
+    if err != nil {
+        return "", domain.PermanentDeliveryError(err)
+    }
+    respBody, err := postJSON(ctx, d.client, target.String(), body, nil)
+    d.track(ctx, alert, err)
+    if err != nil {
+        return "", err
+    }
+    var created struct {
+        ID string `json:"id"`
+    }
+    json.Unmarshal(respBody, &created)
+    return created.ID, nil
+}
+
+// discordMessage is the body of a webhook execution. Mentions are turned off so an alert
+// can never ping a role or everyone.
+type discordMessage struct {
+    Embeds          []discordEmbed         `json:"embeds"`
+    AllowedMentions discordAllowedMentions `json:"allowed_mentions"`
+}
+
+type discordAllowedMentions struct {
+    Parse []string `json:"parse"`
+}
+
+type discordEmbed struct {
+    Title       string         `json:"title"`
+    Description string         `json:"description,omitempty"`
+    URL         string         `json:"url,omitempty"`
+    Color       int            `json:"color"`
+    Fields      []discordField `json:"fields,omitempty"`
+    Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type discordField struct {
+    Name   string `json:"name"`
+    Value  string `json:"value"`
+    Inline bool   `json:"inline,omitempty"`
+}
+
+func newDiscordMessage(embed discordEmbed) discordMessage {
+    return discordMessage{Embeds: []discordEmbed{embed}, AllowedMentions: discordAllowedMentions{Parse: []string{}}}
+}
+
+func createDiscordAlert(event domain.TransactionEvent) discordMessage {
+    color := discordColorIncoming
+    if event.Direction == domain.DirectionOutgoing {
+        color = discordColorOutgoing
+    }
+    explorer := explorerTxURL(event.Blockchain, event.TxHash)
+
+    amount := fmt.Sprintf("%.6f %s", event.Amount, event.Currency)
+    if value := valueText(i18n.Localizer{}, event); value != "" {
+        amount += " (≈ " + value + ")"
+    }
+    fields := []discordField{
+        {Name: "💰 Amount", Value: amount, Inline: true},
+        {Name: "🔗 Network", Value: chainName(event.Blockchain), Inline: true},
+        {Name: "📍 Address", Value: "`" + event.WalletID + "`"},
+    }
+    if event.Counterparty != "" {
+        name := "↔️ From"
+        if event.Direction == domain.DirectionOutgoing {
+            name = "↔️ To"
+        }
+        fields = append(fields, discordField{Name: name, Value: "`" + event.Counterparty + "`"})
+    }
+    fields = append(fields,
+        discordField{Name: "🆔 Tx Hash", Value: "`" + event.TxHash + "`"},
+        discordField{Name: "⏰ Time", Value: fmt.Sprintf("<t:%d:f>", event.Timestamp)},
+    )
+
+    return newDiscordMessage(discordEmbed{
+        Title:       alertTitle(event),
+        Description: fmt.Sprintf("%s · %s\n\n[View on %s](%s)", directionLabel(event.Direction), networkLabel(event.Blockchain), explorerName(event.Blockchain), explorer),
+        URL:         explorer,
+        Color:       color,
+        Fields:      fields,
+        Timestamp:   eventTime(event).Format(time.RFC3339),
+    })
+}
+
+func createDiscordDigest(digest domain.Digest) discordMessage {
+    incoming, outgoing := digest.Counts()
+
+    var totals strings.Builder
+    for _, total := range digest.Totals() {
+        fmt.Fprintf(&totals, "• %s: +%.6f / -%.6f (%d tx)\n", total.Currency, total.Incoming, total.Outgoing, total.Count)
+    }
+    var largest strings.Builder
+    for i, e := range digest.Largest(3) {
+        fmt.Fprintf(&largest, "%d. %s %.6f %s `%s` [tx](%s)\n", i+1, directionArrow(e.Direction), e.Amount, e.Currency, shortAddress(e.WalletID), explorerTxURL(e.Blockchain, e.TxHash))
+    }
+
+    return newDiscordMessage(discordEmbed{
+        Title:       strings.ReplaceAll(digestHeading(digest), "`", ""), // titles do not render markdown
+        Description: fmt.Sprintf("📥 %d incoming · 📤 %d outgoing", incoming, outgoing),
+        Color:       discordColorDigest,
+        Fields: []discordField{
+            {Name: "💰 Totals", Value: strings.TrimSpace(totals.String())},
+            {Name: "🏆 Largest transfers", Value: strings.TrimSpace(largest.String())},
+        },
+        Timestamp: digest.To.UTC().Format(time.RFC3339),
+    })
+}
@@ -0,0 +1,257 @@
+package notifiers
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "crypto/tls"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "mime"
+    "mime/multipart"
+    "mime/quotedprintable"
+    "net"
+    "net/mail"
+    "net/smtp"
+    "net/textproto"
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// SMTP connection security.
+const (
+    SMTPStartTLS = "starttls" // plain connection upgraded with STARTTLS, usually port 587
+    SMTPTLS      = "tls"      // implicit TLS, usually port 465
+    SMTPNone     = "none"     // no encryption, for local relays only
+)
+
+// EmailConfig is the SMTP server alerts are sent through.
+type EmailConfig struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+    Security string // SMTPStartTLS (default), SMTPTLS or SMTPNone
+    Timeout  time.Duration
+}
+
+// EmailNotifier sends alerts and digests by email to the addresses of email alerts.
+type EmailNotifier struct {
+    alertChannel
+    cfg       EmailConfig
+    templates *Templates
+}
+
+var (
+    _ ports.Notifier       = (*EmailNotifier)(nil)
+    _ ports.DigestNotifier = (*EmailNotifier)(nil)
+)
+
+func NewEmailNotifier(cfg EmailConfig, alerts ports.AlertRepository) *EmailNotifier {
+    if cfg.Security == "" {
+        cfg.Security = SMTPStartTLS
+    }
+    if cfg.Timeout <= 0 {
+        cfg.Timeout = 30 * time.Second
+    }
+    // Failures are not counted: a bad recipient fails permanently, everything else is the
+    // SMTP server's fault rather than the alert's.
+    return &EmailNotifier{alertChannel: alertChannel{alerts: alerts}, cfg: cfg, templates: DefaultTemplates()}
+}
+
+// UseTemplates renders emails from the default set of templates instead of the built-in ones.
+func (e *EmailNotifier) UseTemplates(templates *Templates) {
+    e.templates = templates
+}
+
+func (e *EmailNotifier) Channel() string {
+    return domain.ChannelEmail
+}
+
+// Send emails event to the recipients of the email alert with ID to.ID.
+func (e *EmailNotifier) Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (string, error) {
+    view := newMessageEvent(event, time.UTC, i18n.Localizer{})
+    subject := fmt.Sprintf("%s %s %s on %s", view.DirectionLabel, view.AmountText, event.Currency, view.Network)
+    if view.ValueText != "" {
+        subject += " (≈ " + view.ValueText + ")"
+    }
+    switch event.Status {
+    case domain.StatusPending, domain.StatusReverted, domain.StatusReplaced:
+        subject = view.T("alert."+string(event.Status)+".title") + ": " + subject
+    }
+    return e.deliver(ctx, to, subject, []string{view.MessageKind, string(domain.KindNative)}, view)
+}
+
+// SendDigest emails a digest as one summary to the recipients of the email alert with ID to.ID.
+func (e *EmailNotifier) SendDigest(ctx context.Context, to domain.Recipient, digest domain.Digest) (string, error) {
+    view := newMessageDigest(digest, i18n.Localizer{})
+    subject := fmt.Sprintf("%s: %d transfers", view.Title, len(digest.Events))
+    return e.deliver(ctx, to, subject, []string{TemplateDigest}, view)
+}
+
+func (e *EmailNotifier) deliver(ctx context.Context, to domain.Recipient, subject string, kinds []string, view any) (string, error) {
+    alert, err := e.load(ctx, to)
+    if err != nil {
+        return "", err
+    }
+    recipients, err := mail.ParseAddressList(alert.ConfigString("to"))
+    if err != nil {
+        return "", domain.PermanentDeliveryError(fmt.Errorf("email alert %s has invalid recipients: %w", to.ID, err))
+    }
+
+    text, err := e.templates.Render(DefaultTemplateSet, domain.ChannelEmail, kinds, []string{FormatText}, view)
+    if err != nil {
+        return "", domain.PermanentDeliveryError(err)
+    }
+    html, err := e.templates.Render(DefaultTemplateSet, domain.ChannelEmail, kinds, []string{FormatHTML}, view)
+    if err != nil {
+        return "", domain.PermanentDeliveryError(err)
+    }
+    messageID, msg, err := e.compose(recipients, subject, text.Text, html.Text)
+    if err != nil {
+        return "", domain.PermanentDeliveryError(err)
+    }
+    if err := e.send(ctx, recipients, msg); err != nil {
+        return "", classifySMTPError(err)
+    }
+    return messageID, nil
+}
+
+// compose builds a multipart/alternative message with a plain text and an HTML part.
+func (e *EmailNotifier) compose(to []*mail.Address, subject, text, html string) (string, []byte, error) {
+    from, err := mail.ParseAddress(e.cfg.From)
+    if err != nil {
+        return "", nil, fmt.Errorf("invalid sender %q: %w", e.cfg.From, err)
+    }
+    id := make([]byte, 16)
+    if _, err := rand.Read(id); err != nil {
+        return "", nil, err
+    }
+    domainPart := from.Address[strings.LastIndex(from.Address, "@")+1:]
+    messageID := fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domainPart)
+
+    addresses := make([]string, len(to))
+    for i, a := range to {
+        addresses[i] = a.String()
+    }
+
+    var body bytes.Buffer
+    parts := multipart.NewWriter(&body)
+    var msg bytes.Buffer
+    fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+    fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(addresses, ", "))
+    fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+    fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+    fmt.Fprintf(&msg, "Message-ID: %s\r\n", messageID)
+    fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+    fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+    for _, part := range []struct{ contentType, content string }{
+        {"text/plain; charset=utf-8", text},
+        {"text/html; charset=utf-8", html},
+    } {
+        w, err := parts.CreatePart(textproto.MIMEHeader{
+            "Content-Type":              {part.contentType},
+            "Content-Transfer-Encoding": {"quoted-printable"},
+        })
+        if err != nil {
+            return "", nil, err
+        }
+        qp := quotedprintable.NewWriter(w)
+        if _, err := qp.Write([]byte(part.content)); err != nil {
+            return "", nil, err
+        }
+        if err := qp.Close(); err != nil {
+            return "", nil, err
+        }
+    }
+    if err := parts.Close(); err != nil {
+        return "", nil, err
+    }
+    msg.Write(body.Bytes())
+    return messageID, msg.Bytes(), nil
+}
+
+// send hands the message to the SMTP server.
+func (e *EmailNotifier) send(ctx context.Context, to []*mail.Address, msg []byte) error {
+    addr := net.JoinHostPort(e.cfg.Host, e.cfg.Port)
+    tlsConfig := &tls.Config{ServerName: e.cfg.Host}
+    dialer := &net.Dialer{Timeout: e.cfg.Timeout}
+
+    var conn net.Conn
+    var err error
+    if e.cfg.Security == SMTPTLS {
+        conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+    } else {
+        conn, err = dialer.DialContext(ctx, "tcp", addr)
+    }
+    if err != nil {
+        return err
+    }
+    deadline := time.Now().Add(e.cfg.Timeout)
+    if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+        deadline = d
+    }
+    conn.SetDeadline(deadline)
+
+    c, err := smtp.NewClient(conn, e.cfg.Host)
+    if err != nil {
+        conn.Close()
+        return err
+    }
+    defer c.Close()
+
+    if e.cfg.Security == SMTPStartTLS {
+        if ok, _ := c.Extension("STARTTLS"); !ok {
+            return domain.PermanentDeliveryError(fmt.Errorf("smtp server %s does not support STARTTLS", addr))
+        }
+        if err := c.StartTLS(tlsConfig); err != nil {
+            return err
+        }
+    }
+    if e.cfg.Username != "" {
+        if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+            return err
+        }
+    }
+    from, _ := mail.ParseAddress(e.cfg.From)
+    if err := c.Mail(from.Address); err != nil {
+        return err
+    }
+    for _, rcpt := range to {
+        if err := c.Rcpt(rcpt.Address); err != nil {
+            return err
+        }
+    }
+    w, err := c.Data()
+    if err != nil {
+        return err
+    }
+    if _, err := w.Write(msg); err != nil {
+        return err
+    }
+    if err := w.Close(); err != nil {
+        return err
+    }
+    return c.Quit()
+}
+
+// classifySMTPError treats 5xx replies, e.g. an unknown mailbox or rejected credentials, as
+// permanent; 4xx replies and connection errors are retried.
+func classifySMTPError(err error) error {
+    var protoErr *textproto.Error
+    if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+        return domain.PermanentDeliveryError(err)
+    }
+    return err
+}
+
+func formatAmount(amount float64) string {
+    return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.8f", amount), "0"), ".")
+}
@@ -0,0 +1,328 @@
+package notifiers
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "mime/multipart"
+    "net"
+    "net/mail"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// smtpServer is a minimal SMTP server on localhost that records the messages it accepts.
+type smtpServer struct {
+    ln net.Listener
+    // rcptReply answers RCPT TO, "250 OK" when empty.
+    rcptReply string
+    // silent accepts connections without ever greeting.
+    silent bool
+
+    mu       sync.Mutex
+    rcpts    []string
+    messages []string
+}
+
+// newSMTPServer starts a server configured by the options.
+func newSMTPServer(t *testing.T, options ...func(*smtpServer)) *smtpServer {
+    t.Helper()
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    s := &smtpServer{ln: ln}
+    for _, option := range options {
+        option(s)
+    }
+    t.Cleanup(func() { ln.Close() })
+    go s.serve()
+    return s
+}
+
+func (s *smtpServer) serve() {
+    for {
+        conn, err := s.ln.Accept()
+        if err != nil {
+            return
+        }
+        go s.handle(conn)
+    }
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+    defer conn.Close()
+    if s.silent {
+        io.Copy(io.Discard, conn)
+        return
+    }
+    r := bufio.NewReader(conn)
+    reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+    reply("220 localhost ESMTP")
+    for {
+        line, err := r.ReadString('\n')
+        if err != nil {
+            return
+        }
+        line = strings.TrimRight(line, "\r\n")
+        verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+        switch verb {
+        case "EHLO", "HELO":
+            reply("250 localhost")
+        case "MAIL":
+            reply("250 OK")
+        case "RCPT":
+            if s.rcptReply != "" {
+                reply(s.rcptReply)
+                continue
+            }
+            s.mu.Lock()
+            s.rcpts = append(s.rcpts, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+            s.mu.Unlock()
+            reply("250 OK")
+        case "DATA":
+            reply("354 go ahead")
+            var msg strings.Builder
+            for {
+                l, err := r.ReadString('\n')
+                if err != nil {
+                    return
+                }
+                if l == ".\r\n" {
+                    break
+                }
+                msg.WriteString(strings.TrimPrefix(l, "."))
+            }
+            s.mu.Lock()
+            s.messages = append(s.messages, msg.String())
+            s.mu.Unlock()
+            reply("250 queued")
+        case "QUIT":
+            reply("221 bye")
+            return
+        default:
+            reply("502 not implemented")
+        }
+    }
+}
+
+func (s *smtpServer) received() ([]string, []string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]string(nil), s.rcpts...), append([]string(nil), s.messages...)
+}
+
+type fakeAlerts struct {
+    ports.AlertRepository
+    alert domain.Alert
+}
+
+func (f fakeAlerts) Get(ctx context.Context, id string) (domain.Alert, error) {
+    if id != f.alert.ID {
+        return domain.Alert{}, domain.ErrAlertNotFound
+    }
+    return f.alert, nil
+}
+
+func newTestEmailNotifier(s *smtpServer, alert domain.Alert) *EmailNotifier {
+    host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+    return NewEmailNotifier(EmailConfig{
+        Host:     host,
+        Port:     port,
+        From:     "Notifier <alerts@example.com>",
+        Security: SMTPNone,
+        Timeout:  time.Second,
+    }, fakeAlerts{alert: alert})
+}
+
+func emailAlert(to string) domain.Alert {
+    return domain.Alert{ID: "a1", UserID: "1", Type: domain.ChannelEmail, Config: map[string]any{"to": to}}
+}
+
+var emailRecipient = domain.Recipient{Channel: domain.ChannelEmail, ID: "a1"}
+
+func testTransfer() domain.TransactionEvent {
+    return domain.TransactionEvent{
+        Blockchain: "ethereum",
+        WalletID:   "0xabc",
+        TxHash:     "0x1",
+        Direction:  "incoming",
+        Amount:     1.5,
+        Currency:   "ETH",
+        Timestamp:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).Unix(),
+    }.WithID()
+}
+
+// parseEmail returns the decoded subject and the content types of the parts of msg.
+func parseEmail(t *testing.T, raw string) (*mail.Message, string, []string) {
+    t.Helper()
+    msg, err := mail.ReadMessage(strings.NewReader(raw))
+    if err != nil {
+        t.Fatalf("message does not parse: %v", err)
+    }
+    subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+    if err != nil || mediaType != "multipart/alternative" {
+        t.Fatalf("content type = %q, %v", mediaType, err)
+    }
+    var types []string
+    parts := multipart.NewReader(msg.Body, params["boundary"])
+    for {
+        part, err := parts.NextPart()
+        if errors.Is(err, io.EOF) {
+            break
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+        types = append(types, part.Header.Get("Content-Type"))
+    }
+    return msg, subject, types
+}
+
+func TestEmailSend(t *testing.T) {
+    s := newSMTPServer(t)
+    e := newTestEmailNotifier(s, emailAlert("Ann <ann@example.com>, bob@example.com"))
+
+    id, err := e.Send(context.Background(), emailRecipient, testTransfer())
+    if err != nil {
+        t.Fatal(err)
+    }
+    rcpts, messages := s.received()
+    if strings.Join(rcpts, ",") != "ann@example.com,bob@example.com" {
+        t.Errorf("recipients = %v", rcpts)
+    }
+    if len(messages) != 1 {
+        t.Fatalf("got %d messages, want 1", len(messages))
+    }
+    msg, subject, types := parseEmail(t, messages[0])
+    if msg.Header.Get("Message-ID") != id {
+        t.Errorf("Message-ID = %q, Send returned %q", msg.Header.Get("Message-ID"), id)
+    }
+    if !strings.Contains(subject, "1.5 ETH") {
+        t.Errorf("subject = %q", subject)
+    }
+    want := []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}
+    if strings.Join(types, ",") != strings.Join(want, ",") {
+        t.Errorf("parts = %v, want %v", types, want)
+    }
+}
+
+func TestEmailSubjectShowsStatus(t *testing.T) {
+    s := newSMTPServer(t)
+    e := newTestEmailNotifier(s, emailAlert("ann@example.com"))
+
+    evt := testTransfer()
+    evt.Status = domain.StatusPending
+    if _, err := e.Send(context.Background(), emailRecipient, evt); err != nil {
+        t.Fatal(err)
+    }
+    _, messages := s.received()
+    _, subject, _ := parseEmail(t, messages[0])
+    if strings.HasPrefix(subject, "alert.") || !strings.Contains(subject, ": ") {
+        t.Errorf("subject %q has no status title", subject)
+    }
+}
+
+func TestEmailSendDigest(t *testing.T) {
+    s := newSMTPServer(t)
+    e := newTestEmailNotifier(s, emailAlert("ann@example.com"))
+
+    digest := domain.Digest{
+        ChatID:   "1",
+        Mode:     domain.DeliveryHourly,
+        From:     time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC),
+        To:       time.Date(2026, 1, 2, 4, 0, 0, 0, time.UTC),
+        Location: time.UTC,
+        Events:   []domain.TransactionEvent{testTransfer(), testTransfer()},
+    }
+    if _, err := e.SendDigest(context.Background(), emailRecipient, digest); err != nil {
+        t.Fatal(err)
+    }
+    _, messages := s.received()
+    if len(messages) != 1 {
+        t.Fatalf("got %d messages, want 1", len(messages))
+    }
+    if _, subject, _ := parseEmail(t, messages[0]); !strings.HasSuffix(subject, ": 2 transfers") {
+        t.Errorf("subject = %q", subject)
+    }
+}
+
+func TestEmailClassifiesRejections(t *testing.T) {
+    tests := []struct {
+        reply     string
+        permanent bool
+    }{
+        {"550 no such mailbox", true},
+        {"451 try again later", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.reply, func(t *testing.T) {
+            s := newSMTPServer(t, func(s *smtpServer) { s.rcptReply = tt.reply })
+            e := newTestEmailNotifier(s, emailAlert("ann@example.com"))
+
+            _, err := e.Send(context.Background(), emailRecipient, testTransfer())
+            if err == nil {
+                t.Fatal("send succeeded")
+            }
+            var deliveryErr *domain.DeliveryError
+            permanent := errors.As(err, &deliveryErr) && deliveryErr.Permanent
+            if permanent != tt.permanent {
+                t.Errorf("permanent = %v, want %v: %v", permanent, tt.permanent, err)
+            }
+        })
+    }
+}
+
+func TestEmailRequiresStartTLS(t *testing.T) {
+    s := newSMTPServer(t)
+    e := newTestEmailNotifier(s, emailAlert("ann@example.com"))
+    e.cfg.Security = SMTPStartTLS
+
+    _, err := e.Send(context.Background(), emailRecipient, testTransfer())
+    var deliveryErr *domain.DeliveryError
+    if !errors.As(err, &deliveryErr) || !deliveryErr.Permanent {
+        t.Errorf("err = %v, want a permanent error", err)
+    }
+    if _, messages := s.received(); len(messages) != 0 {
+        t.Error("message was sent without TLS")
+    }
+}
+
+func TestEmailTimesOut(t *testing.T) {
+    s := newSMTPServer(t, func(s *smtpServer) { s.silent = true })
+    e := newTestEmailNotifier(s, emailAlert("ann@example.com"))
+    e.cfg.Timeout = 100 * time.Millisecond
+
+    start := time.Now()
+    if _, err := e.Send(context.Background(), emailRecipient, testTransfer()); err == nil {
+        t.Fatal("send succeeded")
+    }
+    if elapsed := time.Since(start); elapsed > time.Second {
+        t.Errorf("send took %v with a timeout of %v", elapsed, e.cfg.Timeout)
+    }
+}
+
+func TestEmailRefusesDisabledAlert(t *testing.T) {
+    s := newSMTPServer(t)
+    alert := emailAlert("ann@example.com")
+    alert.Disabled = true
+    e := newTestEmailNotifier(s, alert)
+
+    _, err := e.Send(context.Background(), emailRecipient, testTransfer())
+    var deliveryErr *domain.DeliveryError
+    if !errors.As(err, &deliveryErr) || !deliveryErr.Permanent {
+        t.Errorf("err = %v, want a permanent error", err)
+    }
+}
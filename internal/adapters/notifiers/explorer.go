@@ -0,0 +1,76 @@
+package notifiers
+
+import "fmt"
+
+// explorer holds the URL patterns of a chain's block explorer. Empty patterns are pages the
+// explorer does not have.
+type explorer struct {
+    name    string
+    tx      string // tx hash
+    address string // address
+    token   string // token contract, holder address
+    nft     string // token contract, token ID
+}
+
+var explorers = map[string]explorer{
+    "ethereum": {
+        name:    "Etherscan",
+        tx:      "https://etherscan.io/tx/%s",
+        address: "https://etherscan.io/address/%s",
+        token:   "https://etherscan.io/token/%s?a=%s",
+        nft:     "https://etherscan.io/nft/%s/%s",
+    },
+    "bitcoin": {
+        name:    "mempool.space",
+        tx:      "https://mempool.space/tx/%s",
+        address: "https://mempool.space/address/%s",
+    },
+}
+
+// explorerFor returns the explorer of a chain, Etherscan for chains without one.
+func explorerFor(blockchain string) explorer {
+    if e, ok := explorers[blockchain]; ok {
+        return e
+    }
+    return explorers["ethereum"]
+}
+
+// explorerTxURL links to a transaction on the block explorer of its chain.
+func explorerTxURL(blockchain string, txHash string) string {
+    return fmt.Sprintf(explorerFor(blockchain).tx, txHash)
+}
+
+// explorerName is the name of the block explorer explorerTxURL links to.
+func explorerName(blockchain string) string {
+    return explorerFor(blockchain).name
+}
+
+func explorerAddressURL(blockchain string, address string) string {
+    if address == "" {
+        return ""
+    }
+    return fmt.Sprintf(explorerFor(blockchain).address, address)
+}
+
+// explorerTokenURL links to the transfers of a token by holder, or to one NFT when tokenID
+// is set. It is empty when the chain's explorer has no such page.
+func explorerTokenURL(blockchain string, contract string, holder string, tokenID string) string {
+    e := explorerFor(blockchain)
+    switch {
+    case contract == "":
+        return ""
+    case tokenID != "" && e.nft != "":
+        return fmt.Sprintf(e.nft, contract, tokenID)
+    case tokenID == "" && e.token != "":
+        return fmt.Sprintf(e.token, contract, holder)
+    }
+    return ""
+}
+
+// networkLabel is the chain name with the marker the Telegram alert shows.
+func networkLabel(blockchain string) string {
+    if blockchain == "bitcoin" {
+        return "🟠 Bitcoin"
+    }
+    return "🔷 Ethereum"
+}
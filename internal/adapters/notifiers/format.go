@@ -0,0 +1,227 @@
+package notifiers
+
+import (
+    "fmt"
+    "math"
+    "strings"
+    "time"
+    "unicode"
+    "unicode/utf8"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+)
+
+// Formatting shared by the chat style channels (Slack, Discord), kept in line with the
+// Telegram messages.
+
+func directionLabel(direction domain.Direction) string {
+    if direction == domain.DirectionOutgoing {
+        return "📤 Outgoing"
+    }
+    return "📥 Incoming"
+}
+
+// chainName returns the blockchain's name as shown in messages, e.g. "Ethereum".
+func chainName(blockchain string) string {
+    first, size := utf8.DecodeRuneInString(blockchain)
+    if size == 0 {
+        return blockchain
+    }
+    return string(unicode.ToUpper(first)) + blockchain[size:]
+}
+
+func directionArrow(direction domain.Direction) string {
+    if direction == domain.DirectionOutgoing {
+        return "📤"
+    }
+    return "📥"
+}
+
+// alertTitle heads the alert of an event, matching the Telegram templates of its kind.
+func alertTitle(event domain.TransactionEvent) string {
+    switch event.MessageKind() {
+    case string(domain.StatusPending):
+        return "⏳ Pending Transaction"
+    case string(domain.StatusReverted):
+        return "❌ Transaction Reverted"
+    case string(domain.StatusReplaced):
+        return "🔁 Transaction Replaced"
+    case string(domain.KindToken):
+        return "🪙 Token Transfer"
+    case string(domain.KindNFT):
+        return "🖼 NFT Transfer"
+    }
+    return "🚨 Transaction Alert"
+}
+
+func eventTime(event domain.TransactionEvent) time.Time {
+    return time.Unix(event.Timestamp, 0).UTC()
+}
+
+// digestHeading is the first line of a digest: its title and period, or for a burst summary
+// how many transfers it collapsed.
+func digestHeading(digest domain.Digest) string {
+    if digest.Mode == domain.DeliveryBurst {
+        minutes := int(math.Ceil(digest.To.Sub(digest.From).Minutes()))
+        return fmt.Sprintf("📦 %d more transfers to %s in the last %d minutes", len(digest.Events), burstTarget(digest.Events), minutes)
+    }
+    title := "Hourly Digest"
+    layout := "15:04"
+    switch digest.Mode {
+    case domain.DeliveryDaily:
+        title = "Daily Digest"
+        layout = "2006-01-02 15:04"
+    case domain.DeliveryHeld:
+        title = "While You Were Away"
+        layout = "2006-01-02 15:04"
+    }
+    loc := digest.Location
+    if loc == nil {
+        loc = time.UTC
+    }
+    return fmt.Sprintf("🗞 %s · %s – %s %s", title, digest.From.In(loc).Format(layout), digest.To.In(loc).Format(layout), digest.To.In(loc).Format("MST"))
+}
+
+// messageEvent is what the templates of an event render. The embedded Localizer gives the
+// templates the chat's texts, e.g. {{.T "alert.amount"}}.
+type messageEvent struct {
+    domain.TransactionEvent
+    i18n.Localizer
+    MessageKind     string
+    Outgoing        bool
+    DirectionLabel  string // "Incoming" or "Outgoing"
+    DirectionEmoji  string
+    Network         string // chain name, e.g. "Ethereum"
+    NetworkLabel    string // chain name with its marker
+    AmountText      string
+    ValueText       string // fiat value, e.g. "1,234.50 USD", empty when unknown
+    Time            string
+    ExplorerName    string
+    ExplorerURL     string
+    AddressURL      string
+    CounterpartyURL string
+    TokenURL        string // token or NFT page, empty for native transfers
+}
+
+// newMessageEvent prepares event for the templates, with times shown in loc and texts,
+// numbers and dates in the language of l.
+func newMessageEvent(event domain.TransactionEvent, loc *time.Location, l i18n.Localizer) messageEvent {
+    if loc == nil {
+        loc = time.UTC
+    }
+    outgoing := event.Direction == domain.DirectionOutgoing
+    direction := l.T("alert.direction.incoming")
+    if outgoing {
+        direction = l.T("alert.direction.outgoing")
+    }
+    view := messageEvent{
+        TransactionEvent: event,
+        Localizer:        l,
+        MessageKind:      event.MessageKind(),
+        Outgoing:         outgoing,
+        DirectionLabel:   direction,
+        DirectionEmoji:   directionArrow(event.Direction),
+        Network:          chainName(event.Blockchain),
+        NetworkLabel:     networkLabel(event.Blockchain),
+        AmountText:       l.Number(event.Amount),
+        ValueText:        valueText(l, event),
+        Time:             l.DateTime(time.Unix(event.Timestamp, 0).In(loc)),
+        ExplorerName:     explorerName(event.Blockchain),
+        ExplorerURL:      explorerTxURL(event.Blockchain, event.TxHash),
+        AddressURL:       explorerAddressURL(event.Blockchain, event.WalletID),
+        CounterpartyURL:  explorerAddressURL(event.Blockchain, event.Counterparty),
+    }
+    if event.Kind == domain.KindToken || event.Kind == domain.KindNFT {
+        view.TokenURL = explorerTokenURL(event.Blockchain, event.TokenContract, event.WalletID, event.TokenID)
+    }
+    return view
+}
+
+// messageDigest is what the digest templates render, with the texts of its Localizer.
+type messageDigest struct {
+    i18n.Localizer
+    Title     string
+    Period    string
+    Burst     bool
+    Minutes   int    // length of a burst
+    Target    string // the address a burst went to, empty when it spread over several
+    Addresses int    // how many addresses a burst went to
+    Count     int
+    Incoming  int
+    Outgoing  int
+    ValueIn   string // fiat value received, empty when no transfer could be valued
+    ValueOut  string
+    Totals    []messageTotal
+    Largest   []messageEvent // up to five, largest first
+}
+
+type messageTotal struct {
+    Currency string
+    Incoming string
+    Outgoing string
+    Count    int
+}
+
+func newMessageDigest(digest domain.Digest, l i18n.Localizer) messageDigest {
+    loc := digest.Location
+    if loc == nil {
+        loc = time.UTC
+    }
+    from, to := digest.From.In(loc), digest.To.In(loc)
+    title := l.T("digest.title.hourly")
+    period := l.Clock(from) + " – " + l.Clock(to) + " " + to.Format("MST")
+    switch digest.Mode {
+    case domain.DeliveryDaily:
+        title = l.T("digest.title.daily")
+        period = l.DateTime(from) + " – " + l.DateTime(to)
+    case domain.DeliveryHeld:
+        title = l.T("digest.title.held")
+        period = l.DateTime(from) + " – " + l.DateTime(to)
+    case domain.DeliveryBurst:
+        title = l.T("digest.title.burst")
+    }
+    view := messageDigest{
+        Localizer: l,
+        Title:     title,
+        Period:    period,
+        Burst:     digest.Mode == domain.DeliveryBurst,
+        Count:     len(digest.Events),
+    }
+    if view.Burst {
+        view.Minutes = int(math.Ceil(digest.To.Sub(digest.From).Minutes()))
+        view.Target, view.Addresses = burstAddresses(digest.Events)
+    }
+    view.Incoming, view.Outgoing = digest.Counts()
+    if in, out, fiat := digest.Value(); fiat != "" {
+        view.ValueIn, view.ValueOut = l.Money(in, fiat), l.Money(out, fiat)
+    }
+    for _, t := range digest.Totals() {
+        view.Totals = append(view.Totals, messageTotal{Currency: t.Currency, Incoming: l.Number(t.Incoming), Outgoing: l.Number(t.Outgoing), Count: t.Count})
+    }
+    for _, e := range digest.Largest(5) {
+        view.Largest = append(view.Largest, newMessageEvent(e, loc, l))
+    }
+    return view
+}
+
+// valueText formats the fiat value of an event, empty when it is unknown.
+func valueText(l i18n.Localizer, event domain.TransactionEvent) string {
+    if event.FiatCurrency == "" {
+        return ""
+    }
+    return l.Money(event.FiatValue, event.FiatCurrency)
+}
+
+// burstAddresses returns the address a burst went to, shortened, or how many addresses it
+// spread over.
+func burstAddresses(events []domain.TransactionEvent) (string, int) {
+    addresses := make(map[string]bool)
+    for _, e := range events {
+        addresses[strings.ToLower(e.WalletID)] = true
+    }
+    if len(addresses) == 1 && len(events) > 0 {
+        return shortAddress(events[0].WalletID), 1
+    }
+    return "", len(addresses)
+}
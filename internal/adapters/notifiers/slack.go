@@ -0,0 +1,171 @@
+package notifiers
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// SlackNotifier posts alerts and digests to the Slack incoming webhooks of slack alerts,
+// formatted with Block Kit.
+type SlackNotifier struct {
+    alertChannel
+    client *http.Client
+}
+
+var (
+    _ ports.Notifier       = (*SlackNotifier)(nil)
+    _ ports.DigestNotifier = (*SlackNotifier)(nil)
+)
+
+// NewSlackNotifier sends with the given request timeout and disables a webhook after
+// maxFailures failed deliveries in a row; zero never disables.
+func NewSlackNotifier(alerts ports.AlertRepository, timeout time.Duration, maxFailures int) *SlackNotifier {
+    return &SlackNotifier{
+        alertChannel: alertChannel{alerts: alerts, maxFailures: maxFailures},
+        client:       newAlertHTTPClient(timeout),
+    }
+}
+
+func (s *SlackNotifier) Channel() string {
+    return domain.ChannelSlack
+}
+
+// Send posts event to the Slack webhook of the alert with ID to.ID.
+func (s *SlackNotifier) Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (string, error) {
+    return s.post(ctx, to, createSlackAlert(event))
+}
+
+// SendDigest posts a digest as one message to the Slack webhook of the alert with ID to.ID.
+func (s *SlackNotifier) SendDigest(ctx context.Context, to domain.Recipient, digest domain.Digest) (string, error) {
+    return s.post(ctx, to, createSlackDigest(digest))
+}
+
+// Slack incoming webhooks answer "ok" without the ID of the message, so none is returned.
+func (s *SlackNotifier) post(ctx context.Context, to domain.Recipient, msg slackMessage) (string, error) {
+    alert, err := s.load(ctx, to)
+    if err != nil {
+        return "", err
+    }
+    body, err := json.Marshal(msg)
+    if err != nil {
+        return "", domain.PermanentDeliveryError(err)
+    }
+    _, err = postJSON(ctx, s.client, alert.ConfigString("url"), body, nil)
+    s.track(ctx, alert, err)
+    return "", err
+}
+
+// slackMessage is the body of an incoming webhook request. Text is the fallback shown in
+// notifications and by clients that cannot render blocks.
+type slackMessage struct {
+    Text   string       `json:"text"`
+    Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+    Type     string        `json:"type"`
+    Text     *slackText    `json:"text,omitempty"`
+    Fields   []slackText   `json:"fields,omitempty"`
+    Elements []slackButton `json:"elements,omitempty"`
+}
+
+type slackText struct {
+    Type string `json:"type"` // "mrkdwn" or "plain_text"
+    Text string `json:"text"`
+}
+
+type slackButton struct {
+    Type string    `json:"type"` // "button"
+    Text slackText `json:"text"`
+    URL  string    `json:"url"`
+}
+
+func slackMarkdown(text string) slackText {
+    return slackText{Type: "mrkdwn", Text: text}
+}
+
+func slackPlain(text string) *slackText {
+    return &slackText{Type: "plain_text", Text: text}
+}
+
+func slackSection(markdown string) slackBlock {
+    text := slackMarkdown(markdown)
+    return slackBlock{Type: "section", Text: &text}
+}
+
+// slackEscape escapes the characters Slack reserves for links and mentions.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+func createSlackAlert(event domain.TransactionEvent) slackMessage {
+    amount := fmt.Sprintf("%.6f %s", event.Amount, slackEscape(event.Currency))
+    if value := valueText(i18n.Localizer{}, event); value != "" {
+        amount += " (≈ " + value + ")"
+    }
+    explorer := explorerTxURL(event.Blockchain, event.TxHash)
+
+    fields := []slackText{
+        slackMarkdown("💰 *Amount:*\n" + amount),
+        slackMarkdown("🔗 *Network:*\n" + networkLabel(event.Blockchain)),
+        slackMarkdown("📍 *Address:*\n`" + event.WalletID + "`"),
+    }
+    if event.Counterparty != "" {
+        label := "From"
+        if event.Direction == domain.DirectionOutgoing {
+            label = "To"
+        }
+        fields = append(fields, slackMarkdown("↔️ *"+label+":*\n`"+event.Counterparty+"`"))
+    }
+    fields = append(fields,
+        slackMarkdown("🆔 *Tx Hash:*\n`"+event.TxHash+"`"),
+        slackMarkdown("⏰ *Time:*\n"+fmt.Sprintf("<!date^%d^{date_short_pretty} {time_secs}|%s>", event.Timestamp, eventTime(event).Format("2006-01-02 15:04:05 MST"))),
+    )
+
+    return slackMessage{
+        Text: fmt.Sprintf("🚨 %s %s on %s", directionLabel(event.Direction), amount, chainName(event.Blockchain)),
+        Blocks: []slackBlock{
+            {Type: "header", Text: slackPlain(alertTitle(event))},
+            slackSection(directionLabel(event.Direction) + " · " + networkLabel(event.Blockchain)),
+            {Type: "section", Fields: fields},
+            {Type: "actions", Elements: []slackButton{{
+                Type: "button",
+                Text: *slackPlain("View on " + explorerName(event.Blockchain)),
+                URL:  explorer,
+            }}},
+        },
+    }
+}
+
+func createSlackDigest(digest domain.Digest) slackMessage {
+    heading := slackEscape(digestHeading(digest))
+    incoming, outgoing := digest.Counts()
+
+    var totals strings.Builder
+    totals.WriteString("💰 *Totals:*\n")
+    for _, total := range digest.Totals() {
+        fmt.Fprintf(&totals, "• %s: +%.6f / -%.6f (%d tx)\n", slackEscape(total.Currency), total.Incoming, total.Outgoing, total.Count)
+    }
+
+    var largest strings.Builder
+    largest.WriteString("🏆 *Largest transfers:*\n")
+    for i, e := range digest.Largest(3) {
+        fmt.Fprintf(&largest, "%d. %s %.6f %s `%s` <%s|tx>\n", i+1, directionArrow(e.Direction), e.Amount, slackEscape(e.Currency), shortAddress(e.WalletID), explorerTxURL(e.Blockchain, e.TxHash))
+    }
+
+    return slackMessage{
+        Text: heading,
+        Blocks: []slackBlock{
+            slackSection("*" + heading + "*"),
+            slackSection(fmt.Sprintf("📥 %d incoming · 📤 %d outgoing", incoming, outgoing)),
+            slackSection(strings.TrimSpace(totals.String())),
+            slackSection(strings.TrimSpace(largest.String())),
+        },
+    }
+}
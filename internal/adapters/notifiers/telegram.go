@@ -2,21 +2,34 @@ package notifiers
 
 import (
     "context"
+    "errors"
     "fmt"
+    "net/http"
+    "strconv"
     "strings"
     "time"
 
     tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
     "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/i18n"
     "github.com/you/wallet_transaction_notifier/internal/ports"
 )
 
+// TelegramAPI is the part of the Telegram bot client the notifier uses. Edits are sent through
+// Send as well.
+type TelegramAPI interface {
+    Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+}
+
 type TelegramNotifier struct {
-    bot      *tgbotapi.BotAPI
-    subsRepo ports.SubscriptionRepository
+    bot       TelegramAPI
+    templates *Templates
+    sessions  ports.SessionRepository
 }
 
-func NewTelegramNotifier(botToken string, subsRepo ports.SubscriptionRepository) (*TelegramNotifier, error) {
+var _ ports.Notifier = (*TelegramNotifier)(nil)
+
+func NewTelegramNotifier(botToken string) (*TelegramNotifier, error) {
     if botToken == "" {
         return &TelegramNotifier{}, nil
     }
@@ -26,86 +39,206 @@ func NewTelegramNotifier(botToken string, subsRepo ports.SubscriptionRepository)
         return nil, fmt.Errorf("failed to create bot: %w", err)
     }
 
-    return &TelegramNotifier{
-        bot:      bot,
-        subsRepo: subsRepo,
-    }, nil
+    return NewTelegramNotifierWithAPI(bot), nil
+}
+
+// NewTelegramNotifierWithAPI sends through an existing client.
+func NewTelegramNotifierWithAPI(bot TelegramAPI) *TelegramNotifier {
+    return &TelegramNotifier{bot: bot, templates: DefaultTemplates()}
+}
+
+// UseTemplates renders messages from templates instead of the built-in ones. With sessions
+// set, each chat gets the template set, time zone and language of its settings.
+func (t *TelegramNotifier) UseTemplates(templates *Templates, sessions ports.SessionRepository) {
+    t.templates = templates
+    t.sessions = sessions
+}
+
+func (t *TelegramNotifier) Channel() string {
+    return domain.ChannelTelegram
 }
 
-func (t *TelegramNotifier) SendAlert(userID string, event domain.TransactionEvent) error {
+// Send delivers one message about event to the chat in to.ID.
+func (t *TelegramNotifier) Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (string, error) {
     if t.bot == nil {
-        return nil
+        return "", nil
     }
-
-    // Get all users who subscribed to watch this address
-    subscribers, err := t.subsRepo.ListSubscribersByAddress(context.Background(), event.Blockchain, event.WalletID)
+    if err := ctx.Err(); err != nil {
+        return "", err
+    }
+    session := t.chatSession(ctx, to.ID)
+    view := newMessageEvent(event, session.Settings.Location(), i18n.New(session.PreferredLanguage()))
+    msg, err := t.render(session.Settings, []string{event.MessageKind(), string(domain.KindNative)}, view)
     if err != nil {
-        return fmt.Errorf("failed to get subscribers: %w", err)
+        return "", err
     }
-
-    if len(subscribers) == 0 {
-        return nil // No subscribers for this address
+    sent, err := t.sendToUser(to.ID, msg)
+    if err != nil {
+        return "", classifyTelegramError(err)
     }
+    return strconv.Itoa(sent.MessageID), nil
+}
 
-    // Create a beautiful notification message
-    msg := t.createNotificationMessage(event)
+var _ ports.MessageEditor = (*TelegramNotifier)(nil)
 
-    // Send notification to all subscribers
-    for _, sub := range subscribers {
-        if err := t.sendToUser(sub.ChatID, msg); err != nil {
-            // Log error but continue with other users
-            fmt.Printf("Failed to send notification to chat %s: %v\n", sub.ChatID, err)
+// Edit replaces the text of the message about event sent to the chat in to.ID, e.g. once
+// its transaction is confirmed. Telegram does not notify the chat about edits.
+func (t *TelegramNotifier) Edit(ctx context.Context, to domain.Recipient, messageID string, event domain.TransactionEvent) error {
+    if t.bot == nil {
+        return nil
+    }
+    id, err := strconv.Atoi(messageID)
+    if err != nil {
+        return domain.PermanentDeliveryError(fmt.Errorf("invalid telegram message id %q: %w", messageID, err))
+    }
+    chatID, err := strconv.ParseInt(to.ID, 10, 64)
+    if err != nil {
+        return domain.PermanentDeliveryError(fmt.Errorf("invalid telegram chat id %q: %w", to.ID, err))
+    }
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    session := t.chatSession(ctx, to.ID)
+    view := newMessageEvent(event, session.Settings.Location(), i18n.New(session.PreferredLanguage()))
+    message, err := t.render(session.Settings, []string{event.MessageKind(), string(domain.KindNative)}, view)
+    if err != nil {
+        return err
+    }
+    edit := tgbotapi.NewEditMessageText(chatID, id, message.Text)
+    edit.ParseMode = parseMode(message)
+    edit.DisableWebPagePreview = true
+    if _, err := t.bot.Send(edit); err != nil {
+        var apiErr *tgbotapi.Error
+        if errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "message is not modified") {
+            return nil
         }
+        return classifyTelegramError(err)
     }
-
     return nil
 }
 
-func (t *TelegramNotifier) createNotificationMessage(event domain.TransactionEvent) string {
-    direction := "📥 Incoming"
-    if event.Direction == domain.DirectionOutgoing {
-        direction = "📤 Outgoing"
+var _ ports.AlertConfirmer = (*TelegramNotifier)(nil)
+
+// RequestConfirmation asks the chat a telegram alert forwards to whether it accepts the
+// alerts of the alert's owner, with a button that sends the alert's code back to the bot.
+func (t *TelegramNotifier) RequestConfirmation(ctx context.Context, alert domain.Alert) error {
+    if t.bot == nil {
+        return fmt.Errorf("telegram is not configured")
+    }
+    target := alert.ConfigString("chatId")
+    chatID, err := strconv.ParseInt(target, 10, 64)
+    if err != nil {
+        return fmt.Errorf("invalid telegram chat id %q: %w", target, err)
+    }
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+    l := i18n.New(t.chatSession(ctx, target).PreferredLanguage())
+    msg := tgbotapi.NewMessage(chatID, l.T("channels.confirm_request", alert.UserID))
+    msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+        tgbotapi.NewInlineKeyboardButtonData(l.T("button.allow"), "alert_confirm_"+alert.ID+"_"+alert.ConfirmCode),
+    ))
+    _, err = t.bot.Send(msg)
+    return err
+}
+
+var _ ports.DigestNotifier = (*TelegramNotifier)(nil)
+
+// SendDigest sends a digest as one summary message to the chat in to.ID.
+func (t *TelegramNotifier) SendDigest(ctx context.Context, to domain.Recipient, digest domain.Digest) (string, error) {
+    if t.bot == nil {
+        return "", nil
+    }
+    if err := ctx.Err(); err != nil {
+        return "", err
+    }
+    session := t.chatSession(ctx, to.ID)
+    msg, err := t.render(session.Settings, []string{TemplateDigest}, newMessageDigest(digest, i18n.New(session.PreferredLanguage())))
+    if err != nil {
+        return "", err
+    }
+    sent, err := t.sendToUser(to.ID, msg)
+    if err != nil {
+        return "", classifyTelegramError(err)
     }
+    return strconv.Itoa(sent.MessageID), nil
+}
 
-    blockchain := "🔷 Ethereum"
-    if event.Blockchain == "bitcoin" {
-        blockchain = "🟠 Bitcoin"
+// chatSession returns the session of a chat, holding its settings and language, or an empty
+// one when it cannot be loaded.
+func (t *TelegramNotifier) chatSession(ctx context.Context, chatID string) domain.TelegramSession {
+    if t.sessions == nil {
+        return domain.TelegramSession{}
     }
+    session, err := t.sessions.GetTelegramSession(ctx, chatID)
+    if err != nil {
+        return domain.TelegramSession{}
+    }
+    return session
+}
 
-    timestamp := time.Unix(event.Timestamp, 0).Format("2006-01-02 15:04:05")
-    
-    return fmt.Sprintf(`🚨 *Transaction Alert*
+// render renders the first of kinds the chat's template set has. A template that fails to
+// render fails the same way on every retry, so the error is permanent.
+func (t *TelegramNotifier) render(settings domain.ChatSettings, kinds []string, data any) (Rendered, error) {
+    msg, err := t.templates.Render(settings.Template, domain.ChannelTelegram, kinds, []string{FormatMarkdownV2, FormatHTML}, data)
+    if err != nil {
+        return Rendered{}, domain.PermanentDeliveryError(err)
+    }
+    return msg, nil
+}
 
-%s %s
-%s
+// classifyTelegramError tells the delivery queue which failures to wait out and which
+// cannot be fixed by retrying.
+func classifyTelegramError(err error) error {
+    var apiErr *tgbotapi.Error
+    if !errors.As(err, &apiErr) {
+        return err
+    }
+    switch {
+    case apiErr.RetryAfter > 0:
+        return domain.RetryDeliveryAfter(err, time.Duration(apiErr.RetryAfter)*time.Second)
+    case apiErr.Code == http.StatusForbidden:
+        // Bot blocked by the user, kicked from the group or the user was deactivated.
+        return domain.PermanentDeliveryError(err)
+    case apiErr.Code == http.StatusBadRequest && strings.Contains(strings.ToLower(apiErr.Message), "chat not found"):
+        return domain.PermanentDeliveryError(err)
+    }
+    return err
+}
 
-💰 *Amount:* %.6f %s
-🔗 *Network:* %s
-📍 *Address:* ` + "`%s`" + `
-🆔 *Tx Hash:* ` + "`%s`" + `
-⏰ *Time:* %s
+// burstTarget names the address a burst went to, or how many addresses it spread over.
+func burstTarget(events []domain.TransactionEvent) string {
+    target, n := burstAddresses(events)
+    if target != "" {
+        return "`" + target + "`"
+    }
+    return fmt.Sprintf("%d addresses", n)
+}
 
-[View on Etherscan](https://etherscan.io/tx/%s)`,
-        direction, event.Direction,
-        blockchain,
-        event.Amount, event.Currency,
-        strings.Title(event.Blockchain),
-        event.WalletID,
-        event.TxHash,
-        timestamp,
-        event.TxHash)
+// shortAddress keeps the start and end of a long address.
+func shortAddress(addr string) string {
+    if len(addr) <= 14 {
+        return addr
+    }
+    return addr[:8] + "…" + addr[len(addr)-4:]
 }
 
-func (t *TelegramNotifier) sendToUser(chatID, message string) error {
-    msg := tgbotapi.NewMessage(0, message)
-    msg.ParseMode = tgbotapi.ModeMarkdown
-    var chatIDInt int64
-    fmt.Sscanf(chatID, "%d", &chatIDInt)
-    msg.ChatID = chatIDInt
+func (t *TelegramNotifier) sendToUser(chatID string, message Rendered) (tgbotapi.Message, error) {
+    chatIDInt, err := strconv.ParseInt(chatID, 10, 64)
+    if err != nil {
+        return tgbotapi.Message{}, domain.PermanentDeliveryError(fmt.Errorf("invalid telegram chat id %q: %w", chatID, err))
+    }
+    msg := tgbotapi.NewMessage(chatIDInt, message.Text)
+    msg.ParseMode = parseMode(message)
     msg.DisableWebPagePreview = true
 
-    _, err := t.bot.Send(msg)
-    return err
+    return t.bot.Send(msg)
 }
 
-
+// parseMode is the Telegram parse mode of a rendered message.
+func parseMode(message Rendered) string {
+    if message.Format == FormatHTML {
+        return tgbotapi.ModeHTML
+    }
+    return tgbotapi.ModeMarkdownV2
+}
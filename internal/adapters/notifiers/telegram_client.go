@@ -0,0 +1,36 @@
+package notifiers
+
+import (
+    "net/http"
+    "strings"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "golang.org/x/time/rate"
+)
+
+// rateLimitedClient makes the requests of a Telegram bot client wait for their turn, so
+// everything sending through the client, alerts and bot replies alike, stays under
+// Telegram's limit for the whole bot. Long polling for updates is not limited.
+type rateLimitedClient struct {
+    client  tgbotapi.HTTPClient
+    limiter *rate.Limiter
+}
+
+// NewRateLimitedTelegramClient wraps client so requests to the bot API go out at most
+// perSecond times per second; 0 returns client as it is. The limit is kept in memory, so
+// each process using the bot enforces it on its own.
+func NewRateLimitedTelegramClient(client tgbotapi.HTTPClient, perSecond int) tgbotapi.HTTPClient {
+    if perSecond <= 0 {
+        return client
+    }
+    return &rateLimitedClient{client: client, limiter: rate.NewLimiter(rate.Limit(perSecond), 1)}
+}
+
+func (c *rateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+    if !strings.HasSuffix(req.URL.Path, "/getUpdates") {
+        if err := c.limiter.Wait(req.Context()); err != nil {
+            return nil, err
+        }
+    }
+    return c.client.Do(req)
+}
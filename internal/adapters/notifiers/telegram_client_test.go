@@ -0,0 +1,62 @@
+package notifiers
+
+import (
+    "io"
+    "net/http"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type countingClient struct {
+    mu    sync.Mutex
+    paths []string
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+    c.mu.Lock()
+    c.paths = append(c.paths, req.URL.Path)
+    c.mu.Unlock()
+    return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"ok":true}`))}, nil
+}
+
+func requestTimes(t *testing.T, client tgbotapi.HTTPClient, method string, n int) time.Duration {
+    t.Helper()
+    start := time.Now()
+    var wg sync.WaitGroup
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            req, _ := http.NewRequest(http.MethodPost, "https://api.telegram.org/botTOKEN/"+method, nil)
+            if _, err := client.Do(req); err != nil {
+                t.Error(err)
+            }
+        }()
+    }
+    wg.Wait()
+    return time.Since(start)
+}
+
+func TestRateLimitedTelegramClient(t *testing.T) {
+    inner := &countingClient{}
+    client := NewRateLimitedTelegramClient(inner, 20)
+
+    // The first request goes out right away, each further one 50ms later.
+    if took := requestTimes(t, client, "sendMessage", 6); took < 200*time.Millisecond {
+        t.Errorf("6 messages took %s, want at least 250ms at 20 per second", took)
+    }
+    if took := requestTimes(t, client, "getUpdates", 6); took > 40*time.Millisecond {
+        t.Errorf("polling for updates took %s, want it unlimited", took)
+    }
+    if len(inner.paths) != 12 {
+        t.Errorf("%d requests reached the API, want 12", len(inner.paths))
+    }
+
+    if NewRateLimitedTelegramClient(inner, 0) != inner {
+        t.Error("a limit of 0 still wraps the client")
+    }
+}
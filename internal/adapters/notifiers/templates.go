@@ -0,0 +1,202 @@
+package notifiers
+
+import (
+    "bytes"
+    "embed"
+    "fmt"
+    "html"
+    "io/fs"
+    "os"
+    "sort"
+    "strings"
+    "text/template"
+)
+
+// Message formats, named by the extension of the template files written in them.
+const (
+    FormatMarkdownV2 = "md"   // Telegram MarkdownV2
+    FormatHTML       = "html" // Telegram or email HTML
+    FormatText       = "txt"  // plain text
+)
+
+// DefaultTemplateSet is the set used for chats that did not pick one. Every other set falls
+// back to it for the templates it does not have.
+const DefaultTemplateSet = "default"
+
+// Template kinds besides the event kinds of domain.TransactionEvent.MessageKind.
+const (
+    TemplateDigest = "digest"
+)
+
+//go:embed templates
+var embeddedTemplates embed.FS
+
+// Templates renders messages from text/template files laid out as
+// <set>/<channel>/<kind>.<format>, e.g. default/telegram/token.md. The escaping helpers
+// esc, code, bold and link escape for the format of the file they are used in.
+type Templates struct {
+    // set → channel → kind → format
+    sets map[string]map[string]map[string]map[string]*template.Template
+}
+
+// Rendered is a rendered message and the format it is written in.
+type Rendered struct {
+    Text   string
+    Format string
+}
+
+// LoadTemplates loads the built-in templates, then the files under dir, if set. Files in dir
+// replace the built-in ones of the same path; new set directories add sets chats can pick.
+func LoadTemplates(dir string) (*Templates, error) {
+    t := &Templates{sets: make(map[string]map[string]map[string]map[string]*template.Template)}
+    builtin, err := fs.Sub(embeddedTemplates, "templates")
+    if err != nil {
+        return nil, err
+    }
+    if err := t.load(builtin); err != nil {
+        return nil, fmt.Errorf("built-in templates: %w", err)
+    }
+    if dir != "" {
+        if err := t.load(os.DirFS(dir)); err != nil {
+            return nil, fmt.Errorf("templates in %s: %w", dir, err)
+        }
+    }
+    return t, nil
+}
+
+// DefaultTemplates are the built-in templates only.
+func DefaultTemplates() *Templates {
+    t, err := LoadTemplates("")
+    if err != nil {
+        panic(err)
+    }
+    return t
+}
+
+func (t *Templates) load(fsys fs.FS) error {
+    return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+        if err != nil || d.IsDir() {
+            return err
+        }
+        parts := strings.Split(path, "/")
+        if len(parts) != 3 {
+            return nil
+        }
+        kind, format, _ := strings.Cut(parts[2], ".")
+        if format != FormatMarkdownV2 && format != FormatHTML && format != FormatText {
+            return nil
+        }
+        body, err := fs.ReadFile(fsys, path)
+        if err != nil {
+            return err
+        }
+        tmpl, err := template.New(path).Option("missingkey=error").Funcs(templateFuncs(format)).Parse(string(body))
+        if err != nil {
+            return err
+        }
+
+        set, channel := parts[0], parts[1]
+        if t.sets[set] == nil {
+            t.sets[set] = make(map[string]map[string]map[string]*template.Template)
+        }
+        if t.sets[set][channel] == nil {
+            t.sets[set][channel] = make(map[string]map[string]*template.Template)
+        }
+        if t.sets[set][channel][kind] == nil {
+            t.sets[set][channel][kind] = make(map[string]*template.Template)
+        }
+        t.sets[set][channel][kind][format] = tmpl
+        return nil
+    })
+}
+
+// Sets lists the template sets, the default set first.
+func (t *Templates) Sets() []string {
+    var names []string
+    for name := range t.sets {
+        if name != DefaultTemplateSet {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    return append([]string{DefaultTemplateSet}, names...)
+}
+
+// HasSet reports whether a set of the given name was loaded.
+func (t *Templates) HasSet(name string) bool {
+    _, ok := t.sets[name]
+    return ok
+}
+
+// Render renders the template of the first kind found for channel, looking in set and then
+// in the default set for each kind, in one of formats, which are in order of preference.
+func (t *Templates) Render(set string, channel string, kinds []string, formats []string, data any) (Rendered, error) {
+    sets := []string{set, DefaultTemplateSet}
+    if set == "" || set == DefaultTemplateSet {
+        sets = sets[1:]
+    }
+    for _, kind := range kinds {
+        for _, s := range sets {
+            for _, format := range formats {
+                tmpl := t.sets[s][channel][kind][format]
+                if tmpl == nil {
+                    continue
+                }
+                var b bytes.Buffer
+                if err := tmpl.Execute(&b, data); err != nil {
+                    return Rendered{}, err
+                }
+                return Rendered{Text: strings.TrimSpace(b.String()), Format: format}, nil
+            }
+        }
+    }
+    return Rendered{}, fmt.Errorf("no %s template for %s in set %q", channel, strings.Join(kinds, " or "), set)
+}
+
+// templateFuncs are the helpers templates of a format can call. Values are inserted as
+// written, so templates have to escape them with esc or one of the formatting helpers.
+func templateFuncs(format string) template.FuncMap {
+    funcs := template.FuncMap{
+        "short":  shortAddress,
+        "amount": formatAmount,
+        "upper":  strings.ToUpper,
+        "inc":    func(i int) int { return i + 1 },
+    }
+    switch format {
+    case FormatMarkdownV2:
+        funcs["esc"] = escapeMarkdownV2
+        funcs["code"] = func(s string) string { return "`" + markdownV2Code.Replace(s) + "`" }
+        funcs["bold"] = func(s string) string { return "*" + escapeMarkdownV2(s) + "*" }
+        funcs["link"] = func(text, url string) string {
+            return "[" + escapeMarkdownV2(text) + "](" + markdownV2URL.Replace(url) + ")"
+        }
+    case FormatHTML:
+        funcs["esc"] = html.EscapeString
+        funcs["code"] = func(s string) string { return "<code>" + html.EscapeString(s) + "</code>" }
+        funcs["bold"] = func(s string) string { return "<b>" + html.EscapeString(s) + "</b>" }
+        funcs["link"] = func(text, url string) string {
+            return `<a href="` + html.EscapeString(url) + `">` + html.EscapeString(text) + "</a>"
+        }
+    default:
+        funcs["esc"] = func(s string) string { return s }
+        funcs["code"] = funcs["esc"]
+        funcs["bold"] = funcs["esc"]
+        funcs["link"] = func(text, url string) string { return text + ": " + url }
+    }
+    return funcs
+}
+
+// Characters Telegram's MarkdownV2 requires to be escaped in text, in code and in link URLs.
+var (
+    markdownV2Text = strings.NewReplacer(
+        `\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`,
+        "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`,
+        "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+    )
+    markdownV2Code = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+    markdownV2URL  = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+func escapeMarkdownV2(s string) string {
+    return markdownV2Text.Replace(s)
+}
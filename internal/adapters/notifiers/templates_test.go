@@ -0,0 +1,45 @@
+package notifiers
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+)
+
+func TestReplacedTemplatesExplainTheReplacement(t *testing.T) {
+    evt := testTransfer()
+    evt.Status = domain.StatusReplaced
+    view := newMessageEvent(evt, nil, i18n.Localizer{})
+    note := view.T("alert.replaced.note")
+
+    channels := map[string][]string{
+        domain.ChannelTelegram: {FormatMarkdownV2},
+        domain.ChannelEmail:    {FormatText, FormatHTML},
+    }
+    for channel, formats := range channels {
+        for _, format := range formats {
+            // Without native to fall back to, a missing replaced template fails to render.
+            msg, err := DefaultTemplates().Render(DefaultTemplateSet, channel, []string{view.MessageKind}, []string{format}, view)
+            if err != nil {
+                t.Fatalf("%s %s: %v", channel, format, err)
+            }
+            text := strings.ReplaceAll(msg.Text, `\`, "")
+            if !strings.Contains(text, note) || !strings.Contains(text, view.T("alert.replaced.title")) {
+                t.Errorf("%s %s does not say the transaction was replaced:\n%s", channel, format, msg.Text)
+            }
+            if !strings.Contains(text, evt.TxHash) {
+                t.Errorf("%s %s misses the transaction hash:\n%s", channel, format, msg.Text)
+            }
+        }
+    }
+}
+
+func TestChainName(t *testing.T) {
+    for in, want := range map[string]string{"ethereum": "Ethereum", "bitcoin": "Bitcoin", "": "", "éther": "Éther"} {
+        if got := chainName(in); got != want {
+            t.Errorf("chainName(%q) = %q, want %q", in, got, want)
+        }
+    }
+}
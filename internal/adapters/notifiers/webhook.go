@@ -0,0 +1,138 @@
+package notifiers
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// WebhookPayloadVersion is the version of the JSON body POSTed to webhooks. It changes only
+// when fields are removed or change meaning.
+const WebhookPayloadVersion = "1"
+
+// Headers sent with every webhook request.
+const (
+    WebhookSignatureHeader = "X-Webhook-Signature"
+    WebhookTimestampHeader = "X-Webhook-Timestamp"
+    WebhookEventHeader     = "X-Webhook-Event-Id"
+)
+
+// WebhookPayload is the body of a webhook request.
+type WebhookPayload struct {
+    Version string             `json:"version"`
+    Type    string             `json:"type"` // "transaction"
+    // ID is the event ID. It is the same on every retry, so receivers can drop duplicates.
+    ID      string             `json:"id"`
+    Data    WebhookTransaction `json:"data"`
+}
+
+// WebhookTransaction describes the transfer a webhook is called for.
+type WebhookTransaction struct {
+    Blockchain   string           `json:"blockchain"`
+    Address      string           `json:"address"`
+    TxHash       string           `json:"txHash"`
+    LogIndex     int              `json:"logIndex"`
+    Direction    domain.Direction `json:"direction"`
+    Counterparty string           `json:"counterparty,omitempty"`
+    Amount       float64          `json:"amount"`
+    Currency     string           `json:"currency"`
+    Timestamp    int64            `json:"timestamp"`
+    ExplorerURL  string           `json:"explorerUrl"`
+    // Kind and Status are omitted for confirmed transfers of the chain's own currency.
+    Kind          domain.EventKind   `json:"kind,omitempty"`
+    Status        domain.EventStatus `json:"status,omitempty"`
+    TokenContract string             `json:"tokenContract,omitempty"`
+    TokenID       string             `json:"tokenId,omitempty"`
+    // FiatValue is the value at block time in FiatCurrency, omitted when it is unknown.
+    FiatValue     float64            `json:"fiatValue,omitempty"`
+    FiatCurrency  string             `json:"fiatCurrency,omitempty"`
+}
+
+func newWebhookPayload(event domain.TransactionEvent) WebhookPayload {
+    return WebhookPayload{
+        Version: WebhookPayloadVersion,
+        Type:    "transaction",
+        ID:      event.ID,
+        Data: WebhookTransaction{
+            Blockchain:   event.Blockchain,
+            Address:      event.WalletID,
+            TxHash:       event.TxHash,
+            LogIndex:     event.LogIndex,
+            Direction:    event.Direction,
+            Counterparty: event.Counterparty,
+            Amount:       event.Amount,
+            Currency:     event.Currency,
+            Timestamp:    event.Timestamp,
+            ExplorerURL:  explorerTxURL(event.Blockchain, event.TxHash),
+            Kind:          event.Kind,
+            Status:        event.Status,
+            TokenContract: event.TokenContract,
+            TokenID:       event.TokenID,
+            FiatValue:     event.FiatValue,
+            FiatCurrency:  event.FiatCurrency,
+        },
+    }
+}
+
+// SignWebhook returns the signature header value of a webhook body: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the endpoint secret, prefixed with the scheme version.
+// Receivers recompute it and reject requests whose timestamp is more than a few minutes old.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+    mac.Write([]byte("."))
+    mac.Write(body)
+    return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookNotifier POSTs alerts to the webhook alerts registered by their owners. Retries are
+// left to the delivery queue; an endpoint that keeps failing is disabled.
+type WebhookNotifier struct {
+    alertChannel
+    client *http.Client
+}
+
+var _ ports.Notifier = (*WebhookNotifier)(nil)
+
+// NewWebhookNotifier sends with the given request timeout and disables an endpoint after
+// maxFailures failed deliveries in a row; zero never disables.
+func NewWebhookNotifier(alerts ports.AlertRepository, timeout time.Duration, maxFailures int) *WebhookNotifier {
+    return &WebhookNotifier{
+        alertChannel: alertChannel{alerts: alerts, maxFailures: maxFailures},
+        client:       newAlertHTTPClient(timeout),
+    }
+}
+
+func (w *WebhookNotifier) Channel() string {
+    return domain.ChannelWebhook
+}
+
+// Send POSTs event to the webhook alert with ID to.ID.
+func (w *WebhookNotifier) Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (string, error) {
+    alert, err := w.load(ctx, to)
+    if err != nil {
+        return "", err
+    }
+    body, err := json.Marshal(newWebhookPayload(event))
+    if err != nil {
+        return "", domain.PermanentDeliveryError(err)
+    }
+
+    timestamp := time.Now().Unix()
+    header := http.Header{}
+    header.Set("User-Agent", "wallet-transaction-notifier/"+WebhookPayloadVersion)
+    header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+    header.Set(WebhookSignatureHeader, SignWebhook(alert.ConfigString("secret"), timestamp, body))
+    header.Set(WebhookEventHeader, event.ID)
+    _, err = postJSON(ctx, w.client, alert.ConfigString("url"), body, header)
+    w.track(ctx, alert, err)
+    return "", err
+}
@@ -0,0 +1,77 @@
+package prices
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Cache remembers the quotes of another provider. Quotes are rounded to the bucket around
+// their time, so the transfers of one block or burst cost a single lookup, and kept for ttl.
+// Unknown prices are remembered as well, so unlisted tokens are not looked up every time.
+type Cache struct {
+    provider ports.PriceProvider
+    bucket   time.Duration
+    ttl      time.Duration
+
+    mu      sync.Mutex
+    entries map[cacheKey]cacheEntry
+    swept   time.Time
+}
+
+var _ ports.PriceProvider = (*Cache)(nil)
+
+type cacheKey struct {
+    asset domain.Asset
+    fiat  string
+    at    int64
+}
+
+type cacheEntry struct {
+    price   float64
+    err     error
+    expires time.Time
+}
+
+// NewCache caches the quotes of provider, treating times within bucket as the same.
+func NewCache(provider ports.PriceProvider, bucket time.Duration, ttl time.Duration) *Cache {
+    if bucket <= 0 {
+        bucket = time.Minute
+    }
+    return &Cache{provider: provider, bucket: bucket, ttl: ttl, entries: make(map[cacheKey]cacheEntry)}
+}
+
+func (c *Cache) Price(ctx context.Context, asset domain.Asset, fiat string, at time.Time) (float64, error) {
+    key := cacheKey{asset: asset, fiat: strings.ToLower(fiat), at: at.Truncate(c.bucket).Unix()}
+    now := time.Now()
+
+    c.mu.Lock()
+    entry, ok := c.entries[key]
+    c.mu.Unlock()
+    if ok && now.Before(entry.expires) {
+        return entry.price, entry.err
+    }
+
+    price, err := c.provider.Price(ctx, asset, key.fiat, at)
+    if err != nil && !errors.Is(err, domain.ErrPriceUnknown) {
+        // Network and rate limit errors are worth retrying with the next transfer.
+        return 0, err
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.entries[key] = cacheEntry{price: price, err: err, expires: now.Add(c.ttl)}
+    if now.Sub(c.swept) > c.ttl {
+        for k, e := range c.entries {
+            if !now.Before(e.expires) {
+                delete(c.entries, k)
+            }
+        }
+        c.swept = now
+    }
+    return price, err
+}
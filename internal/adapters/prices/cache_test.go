@@ -0,0 +1,84 @@
+package prices
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// countingProvider quotes the next of its results and counts the lookups.
+type countingProvider struct {
+    calls   int
+    results []error
+}
+
+func (p *countingProvider) Price(_ context.Context, asset domain.Asset, fiat string, _ time.Time) (float64, error) {
+    var err error
+    if p.calls < len(p.results) {
+        err = p.results[p.calls]
+    }
+    p.calls++
+    if err != nil {
+        return 0, err
+    }
+    return float64(p.calls), nil
+}
+
+func TestCacheBucketsQuotes(t *testing.T) {
+    p := &countingProvider{}
+    c := NewCache(p, 5*time.Minute, time.Hour)
+    ctx := context.Background()
+    at := time.Date(2026, 1, 2, 3, 1, 0, 0, time.UTC)
+
+    first, _ := c.Price(ctx, eth, "usd", at)
+    same, _ := c.Price(ctx, eth, "USD", at.Add(2*time.Minute))
+    if same != first || p.calls != 1 {
+        t.Errorf("same bucket: got %v after %d lookups, want %v after 1", same, p.calls, first)
+    }
+    if _, err := c.Price(ctx, eth, "usd", at.Add(5*time.Minute)); err != nil || p.calls != 2 {
+        t.Errorf("next bucket: %d lookups, want 2 (%v)", p.calls, err)
+    }
+    if _, err := c.Price(ctx, eth, "eur", at); err != nil || p.calls != 3 {
+        t.Errorf("other fiat: %d lookups, want 3 (%v)", p.calls, err)
+    }
+}
+
+func TestCacheRemembersUnknownPrices(t *testing.T) {
+    p := &countingProvider{results: []error{fmt.Errorf("%w: spam", domain.ErrPriceUnknown)}}
+    c := NewCache(p, time.Minute, time.Hour)
+    for i := 0; i < 2; i++ {
+        if _, err := c.Price(context.Background(), usdt, "usd", time.Now()); !errors.Is(err, domain.ErrPriceUnknown) {
+            t.Fatalf("lookup %d: err = %v, want unknown price", i, err)
+        }
+    }
+    if p.calls != 1 {
+        t.Errorf("%d lookups, want 1", p.calls)
+    }
+}
+
+func TestCacheRetriesFailures(t *testing.T) {
+    p := &countingProvider{results: []error{errors.New("rate limited")}}
+    c := NewCache(p, time.Minute, time.Hour)
+    at := time.Now()
+    if _, err := c.Price(context.Background(), eth, "usd", at); err == nil {
+        t.Fatal("failure was not returned")
+    }
+    if price, err := c.Price(context.Background(), eth, "usd", at); err != nil || price != 2 {
+        t.Errorf("retry: got %v, %v; want 2 from a second lookup", price, err)
+    }
+}
+
+func TestCacheExpires(t *testing.T) {
+    p := &countingProvider{}
+    c := NewCache(p, time.Minute, -time.Second)
+    at := time.Now()
+    c.Price(context.Background(), eth, "usd", at)
+    c.Price(context.Background(), eth, "usd", at)
+    if p.calls != 2 {
+        t.Errorf("%d lookups, want 2 once the quote expired", p.calls)
+    }
+}
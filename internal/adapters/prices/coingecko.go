@@ -0,0 +1,154 @@
+package prices
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "math"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// DefaultCoinGeckoURL is the public CoinGecko API.
+const DefaultCoinGeckoURL = "https://api.coingecko.com/api/v3"
+
+// coinIDs maps the currencies of the supported chains to CoinGecko coin IDs. Tokens are
+// looked up by contract on the chains in platforms, never by symbol, which anyone can copy.
+var coinIDs = map[string]string{
+    "BTC": "bitcoin",
+    "ETH": "ethereum",
+}
+
+// platforms maps blockchains to the CoinGecko asset platforms their tokens are listed on.
+var platforms = map[string]string{
+    "ethereum": "ethereum",
+}
+
+// recentPrice is how old a transfer may be to be valued at the current price rather than
+// from the price history.
+const recentPrice = 10 * time.Minute
+
+// CoinGecko quotes assets through the CoinGecko API: the current price for recent transfers,
+// otherwise the point of the price history closest to the transfer.
+type CoinGecko struct {
+    baseURL string
+    apiKey  string
+    client  *http.Client
+}
+
+var _ ports.PriceProvider = (*CoinGecko)(nil)
+
+// NewCoinGecko uses the API at baseURL, DefaultCoinGeckoURL when empty. apiKey is optional;
+// keys for the paid API go with its pro-api.coingecko.com URL.
+func NewCoinGecko(baseURL string, apiKey string, timeout time.Duration) *CoinGecko {
+    if baseURL == "" {
+        baseURL = DefaultCoinGeckoURL
+    }
+    return &CoinGecko{
+        baseURL: strings.TrimRight(baseURL, "/"),
+        apiKey:  apiKey,
+        client:  &http.Client{Timeout: timeout},
+    }
+}
+
+func (c *CoinGecko) Price(ctx context.Context, asset domain.Asset, fiat string, at time.Time) (float64, error) {
+    fiat = strings.ToLower(fiat)
+    coin, byContract := c.coinPath(asset)
+    if coin == "" {
+        return 0, fmt.Errorf("%w: %s is not listed", domain.ErrPriceUnknown, asset)
+    }
+
+    if time.Since(at) < recentPrice {
+        query := url.Values{"vs_currencies": {fiat}}
+        path := "/simple/price"
+        key := coin
+        if byContract {
+            path = "/simple/token_price/" + platforms[asset.Blockchain]
+            query.Set("contract_addresses", asset.Contract)
+            key = asset.Contract
+        } else {
+            query.Set("ids", coin)
+        }
+        var quotes map[string]map[string]float64
+        if err := c.get(ctx, path, query, &quotes); err != nil {
+            return 0, err
+        }
+        price, ok := quotes[key][fiat]
+        if !ok {
+            return 0, fmt.Errorf("%w: no %s price of %s", domain.ErrPriceUnknown, fiat, asset)
+        }
+        return price, nil
+    }
+
+    query := url.Values{
+        "vs_currency": {fiat},
+        "from":        {strconv.FormatInt(at.Add(-time.Hour).Unix(), 10)},
+        "to":          {strconv.FormatInt(at.Add(time.Hour).Unix(), 10)},
+    }
+    var history struct {
+        Prices [][2]float64 `json:"prices"` // [unix milliseconds, price]
+    }
+    if err := c.get(ctx, "/coins/"+coin+"/market_chart/range", query, &history); err != nil {
+        return 0, err
+    }
+    closest, best := 0.0, math.Inf(1)
+    for _, p := range history.Prices {
+        if d := math.Abs(p[0] - float64(at.UnixMilli())); d < best {
+            closest, best = p[1], d
+        }
+    }
+    if math.IsInf(best, 1) {
+        return 0, fmt.Errorf("%w: no %s price of %s around %s", domain.ErrPriceUnknown, fiat, asset, at.UTC().Format(time.RFC3339))
+    }
+    return closest, nil
+}
+
+// coinPath returns the path of the asset under /coins, and whether it is a token looked up by
+// contract. It is empty for assets CoinGecko cannot be asked about.
+func (c *CoinGecko) coinPath(asset domain.Asset) (string, bool) {
+    if asset.Contract == "" {
+        return coinIDs[asset.Symbol], false
+    }
+    if platform, ok := platforms[asset.Blockchain]; ok {
+        return platform + "/contract/" + url.PathEscape(asset.Contract), true
+    }
+    return "", false
+}
+
+func (c *CoinGecko) get(ctx context.Context, path string, query url.Values, out any) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+    if c.apiKey != "" {
+        header := "x-cg-demo-api-key"
+        if strings.Contains(c.baseURL, "pro-api.") {
+            header = "x-cg-pro-api-key"
+        }
+        req.Header.Set(header, c.apiKey)
+    }
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return fmt.Errorf("coingecko: %w", err)
+    }
+    defer resp.Body.Close()
+    switch {
+    case resp.StatusCode == http.StatusNotFound:
+        return fmt.Errorf("%w: coingecko has no %s", domain.ErrPriceUnknown, path)
+    case resp.StatusCode == http.StatusTooManyRequests:
+        return fmt.Errorf("coingecko: rate limited")
+    case resp.StatusCode >= 300:
+        return fmt.Errorf("coingecko: %s returned %s", path, resp.Status)
+    }
+    if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+        return fmt.Errorf("coingecko: decode %s: %w", path, err)
+    }
+    return nil
+}
@@ -0,0 +1,61 @@
+package prices
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// StaticPrices quotes fixed prices regardless of time, for tests and setups without a price
+// API. Prices are keyed by fiat currency, then by asset symbol (e.g. "ETH") or by
+// "<blockchain>:<token contract>".
+type StaticPrices struct {
+    prices map[string]map[string]float64
+}
+
+var _ ports.PriceProvider = (*StaticPrices)(nil)
+
+func NewStaticPrices(prices map[string]map[string]float64) *StaticPrices {
+    s := &StaticPrices{prices: make(map[string]map[string]float64)}
+    for fiat, quotes := range prices {
+        normalized := make(map[string]float64, len(quotes))
+        for key, price := range quotes {
+            normalized[strings.ToLower(key)] = price
+        }
+        s.prices[strings.ToLower(fiat)] = normalized
+    }
+    return s
+}
+
+// LoadStaticPrices reads prices from a JSON file such as
+// {"usd": {"ETH": 3000, "ethereum:0xa0b8...": 1}}.
+func LoadStaticPrices(path string) (*StaticPrices, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("read prices: %w", err)
+    }
+    var prices map[string]map[string]float64
+    if err := json.Unmarshal(data, &prices); err != nil {
+        return nil, fmt.Errorf("parse prices %s: %w", path, err)
+    }
+    return NewStaticPrices(prices), nil
+}
+
+func (s *StaticPrices) Price(_ context.Context, asset domain.Asset, fiat string, _ time.Time) (float64, error) {
+    quotes := s.prices[strings.ToLower(fiat)]
+    if asset.Contract != "" {
+        if price, ok := quotes[strings.ToLower(asset.Blockchain+":"+asset.Contract)]; ok {
+            return price, nil
+        }
+    }
+    if price, ok := quotes[strings.ToLower(asset.Symbol)]; ok && asset.Symbol != "" {
+        return price, nil
+    }
+    return 0, fmt.Errorf("%w: %s in %s", domain.ErrPriceUnknown, asset, fiat)
+}
@@ -0,0 +1,67 @@
+package prices
+
+import (
+    "context"
+    "errors"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+var (
+    eth  = domain.Asset{Blockchain: "ethereum", Symbol: "ETH"}
+    usdt = domain.Asset{Blockchain: "ethereum", Symbol: "USDT", Contract: "0xdac17f958d2ee523a2206206994597c13d831ec7"}
+)
+
+func TestStaticPrices(t *testing.T) {
+    s := NewStaticPrices(map[string]map[string]float64{
+        "USD": {"eth": 2000, "ethereum:0xDAC17F958D2EE523A2206206994597C13D831EC7": 1},
+    })
+    tests := []struct {
+        name  string
+        asset domain.Asset
+        fiat  string
+        want  float64
+        err   error
+    }{
+        {"symbol", eth, "usd", 2000, nil},
+        {"fiat case", eth, "USD", 2000, nil},
+        {"contract", usdt, "usd", 1, nil},
+        {"unknown asset", domain.Asset{Blockchain: "bitcoin", Symbol: "BTC"}, "usd", 0, domain.ErrPriceUnknown},
+        {"unknown fiat", eth, "eur", 0, domain.ErrPriceUnknown},
+    }
+    for _, tt := range tests {
+        got, err := s.Price(context.Background(), tt.asset, tt.fiat, time.Now())
+        if got != tt.want || !errors.Is(err, tt.err) {
+            t.Errorf("%s: got %v, %v; want %v, %v", tt.name, got, err, tt.want, tt.err)
+        }
+    }
+}
+
+func TestLoadStaticPrices(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "prices.json")
+    if err := os.WriteFile(path, []byte(`{"eur": {"BTC": 40000}}`), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    s, err := LoadStaticPrices(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    btc := domain.Asset{Blockchain: "bitcoin", Symbol: "BTC"}
+    if got, err := s.Price(context.Background(), btc, "eur", time.Now()); got != 40000 || err != nil {
+        t.Errorf("got %v, %v; want 40000", got, err)
+    }
+
+    if err := os.WriteFile(path, []byte(`{"eur": ["BTC"]}`), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := LoadStaticPrices(path); err == nil {
+        t.Error("malformed prices loaded")
+    }
+    if _, err := LoadStaticPrices(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+        t.Error("missing file loaded")
+    }
+}
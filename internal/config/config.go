@@ -4,6 +4,7 @@ import (
     "log"
     "os"
     "strconv"
+    "strings"
     "time"
 )
 
@@ -17,6 +18,53 @@ type Config struct {
     BitcoinRPCURL    string
     BitcoinRPCUser   string
     BitcoinRPCPass   string
+    Chains           []string // blockchains to watch, e.g. ethereum,bitcoin
+    PendingChains    []string // blockchains whose pending transactions are reported
+    EthConfirmations int      // blocks after which an Ethereum transfer is reported confirmed again, 0 disables
+    BtcConfirmations int
+    WatchlistRefresh time.Duration
+    AddressMatcher   string // "bloom" or "exact"
+    MatcherCapacity  int    // expected watched addresses per chain, sizes the bloom filter
+    EventBus         string // "memory", "mongo", "nats" or "redis"
+    EventAckTimeout  time.Duration
+    EventRetention   time.Duration
+    EventBufferSize  int
+    EventOverflow    string // drop_newest, drop_oldest, block or spill
+    EventBlockWait   time.Duration
+    EventSpillDir    string
+    EventSubscribers string // per-subscriber overrides, e.g. dispatcher=1024:spill
+    NATSURL          string
+    RedisURL         string
+    Roles            []string // components run by this process: api, watcher, dispatcher, bot
+    DeliveryAttempts int      // send attempts before a delivery is dead-lettered
+    DeliveryBackoff  time.Duration
+    DeliveryMaxWait  time.Duration
+    AdminToken       string   // enables the /admin endpoints, sent as X-Admin-Token
+    ChatRateLimit    int      // alerts per minute per chat before bursts are summarised, 0 disables
+    ChatRateBurst    int
+    BurstWindow      time.Duration
+    TelegramRate     int      // messages per second across all chats, 0 disables
+    TelegramWebhookURL    string // public base URL of the API; switches the bot from long polling to a webhook
+    TelegramWebhookSecret string
+    WebhookTimeout   time.Duration
+    WebhookFailures  int      // failed deliveries in a row before a webhook is disabled, 0 never
+    SMTPHost         string   // enables email alerts
+    SMTPPort         string
+    SMTPUsername     string
+    SMTPPassword     string
+    SMTPFrom         string
+    SMTPSecurity     string   // starttls, tls or none
+    SMTPTimeout      time.Duration
+    StreamBuffer     int      // latest events kept for clients resuming the live stream
+    StreamOrigins    []string // origins allowed to open the stream as a WebSocket, besides the API's own
+    TemplatesDir     string   // message templates overriding or adding to the built-in ones
+    PriceProvider    string   // "coingecko", "static" or "none"
+    PriceFile        string   // prices of the static provider
+    CoinGeckoURL     string
+    CoinGeckoAPIKey  string
+    PriceTimeout     time.Duration
+    PriceCacheTTL    time.Duration
+    RuleCacheTTL     time.Duration // how long dispatchers keep the rules of a chat, 0 disables
 }
 
 func Load() Config {
@@ -30,11 +78,68 @@ func Load() Config {
         BitcoinRPCURL:    getEnv("BITCOIN_RPC_URL", "localhost:8332"),
         BitcoinRPCUser:   getEnv("BITCOIN_RPC_USER", "bitcoin"),
         BitcoinRPCPass:   getEnv("BITCOIN_RPC_PASS", "bitcoin"),
+        Chains:           getEnvList("CHAINS", "ethereum"),
+        PendingChains:    getEnvList("PENDING_CHAINS", ""),
+        EthConfirmations: getEnvInt("ETH_CONFIRMATIONS", 12),
+        BtcConfirmations: getEnvInt("BTC_CONFIRMATIONS", 6),
+        WatchlistRefresh: getEnvDurationSeconds("WATCHLIST_REFRESH_SECONDS", 300),
+        AddressMatcher:   getEnv("ADDRESS_MATCHER", "bloom"),
+        MatcherCapacity:  getEnvInt("ADDRESS_MATCHER_CAPACITY", 10000),
+        EventBus:         getEnv("EVENT_BUS", "memory"),
+        EventAckTimeout:  getEnvDurationSeconds("EVENT_ACK_TIMEOUT_SECONDS", 30),
+        EventRetention:   getEnvDurationSeconds("EVENT_RETENTION_SECONDS", 7*24*3600),
+        EventBufferSize:  getEnvInt("EVENT_BUS_BUFFER", 32),
+        EventOverflow:    getEnv("EVENT_BUS_OVERFLOW", "drop_newest"),
+        EventBlockWait:   getEnvDurationSeconds("EVENT_BUS_BLOCK_TIMEOUT_SECONDS", 1),
+        EventSpillDir:    getEnv("EVENT_BUS_SPILL_DIR", ""),
+        EventSubscribers: getEnv("EVENT_BUS_SUBSCRIBERS", ""),
+        NATSURL:          getEnv("NATS_URL", "nats://localhost:4222"),
+        RedisURL:         getEnv("REDIS_URL", "redis://localhost:6379/0"),
+        Roles:            getEnvList("APP_ROLES", "api,watcher,dispatcher,bot"),
+        DeliveryAttempts: getEnvInt("DELIVERY_MAX_ATTEMPTS", 8),
+        DeliveryBackoff:  getEnvDurationSeconds("DELIVERY_BACKOFF_SECONDS", 5),
+        DeliveryMaxWait:  getEnvDurationSeconds("DELIVERY_MAX_BACKOFF_SECONDS", 3600),
+        AdminToken:       getEnv("ADMIN_TOKEN", ""),
+        ChatRateLimit:    getEnvInt("CHAT_RATE_PER_MINUTE", 20),
+        ChatRateBurst:    getEnvInt("CHAT_RATE_BURST", 5),
+        BurstWindow:      getEnvDurationSeconds("BURST_WINDOW_SECONDS", 300),
+        TelegramRate:     getEnvInt("TELEGRAM_MAX_PER_SECOND", 30),
+        TelegramWebhookURL:    getEnv("TELEGRAM_WEBHOOK_URL", ""),
+        TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+        WebhookTimeout:   getEnvDurationSeconds("WEBHOOK_TIMEOUT_SECONDS", 10),
+        WebhookFailures:  getEnvInt("WEBHOOK_MAX_FAILURES", 20),
+        SMTPHost:         getEnv("SMTP_HOST", ""),
+        SMTPPort:         getEnv("SMTP_PORT", "587"),
+        SMTPUsername:     getEnv("SMTP_USERNAME", ""),
+        SMTPPassword:     getEnv("SMTP_PASSWORD", ""),
+        SMTPFrom:         getEnv("SMTP_FROM", ""),
+        SMTPSecurity:     strings.ToLower(getEnv("SMTP_SECURITY", "starttls")),
+        SMTPTimeout:      getEnvDurationSeconds("SMTP_TIMEOUT_SECONDS", 30),
+        StreamBuffer:     getEnvInt("STREAM_BUFFER_SIZE", 1000),
+        StreamOrigins:    getEnvList("STREAM_ALLOWED_ORIGINS", ""),
+        TemplatesDir:     getEnv("TEMPLATES_DIR", ""),
+        PriceProvider:    strings.ToLower(getEnv("PRICE_PROVIDER", "coingecko")),
+        PriceFile:        getEnv("PRICE_FILE", ""),
+        CoinGeckoURL:     getEnv("COINGECKO_URL", ""),
+        CoinGeckoAPIKey:  getEnv("COINGECKO_API_KEY", ""),
+        PriceTimeout:     getEnvDurationSeconds("PRICE_TIMEOUT_SECONDS", 5),
+        PriceCacheTTL:    getEnvDurationSeconds("PRICE_CACHE_SECONDS", 3600),
+        RuleCacheTTL:     getEnvDurationSeconds("RULES_CACHE_SECONDS", 60),
     }
-    log.Printf("config loaded: port=%s db=%s", cfg.AppPort, cfg.DatabaseName)
+    log.Printf("config loaded: port=%s db=%s chains=%v eventbus=%s roles=%v", cfg.AppPort, cfg.DatabaseName, cfg.Chains, cfg.EventBus, cfg.Roles)
     return cfg
 }
 
+// HasRole reports whether this process should run the given component.
+func (c Config) HasRole(role string) bool {
+    for _, r := range c.Roles {
+        if r == role {
+            return true
+        }
+    }
+    return false
+}
+
 func getEnv(key string, def string) string {
     v := os.Getenv(key)
     if v == "" {
@@ -43,6 +148,24 @@ func getEnv(key string, def string) string {
     return v
 }
 
+func getEnvList(key string, def string) []string {
+    var items []string
+    for _, item := range strings.Split(getEnv(key, def), ",") {
+        if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
+func getEnvInt(key string, def int) int {
+    n, err := strconv.Atoi(os.Getenv(key))
+    if err != nil {
+        return def
+    }
+    return n
+}
+
 func getEnvDurationSeconds(key string, def int) time.Duration {
     v := os.Getenv(key)
     if v == "" {
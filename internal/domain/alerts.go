@@ -0,0 +1,84 @@
+package domain
+
+import (
+    "errors"
+    "strings"
+)
+
+const (
+    // ChannelWebhook is the channel of alerts POSTed to a URL registered by the owner.
+    ChannelWebhook = "webhook"
+    // ChannelEmail is the channel of alerts emailed to the addresses registered by the owner.
+    ChannelEmail = "email"
+    // ChannelSlack is the channel of alerts posted to a Slack incoming webhook.
+    ChannelSlack = "slack"
+    // ChannelDiscord is the channel of alerts posted to a Discord webhook.
+    ChannelDiscord = "discord"
+)
+
+var (
+    // ErrInvalidAlert wraps the reason an alert channel was rejected.
+    ErrInvalidAlert  = errors.New("invalid alert")
+    ErrAlertNotFound = errors.New("alert not found")
+    // ErrChannelUnavailable is returned when no notifier serves the type of an alert channel,
+    // e.g. email without an SMTP server.
+    ErrChannelUnavailable = errors.New("alert channel not available")
+    // ErrAlertUnconfirmed is returned when using a telegram alert its target chat has not
+    // confirmed yet.
+    ErrAlertUnconfirmed = errors.New("alert channel not confirmed by its chat yet")
+)
+
+// secretConfigKeys are the Config entries that are never shown back to the owner.
+var secretConfigKeys = []string{"secret", "password"}
+
+// ConfigString returns a string entry of the alert's Config, empty when missing.
+func (a Alert) ConfigString(key string) string {
+    s, _ := a.Config[key].(string)
+    return s
+}
+
+// Redacted returns the alert without its secrets, for listing.
+func (a Alert) Redacted() Alert {
+    config := make(map[string]any, len(a.Config))
+    for k, v := range a.Config {
+        config[k] = v
+    }
+    for _, k := range secretConfigKeys {
+        delete(config, k)
+    }
+    // Anyone holding a Slack or Discord webhook URL can post to the channel, so its token
+    // is hidden too.
+    if url := a.ConfigString("url"); url != "" && (a.Type == ChannelSlack || a.Type == ChannelDiscord) {
+        config["url"] = url[:strings.LastIndex(url, "/")+1] + "****"
+    }
+    a.Config = config
+    return a
+}
+
+// Recipient is where the notifiers deliver the alert's messages: the configured chat for
+// telegram alerts, which the Telegram notifier sends to directly, the alert itself otherwise.
+func (a Alert) Recipient() Recipient {
+    if a.Type == ChannelTelegram {
+        return Recipient{Channel: ChannelTelegram, ID: a.ConfigString("chatId")}
+    }
+    return Recipient{Channel: a.Type, ID: a.ID}
+}
+
+// DeliveryKey names a delivery in the Deliveries of chatID's notification: the channel for
+// the chat itself, the channel and recipient ID for any other recipient such as a webhook.
+func DeliveryKey(chatID string, to Recipient) string {
+    if to.Channel == ChannelTelegram && to.ID == chatID {
+        return to.Channel
+    }
+    return to.Channel + ":" + to.ID
+}
+
+// DeliveryRecipient returns the recipient of the delivery named key in the Deliveries of
+// chatID's notification, the reverse of DeliveryKey.
+func DeliveryRecipient(chatID string, key string) Recipient {
+    channel, id, ok := strings.Cut(key, ":")
+    if !ok {
+        id = chatID
+    }
+    return Recipient{Channel: channel, ID: id}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+    "errors"
+    "time"
+)
+
+// ChatRule is an alert rule of a chat written in the rule expression language, e.g.
+// `outgoing and amount > 10` or `count_outgoing(10m) > 5`. When a chat has enabled rules,
+// it is only notified about events that satisfy at least one of them.
+type ChatRule struct {
+    ID         string    `bson:"_id" json:"id"`
+    ChatID     string    `bson:"chatId" json:"chatId"`
+    Name       string    `bson:"name" json:"name"`
+    Expression string    `bson:"expression" json:"expression"`
+    Enabled    bool      `bson:"enabled" json:"enabled"`
+    CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// ErrInvalidRule wraps the reason a rule expression was rejected.
+var ErrInvalidRule = errors.New("invalid rule")
+
+// ErrRuleNotFound is returned for operations on a rule that does not exist.
+var ErrRuleNotFound = errors.New("rule not found")
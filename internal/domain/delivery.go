@@ -0,0 +1,66 @@
+package domain
+
+import (
+    "errors"
+    "fmt"
+    "time"
+)
+
+// Delivery is one alert waiting to be sent to one recipient. Its ID is derived from the event
+// and the recipient, so queueing the same alert twice keeps a single delivery.
+type Delivery struct {
+    ID            string           `bson:"_id" json:"id"`
+    ChatID        string           `bson:"chatId" json:"chatId"` // chat whose notification the delivery belongs to
+    Recipient     Recipient        `bson:"recipient" json:"recipient"`
+    Event         TransactionEvent `bson:"event" json:"event"`
+    // Edit marks a delivery that updates the message already sent about the event to show
+    // the state of Event, instead of sending a new one.
+    Edit          bool             `bson:"edit,omitempty" json:"edit,omitempty"`
+    Attempts      int              `bson:"attempts" json:"attempts"`
+    NextAttemptAt time.Time        `bson:"nextAttemptAt" json:"nextAttemptAt"`
+    LeaseUntil    time.Time        `bson:"leaseUntil" json:"-"`
+    LastError     string           `bson:"lastError,omitempty" json:"lastError,omitempty"`
+    CreatedAt     time.Time        `bson:"createdAt" json:"createdAt"`
+}
+
+// DeliveryID derives the ID of the delivery of an event to a recipient.
+func DeliveryID(eventID string, to Recipient) string {
+    return eventID + ":" + to.Channel + ":" + to.ID
+}
+
+// DeliveryEditID derives the ID of the edit showing the event's state to a recipient. Each
+// state gets its own, so a later one is queued while an earlier one is being sent.
+func DeliveryEditID(evt TransactionEvent, to Recipient) string {
+    return fmt.Sprintf("%s:edit:%s:%d", DeliveryID(evt.ID, to), evt.Status, evt.Confirmations)
+}
+
+// DeadLetter is a delivery that was given up on.
+type DeadLetter struct {
+    Delivery `bson:",inline"`
+    Reason   string    `bson:"reason" json:"reason"`
+    FailedAt time.Time `bson:"failedAt" json:"failedAt"`
+}
+
+// DeliveryError tells the delivery queue how to handle a failed send. Notifiers return it
+// for failures they can classify; any other error is retried with the default backoff.
+type DeliveryError struct {
+    Err        error
+    Permanent  bool          // retrying cannot succeed, e.g. the bot was blocked
+    RetryAfter time.Duration // the channel asked to wait at least this long
+}
+
+func (e *DeliveryError) Error() string { return e.Err.Error() }
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// PermanentDeliveryError marks err as not worth retrying.
+func PermanentDeliveryError(err error) error {
+    return &DeliveryError{Err: err, Permanent: true}
+}
+
+// RetryDeliveryAfter marks err as retryable no earlier than after d.
+func RetryDeliveryAfter(err error, d time.Duration) error {
+    return &DeliveryError{Err: err, RetryAfter: d}
+}
+
+// ErrDeadLetterNotFound is returned when replaying a dead letter that does not exist.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
@@ -0,0 +1,164 @@
+package domain
+
+import (
+    "sort"
+    "time"
+)
+
+// DeliveryMode says whether a subscription's alerts are sent one by one or collected into digests.
+type DeliveryMode string
+
+const (
+    DeliveryInstant DeliveryMode = "instant"
+    DeliveryHourly  DeliveryMode = "hourly"
+    DeliveryDaily   DeliveryMode = "daily"
+    // DeliveryHeld collects the alerts held back during a chat's quiet hours. It is not a
+    // subscription mode, only the mode of the digest sent when quiet hours end.
+    DeliveryHeld DeliveryMode = "held"
+    // DeliveryBurst collects the alerts over a chat's rate limit into one burst summary.
+    DeliveryBurst DeliveryMode = "burst"
+)
+
+// IsDigest reports whether alerts are collected instead of being sent right away.
+func (m DeliveryMode) IsDigest() bool {
+    return m == DeliveryHourly || m == DeliveryDaily
+}
+
+// NextDigestAt returns when the digest collecting an event at t is sent: the start of the
+// next hour, or the next midnight in loc.
+func NextDigestAt(mode DeliveryMode, t time.Time, loc *time.Location) time.Time {
+    if loc == nil {
+        loc = time.UTC
+    }
+    t = t.In(loc)
+    if mode == DeliveryDaily {
+        y, m, d := t.Date()
+        return time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+    }
+    return t.Truncate(time.Hour).Add(time.Hour)
+}
+
+// DigestEntry is an event waiting in the digest of one of a chat's recipients until DueAt.
+type DigestEntry struct {
+    ID         string           `bson:"_id" json:"id"`
+    ChatID     string           `bson:"chatId" json:"chatId"`
+    // Recipient gets the digest: the chat itself, or one of its alert channels. Entries
+    // without one are the chat's.
+    Recipient  Recipient        `bson:"recipient" json:"recipient"`
+    Mode       DeliveryMode     `bson:"mode" json:"mode"`
+    Event      TransactionEvent `bson:"event" json:"event"`
+    DueAt      time.Time        `bson:"dueAt" json:"dueAt"`
+    Timezone   string           `bson:"timezone,omitempty" json:"timezone,omitempty"` // the chat's, for display
+    CreatedAt  time.Time        `bson:"createdAt" json:"createdAt"`
+    // LeaseUntil and Claim are set while a scheduler sends the entry's digest.
+    LeaseUntil time.Time        `bson:"leaseUntil,omitempty" json:"-"`
+    Claim      string           `bson:"claim,omitempty" json:"-"`
+}
+
+// To returns the recipient of the entry's digest.
+func (e DigestEntry) To() Recipient {
+    if e.Recipient.ID == "" {
+        return Recipient{Channel: ChannelTelegram, ID: e.ChatID}
+    }
+    return e.Recipient
+}
+
+// DigestEntryID derives the ID of an event's entry in the digest of one of a chat's
+// recipients, named by DeliveryKey.
+func DigestEntryID(eventID string, chatID string, key string) string {
+    if key == ChannelTelegram {
+        return eventID + ":" + chatID
+    }
+    return eventID + ":" + chatID + ":" + key
+}
+
+// Digest summarises the events collected for a chat over one period.
+type Digest struct {
+    ChatID   string
+    Mode     DeliveryMode
+    From     time.Time
+    To       time.Time
+    // Location is the chat's time zone, used to show From and To.
+    Location *time.Location
+    Events   []TransactionEvent
+}
+
+// CurrencyTotal is the volume moved in one currency over a digest period.
+type CurrencyTotal struct {
+    Currency string
+    Incoming float64
+    Outgoing float64
+    Count    int
+}
+
+// Counts returns the number of incoming and outgoing events.
+func (d Digest) Counts() (incoming, outgoing int) {
+    for _, e := range d.Events {
+        if e.Direction == DirectionOutgoing {
+            outgoing++
+        } else {
+            incoming++
+        }
+    }
+    return incoming, outgoing
+}
+
+// Totals returns the volume per currency, largest number of transfers first.
+func (d Digest) Totals() []CurrencyTotal {
+    byCurrency := make(map[string]*CurrencyTotal)
+    var totals []*CurrencyTotal
+    for _, e := range d.Events {
+        t, ok := byCurrency[e.Currency]
+        if !ok {
+            t = &CurrencyTotal{Currency: e.Currency}
+            byCurrency[e.Currency] = t
+            totals = append(totals, t)
+        }
+        t.Count++
+        if e.Direction == DirectionOutgoing {
+            t.Outgoing += e.Amount
+        } else {
+            t.Incoming += e.Amount
+        }
+    }
+    sort.SliceStable(totals, func(i, j int) bool { return totals[i].Count > totals[j].Count })
+    out := make([]CurrencyTotal, len(totals))
+    for i, t := range totals {
+        out[i] = *t
+    }
+    return out
+}
+
+// Value returns the fiat value received and sent by the events that could be valued, in the
+// fiat currency of the first of them; currency is empty when none could.
+func (d Digest) Value() (incoming, outgoing float64, currency string) {
+    for _, e := range d.Events {
+        if e.FiatCurrency == "" || currency != "" && e.FiatCurrency != currency {
+            continue
+        }
+        currency = e.FiatCurrency
+        if e.Direction == DirectionOutgoing {
+            outgoing += e.FiatValue
+        } else {
+            incoming += e.FiatValue
+        }
+    }
+    return incoming, outgoing, currency
+}
+
+// Largest returns up to n events with the biggest amounts, compared by fiat value where
+// both events have one.
+func (d Digest) Largest(n int) []TransactionEvent {
+    events := append([]TransactionEvent(nil), d.Events...)
+    sort.SliceStable(events, func(i, j int) bool {
+        a, b := events[i], events[j]
+        if a.FiatCurrency != "" && a.FiatCurrency == b.FiatCurrency {
+            return a.FiatValue > b.FiatValue
+        }
+        return a.Amount > b.Amount
+    })
+    if len(events) > n {
+        events = events[:n]
+    }
+    return events
+}
@@ -1,6 +1,13 @@
     package domain
 
-    import "time"
+    import (
+        "crypto/sha256"
+        "encoding/hex"
+        "errors"
+        "fmt"
+        "strings"
+        "time"
+    )
 
     type User struct {
         ID          string    `json:"_id"`
@@ -11,19 +18,36 @@
     }
 
     type Wallet struct {
-        ID         string    `json:"_id"`
-        UserID     string    `json:"userId"`
-        Blockchain string    `json:"blockchain"`
-        Address    string    `json:"address"`
-        CreatedAt  time.Time `json:"createdAt"`
+        ID         string    `bson:"_id" json:"_id"`
+        UserID     string    `bson:"userId" json:"userId"`
+        Blockchain string    `bson:"blockchain" json:"blockchain"`
+        Address    string    `bson:"address" json:"address"`
+        CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
     }
 
+    var (
+        ErrWalletNotFound = errors.New("wallet not found")
+        ErrWalletExists   = errors.New("wallet already added")
+        // ErrInvalidWallet wraps the reason a wallet was rejected.
+        ErrInvalidWallet  = errors.New("invalid wallet")
+    )
+
+    // Alert is a channel an owner is notified through besides the bot, e.g. a webhook. Alerts
+    // registered for a chat are owned by the chat ID. Type is the channel name.
     type Alert struct {
-        ID        string                 `json:"_id"`
-        UserID    string                 `json:"userId"`
-        Type      string                 `json:"type"`
-        Config    map[string]any         `json:"config"`
-        CreatedAt time.Time              `json:"createdAt"`
+        ID        string                 `bson:"_id" json:"_id"`
+        UserID    string                 `bson:"userId" json:"userId"`
+        Type      string                 `bson:"type" json:"type"`
+        Config    map[string]any         `bson:"config" json:"config"`
+        // Disabled stops deliveries, set by the owner or after too many failures in a row.
+        Disabled  bool                   `bson:"disabled" json:"disabled"`
+        Failures  int                    `bson:"failures" json:"failures"` // consecutive failed deliveries
+        LastError string                 `bson:"lastError,omitempty" json:"lastError,omitempty"`
+        // Unconfirmed marks a telegram alert whose target chat has not yet accepted the
+        // alerts; it stays disabled until the chat sends back ConfirmCode.
+        Unconfirmed bool                 `bson:"unconfirmed,omitempty" json:"unconfirmed,omitempty"`
+        ConfirmCode string               `bson:"confirmCode,omitempty" json:"-"`
+        CreatedAt time.Time              `bson:"createdAt" json:"createdAt"`
     }
 
     type Direction string
@@ -33,21 +57,176 @@
         DirectionOutgoing Direction = "outgoing"
     )
 
+    // NativeTransfer is the LogIndex of a transfer of the chain's own currency, which has no log entry.
+    const NativeTransfer = -1
+
+    // EventKind tells what was transferred; empty means the chain's own currency.
+    type EventKind string
+
+    const (
+        KindNative EventKind = "native"
+        KindToken  EventKind = "token"
+        KindNFT    EventKind = "nft"
+    )
+
+    // EventStatus is the state of the transaction an event belongs to; empty means confirmed.
+    type EventStatus string
+
+    const (
+        StatusConfirmed EventStatus = "confirmed"
+        StatusPending   EventStatus = "pending"
+        StatusReverted  EventStatus = "reverted"
+        // StatusReplaced means the pending transaction was dropped for another one from the
+        // same sender with the same nonce, e.g. a speed-up or cancellation.
+        StatusReplaced  EventStatus = "replaced"
+    )
+
+    // Final reports whether a transaction in this state will not change anymore.
+    func (s EventStatus) Final() bool {
+        return s == StatusReverted || s == StatusReplaced
+    }
+
     type TransactionEvent struct {
+        ID         string     `json:"id"`
         WalletID   string     `json:"walletId"`
         Blockchain string     `json:"blockchain"`
         TxHash     string     `json:"txHash"`
+        LogIndex   int        `json:"logIndex"`
         Direction  Direction  `json:"direction"`
+        // Counterparty is the other side of the transfer, empty when the adapter cannot tell.
+        Counterparty string   `json:"counterparty,omitempty"`
         Amount     float64    `json:"amount"`
         Currency   string     `json:"currency"`
         Timestamp  int64      `json:"timestamp"`
+        Kind       EventKind  `json:"kind,omitempty"`
+        Status     EventStatus `json:"status,omitempty"`
+        // Confirmations counts the blocks from the transaction's one to the chain head, for
+        // adapters that report a transaction again as it gets confirmed; 0 when unknown.
+        Confirmations int     `json:"confirmations,omitempty"`
+        // TokenContract and TokenID identify the token of token and NFT transfers.
+        TokenContract string  `json:"tokenContract,omitempty"`
+        TokenID    string     `json:"tokenId,omitempty"`
+        // FiatValue is the value of the transfer at block time in FiatCurrency, the currency
+        // of the chat it is notified to. Both are empty when the price is unknown.
+        FiatValue    float64  `json:"fiatValue,omitempty"`
+        FiatCurrency string   `json:"fiatCurrency,omitempty"`
+    }
+
+    // MessageKind names the message an event is announced with: its status while pending or
+    // once reverted, otherwise what was transferred.
+    func (e TransactionEvent) MessageKind() string {
+        switch e.Status {
+        case StatusPending, StatusReverted, StatusReplaced:
+            return string(e.Status)
+        }
+        if e.Kind == "" {
+            return string(KindNative)
+        }
+        return string(e.Kind)
+    }
+
+    // Advances reports whether the event is a later state of the transaction than status and
+    // confirmations, as recorded when it was notified: pending, then confirmed with a growing
+    // number of confirmations, or reverted or replaced, after which it no longer changes.
+    func (e TransactionEvent) Advances(status EventStatus, confirmations int) bool {
+        switch {
+        case status.Final():
+            return false
+        case e.Status.Final():
+            return true
+        case e.Status == StatusPending:
+            return false
+        case status == StatusPending:
+            return true
+        }
+        return e.Confirmations > confirmations
+    }
+
+    // EventID derives the ID of a transaction event. The same transfer seen twice, e.g. when a
+    // block is processed again after a restart, always gets the same ID.
+    func EventID(blockchain string, txHash string, logIndex int, address string, direction Direction) string {
+        key := fmt.Sprintf("%s|%s|%d|%s|%s", blockchain, strings.ToLower(txHash), logIndex, strings.ToLower(address), direction)
+        sum := sha256.Sum256([]byte(key))
+        return hex.EncodeToString(sum[:])
+    }
+
+    // WithID returns the event with its ID filled in if it was missing.
+    func (e TransactionEvent) WithID() TransactionEvent {
+        if e.ID == "" {
+            e.ID = EventID(e.Blockchain, e.TxHash, e.LogIndex, e.WalletID, e.Direction)
+        }
+        return e
+    }
+
+    // ErrDuplicateNotification is returned when a chat has already been notified about an event.
+    var ErrDuplicateNotification = errors.New("notification already recorded for this event and chat")
+
+    // ErrNotificationNotFound is returned when a chat has no notification about an event.
+    var ErrNotificationNotFound = errors.New("notification not found")
+
+    // ChannelTelegram is the channel of recipients reached through the Telegram bot.
+    const ChannelTelegram = "telegram"
+
+    // Recipient is the destination of a single alert. ID is channel specific, e.g. the
+    // Telegram chat ID.
+    type Recipient struct {
+        Channel string `json:"channel"`
+        ID      string `json:"id"`
     }
 
     // Subscription ties a chat to a blockchain/address.
     type Subscription struct {
-        ChatID     string `bson:"chatId" json:"chatId"`
-        Blockchain string `json:"blockchain"`
-        Address    string `json:"address"`
+        ChatID     string            `bson:"chatId" json:"chatId"`
+        Blockchain string            `json:"blockchain"`
+        Address    string            `json:"address"`
+        Rules      SubscriptionRules `bson:"rules,omitempty" json:"rules"`
+        Mode       DeliveryMode      `bson:"mode,omitempty" json:"mode,omitempty"` // empty means instant
+        // MutedUntil silences the subscription's alerts until then, see MutedForever.
+        MutedUntil time.Time         `bson:"mutedUntil,omitempty" json:"mutedUntil,omitempty"`
+        // Channels are the IDs of the chat's alert channels the subscription's alerts go to,
+        // with ChannelTelegram standing for the chat itself. Empty means all of them.
+        Channels   []string          `bson:"channels,omitempty" json:"channels,omitempty"`
+    }
+
+    // Muted reports whether the subscription's alerts are silenced at now.
+    func (s Subscription) Muted(now time.Time) bool {
+        return now.Before(s.MutedUntil)
+    }
+
+    // SendsTo reports whether the subscription's alerts go to the alert channel with the
+    // given ID, or to the chat itself for ChannelTelegram.
+    func (s Subscription) SendsTo(channel string) bool {
+        if len(s.Channels) == 0 {
+            return true
+        }
+        for _, c := range s.Channels {
+            if c == channel {
+                return true
+            }
+        }
+        return false
+    }
+
+    var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+    // SubscriptionChangeType tells whether a subscription was created or deleted.
+    type SubscriptionChangeType string
+
+    const (
+        SubscriptionAdded   SubscriptionChangeType = "added"
+        SubscriptionRemoved SubscriptionChangeType = "removed"
+    )
+
+    // SubscriptionChange is emitted after a subscription or wallet write. Remaining is the
+    // number of subscriptions and wallets left on the same blockchain/address once the write
+    // has been applied.
+    type SubscriptionChange struct {
+        Type         SubscriptionChangeType `json:"type"`
+        Subscription Subscription           `json:"subscription"`
+        Remaining    int64                  `json:"remaining"`
+        // UserID is the owner of the wallet for wallet writes, whose Subscription only has
+        // the blockchain and address. It is empty for chat subscriptions.
+        UserID       string                 `json:"userId,omitempty"`
     }
 
     // UserState represents the current state of a user in the bot conversation
@@ -59,27 +238,73 @@
         StateAddAddress     UserState = "add_address"
         StateRemoveAddress  UserState = "remove_address"
         StateViewNotifications UserState = "view_notifications"
+        StateEditRule       UserState = "edit_rule"
     )
 
     // TelegramSession represents a user's session state
     type TelegramSession struct {
-        ChatID     string    `json:"chatId"`
-        State      UserState `json:"state"`
-        LastAction string    `json:"lastAction,omitempty"`
-        CreatedAt  time.Time `json:"createdAt"`
-        UpdatedAt  time.Time `json:"updatedAt"`
+        ChatID     string       `bson:"chatId" json:"chatId"`
+        State      UserState    `bson:"state" json:"state"`
+        LastAction string       `bson:"lastAction,omitempty" json:"lastAction,omitempty"`
+        Settings   ChatSettings `bson:"settings,omitempty" json:"settings"`
+        // Language is the language picked with /language, LanguageCode the IETF tag of the
+        // Telegram client that last wrote to the bot.
+        Language     string     `bson:"language,omitempty" json:"language,omitempty"`
+        LanguageCode string     `bson:"languageCode,omitempty" json:"languageCode,omitempty"`
+        CreatedAt  time.Time    `bson:"createdAt" json:"createdAt"`
+        UpdatedAt  time.Time    `bson:"updatedAt" json:"updatedAt"`
+    }
+
+    // PreferredLanguage is the language the chat picked, or else the one of its Telegram client.
+    func (s TelegramSession) PreferredLanguage() string {
+        if s.Language != "" {
+            return s.Language
+        }
+        return s.LanguageCode
     }
 
     // Notification log for a chat/address.
     type Notification struct {
-        ChatID     string     `json:"chatId"`
-        Blockchain string     `json:"blockchain"`
-        Address    string     `json:"address"`
-        TxHash     string     `json:"txHash"`
-        Direction  Direction  `json:"direction"`
-        Amount     float64    `json:"amount"`
-        Currency   string     `json:"currency"`
-        Timestamp  int64      `json:"timestamp"`
+        EventID    string     `bson:"eventId,omitempty" json:"eventId,omitempty"`
+        ChatID     string     `bson:"chatId" json:"chatId"`
+        Blockchain string     `bson:"blockchain" json:"blockchain"`
+        Address    string     `bson:"address" json:"address"`
+        TxHash     string     `bson:"txHash" json:"txHash"`
+        Direction  Direction  `bson:"direction" json:"direction"`
+        Amount     float64    `bson:"amount" json:"amount"`
+        Currency   string     `bson:"currency" json:"currency"`
+        Timestamp  int64      `bson:"timestamp" json:"timestamp"`
+        // FiatValue is the value of the transfer at block time in FiatCurrency, if known.
+        FiatValue    float64  `bson:"fiatValue,omitempty" json:"fiatValue,omitempty"`
+        FiatCurrency string   `bson:"fiatCurrency,omitempty" json:"fiatCurrency,omitempty"`
+        // Status and Confirmations are the state of the transaction as last notified.
+        Status        EventStatus `bson:"status,omitempty" json:"status,omitempty"`
+        Confirmations int      `bson:"confirmations,omitempty" json:"confirmations,omitempty"`
+        // Deliveries holds the delivery status per recipient, keyed by DeliveryKey: the channel
+        // name for the chat itself, e.g. "telegram", or "webhook:<alert id>".
+        Deliveries map[string]DeliveryStatus `bson:"deliveries,omitempty" json:"deliveries,omitempty"`
+    }
+
+    // DeliveryState is where the alert for a notification is on one channel.
+    type DeliveryState string
+
+    const (
+        DeliveryQueued DeliveryState = "queued"
+        DeliverySent   DeliveryState = "sent"
+        DeliveryFailed DeliveryState = "failed"
+        // DeliveryMuted means the alert was not sent because of a mute, snooze or quiet hours.
+        DeliveryMuted  DeliveryState = "muted"
+    )
+
+    // DeliveryStatus records the outcome of delivering a notification on one channel.
+    type DeliveryStatus struct {
+        State     DeliveryState `bson:"state" json:"state"`
+        MessageID string        `bson:"messageId,omitempty" json:"messageId,omitempty"` // e.g. the Telegram message ID
+        Attempts  int           `bson:"attempts" json:"attempts"`
+        Error     string        `bson:"error,omitempty" json:"error,omitempty"`
+        // Digest tells that the event is, or is to be, part of a digest rather than a message of its own.
+        Digest    bool          `bson:"digest,omitempty" json:"digest,omitempty"`
+        UpdatedAt time.Time     `bson:"updatedAt" json:"updatedAt"`
     }
 
 
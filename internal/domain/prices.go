@@ -0,0 +1,45 @@
+package domain
+
+import (
+    "errors"
+    "strings"
+)
+
+// DefaultFiatCurrency is the currency transfers are valued in for chats that did not pick one.
+const DefaultFiatCurrency = "usd"
+
+// ErrPriceUnknown is returned by price providers that have no price for an asset.
+var ErrPriceUnknown = errors.New("price unknown")
+
+// Asset identifies what a price is quoted for: the chain's own currency or one of its tokens.
+type Asset struct {
+    Blockchain string
+    Symbol     string // upper-case, e.g. "ETH"
+    Contract   string // lower-case token contract, empty for the chain's own currency
+}
+
+func (a Asset) String() string {
+    if a.Contract != "" {
+        return a.Blockchain + ":" + a.Contract
+    }
+    return a.Blockchain + ":" + a.Symbol
+}
+
+// Asset returns what was transferred. NFTs have no price, so ok is false for them.
+func (e TransactionEvent) Asset() (asset Asset, ok bool) {
+    if e.Kind == KindNFT {
+        return Asset{}, false
+    }
+    asset = Asset{Blockchain: e.Blockchain, Symbol: strings.ToUpper(e.Currency)}
+    if e.Kind == KindToken {
+        asset.Contract = strings.ToLower(e.TokenContract)
+    }
+    return asset, asset.Symbol != "" || asset.Contract != ""
+}
+
+// WithFiatValue returns the event valued at price per unit in the fiat currency.
+func (e TransactionEvent) WithFiatValue(price float64, currency string) TransactionEvent {
+    e.FiatValue = e.Amount * price
+    e.FiatCurrency = strings.ToLower(currency)
+    return e
+}
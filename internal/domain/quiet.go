@@ -0,0 +1,110 @@
+package domain
+
+import (
+    "fmt"
+    "time"
+)
+
+// MutedForever is the MutedUntil of a subscription muted until it is unmuted by hand.
+var MutedForever = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// ChatSettings are the delivery preferences of a chat that apply to all its subscriptions.
+type ChatSettings struct {
+    // Timezone is an IANA zone name, e.g. "Europe/Berlin"; empty means UTC.
+    Timezone     string      `bson:"timezone,omitempty" json:"timezone,omitempty"`
+    SnoozedUntil time.Time   `bson:"snoozedUntil,omitempty" json:"snoozedUntil,omitempty"`
+    QuietHours   *QuietHours `bson:"quietHours,omitempty" json:"quietHours,omitempty"`
+    // Template is the message template set alerts are rendered with; empty means the default.
+    Template     string      `bson:"template,omitempty" json:"template,omitempty"`
+    // FiatCurrency is the currency transfers are valued in, e.g. "eur"; empty means
+    // DefaultFiatCurrency.
+    FiatCurrency string      `bson:"fiatCurrency,omitempty" json:"fiatCurrency,omitempty"`
+}
+
+// Fiat returns the currency the chat's transfers are valued in.
+func (s ChatSettings) Fiat() string {
+    if s.FiatCurrency == "" {
+        return DefaultFiatCurrency
+    }
+    return s.FiatCurrency
+}
+
+// Location returns the chat's time zone, UTC when it is unset or unknown.
+func (s ChatSettings) Location() *time.Location {
+    if s.Timezone == "" {
+        return time.UTC
+    }
+    loc, err := time.LoadLocation(s.Timezone)
+    if err != nil {
+        return time.UTC
+    }
+    return loc
+}
+
+// Snoozed reports whether all alerts of the chat are silenced at now.
+func (s ChatSettings) Snoozed(now time.Time) bool {
+    return now.Before(s.SnoozedUntil)
+}
+
+// Quiet reports whether quiet hours hold back evt at now.
+func (s ChatSettings) Quiet(now time.Time, evt TransactionEvent) bool {
+    if s.QuietHours == nil || s.QuietHours.LetsThrough(evt) {
+        return false
+    }
+    return s.QuietHours.Contains(now.In(s.Location()))
+}
+
+// QuietHours is a daily window in the chat's time zone during which alerts are held back.
+// Start and End are minutes after midnight; a window with End before Start spans midnight.
+type QuietHours struct {
+    Start int `bson:"start" json:"start"`
+    End   int `bson:"end" json:"end"`
+    // Digest sends the alerts held back as one digest when the window ends instead of dropping them.
+    Digest bool `bson:"digest" json:"digest"`
+    // MinAmount lets transfers of at least this amount through; zero holds back everything.
+    MinAmount float64 `bson:"minAmount,omitempty" json:"minAmount,omitempty"`
+}
+
+// ParseQuietHours parses a window such as "22:00-07:30".
+func ParseQuietHours(s string) (QuietHours, error) {
+    var sh, sm, eh, em int
+    if _, err := fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em); err != nil {
+        return QuietHours{}, fmt.Errorf("quiet hours must look like 22:00-07:00")
+    }
+    if sh < 0 || sh > 23 || eh < 0 || eh > 23 || sm < 0 || sm > 59 || em < 0 || em > 59 {
+        return QuietHours{}, fmt.Errorf("quiet hours must use times from 00:00 to 23:59")
+    }
+    q := QuietHours{Start: sh*60 + sm, End: eh*60 + em}
+    if q.Start == q.End {
+        return QuietHours{}, fmt.Errorf("quiet hours must not start and end at the same time")
+    }
+    return q, nil
+}
+
+func (q QuietHours) String() string {
+    return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
+// LetsThrough reports whether evt is important enough to be sent during quiet hours.
+func (q QuietHours) LetsThrough(evt TransactionEvent) bool {
+    return q.MinAmount > 0 && evt.Amount >= q.MinAmount
+}
+
+// Contains reports whether t, in the chat's time zone, falls inside the window.
+func (q QuietHours) Contains(t time.Time) bool {
+    minute := t.Hour()*60 + t.Minute()
+    if q.Start < q.End {
+        return minute >= q.Start && minute < q.End
+    }
+    return minute >= q.Start || minute < q.End
+}
+
+// NextEnd returns the first end of the window after t, in t's location.
+func (q QuietHours) NextEnd(t time.Time) time.Time {
+    y, m, d := t.Date()
+    end := time.Date(y, m, d, q.End/60, q.End%60, 0, 0, t.Location())
+    if !end.After(t) {
+        end = time.Date(y, m, d+1, q.End/60, q.End%60, 0, 0, t.Location())
+    }
+    return end
+}
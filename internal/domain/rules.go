@@ -0,0 +1,66 @@
+package domain
+
+import "strings"
+
+// SubscriptionRules narrow down which events of a subscription are notified. Zero values
+// mean no restriction.
+type SubscriptionRules struct {
+    MinAmount           float64   `bson:"minAmount,omitempty" json:"minAmount,omitempty"`
+    MaxAmount           float64   `bson:"maxAmount,omitempty" json:"maxAmount,omitempty"`
+    // MinValue and MaxValue limit the fiat value of a transfer, in the chat's currency.
+    MinValue            float64   `bson:"minValue,omitempty" json:"minValue,omitempty"`
+    MaxValue            float64   `bson:"maxValue,omitempty" json:"maxValue,omitempty"`
+    Direction           Direction `bson:"direction,omitempty" json:"direction,omitempty"`
+    Currencies          []string  `bson:"currencies,omitempty" json:"currencies,omitempty"`
+    AllowCounterparties []string  `bson:"allowCounterparties,omitempty" json:"allowCounterparties,omitempty"`
+    BlockCounterparties []string  `bson:"blockCounterparties,omitempty" json:"blockCounterparties,omitempty"`
+}
+
+// IsZero reports whether the rules let every event through.
+func (r SubscriptionRules) IsZero() bool {
+    return r.MinAmount == 0 && r.MaxAmount == 0 && r.MinValue == 0 && r.MaxValue == 0 && r.Direction == "" &&
+        len(r.Currencies) == 0 && len(r.AllowCounterparties) == 0 && len(r.BlockCounterparties) == 0
+}
+
+// Allows reports whether evt passes the rules. An event without a known counterparty never
+// matches an allowlist but is not affected by a blocklist. Likewise, the value limits do not
+// apply to events whose fiat value is unknown, so large transfers of unpriced tokens still
+// get through.
+func (r SubscriptionRules) Allows(evt TransactionEvent) bool {
+    if r.MinAmount > 0 && evt.Amount < r.MinAmount {
+        return false
+    }
+    if r.MaxAmount > 0 && evt.Amount > r.MaxAmount {
+        return false
+    }
+    if evt.FiatCurrency != "" {
+        if r.MinValue > 0 && evt.FiatValue < r.MinValue {
+            return false
+        }
+        if r.MaxValue > 0 && evt.FiatValue > r.MaxValue {
+            return false
+        }
+    }
+    if r.Direction != "" && evt.Direction != r.Direction {
+        return false
+    }
+    if len(r.Currencies) > 0 && !containsFold(r.Currencies, evt.Currency) {
+        return false
+    }
+    if len(r.AllowCounterparties) > 0 && (evt.Counterparty == "" || !containsFold(r.AllowCounterparties, evt.Counterparty)) {
+        return false
+    }
+    if evt.Counterparty != "" && containsFold(r.BlockCounterparties, evt.Counterparty) {
+        return false
+    }
+    return true
+}
+
+func containsFold(items []string, s string) bool {
+    for _, item := range items {
+        if strings.EqualFold(item, s) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,183 @@
+package eventbus
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Shared tests for the buses backed by a broker. They run against the server in the
+// environment variable named by each test and are skipped without it.
+
+const receiveTimeout = 10 * time.Second
+
+// testRun returns a prefix unique to this run, so tests sharing a broker with other runs
+// only look at their own events.
+func testRun(t *testing.T) string {
+    t.Helper()
+    buf := make([]byte, 6)
+    if _, err := rand.Read(buf); err != nil {
+        t.Fatal(err)
+    }
+    return "test-" + hex.EncodeToString(buf)
+}
+
+func testEvents(run string, n int) []domain.TransactionEvent {
+    events := make([]domain.TransactionEvent, n)
+    for i := range events {
+        events[i] = domain.TransactionEvent{
+            Blockchain: "test",
+            TxHash:     fmt.Sprintf("%s-%d", run, i),
+            WalletID:   "0xabc",
+            Amount:     float64(i),
+        }.WithID()
+    }
+    return events
+}
+
+// receive collects the tx hashes of this run's events from ch until want of them arrived.
+func receive(t *testing.T, ch <-chan domain.TransactionEvent, run string, want int) map[string]int {
+    t.Helper()
+    got := make(map[string]int)
+    deadline := time.After(receiveTimeout)
+    for len(got) < want {
+        select {
+        case evt, ok := <-ch:
+            if !ok {
+                t.Fatalf("subscription closed after %d of %d events", len(got), want)
+            }
+            if strings.HasPrefix(evt.TxHash, run) {
+                got[evt.TxHash]++
+            }
+        case <-deadline:
+            t.Fatalf("received %d of %d events", len(got), want)
+        }
+    }
+    return got
+}
+
+// testFanOut checks that every Subscribe gets every event.
+func testFanOut(t *testing.T, bus ports.EventBus) {
+    run := testRun(t)
+    first, unsubscribeFirst := bus.Subscribe()
+    defer unsubscribeFirst()
+    second, unsubscribeSecond := bus.Subscribe()
+    defer unsubscribeSecond()
+
+    events := testEvents(run, 5)
+    for _, evt := range events {
+        bus.Publish(evt)
+    }
+    for name, ch := range map[string]<-chan domain.TransactionEvent{"first": first, "second": second} {
+        for hash, n := range receive(t, ch, run, len(events)) {
+            if n != 1 {
+                t.Errorf("%s subscriber got %s %d times", name, hash, n)
+            }
+        }
+    }
+}
+
+// testGroup checks that the members of a consumer group share the events: each event goes
+// to exactly one of them and is not redelivered once acknowledged.
+func testGroup(t *testing.T, bus ports.AckingEventBus, group string) {
+    run := testRun(t)
+    const members = 2
+    merged := make(chan domain.TransactionEvent)
+    perMember := make([]int, members)
+    var wg sync.WaitGroup
+    done := make(chan struct{})
+    for i := 0; i < members; i++ {
+        ch, unsubscribe := bus.SubscribeGroup(group)
+        defer unsubscribe()
+        wg.Add(1)
+        go func(i int, ch <-chan ports.AckableEvent) {
+            defer wg.Done()
+            for {
+                select {
+                case d, ok := <-ch:
+                    if !ok {
+                        return
+                    }
+                    if err := d.Ack(); err != nil {
+                        t.Errorf("ack %s: %v", d.Event.TxHash, err)
+                    }
+                    if strings.HasPrefix(d.Event.TxHash, run) {
+                        perMember[i]++
+                    }
+                    select {
+                    case merged <- d.Event:
+                    case <-done:
+                        return
+                    }
+                case <-done:
+                    return
+                }
+            }
+        }(i, ch)
+    }
+
+    events := testEvents(run, 20)
+    for _, evt := range events {
+        bus.Publish(evt)
+    }
+    got := receive(t, merged, run, len(events))
+
+    // Anything delivered twice shows up within the grace period.
+    grace := time.After(time.Second)
+wait:
+    for {
+        select {
+        case evt := <-merged:
+            if strings.HasPrefix(evt.TxHash, run) {
+                got[evt.TxHash]++
+            }
+        case <-grace:
+            break wait
+        }
+    }
+    close(done)
+    wg.Wait()
+    for hash, n := range got {
+        if n != 1 {
+            t.Errorf("group delivered %s %d times", hash, n)
+        }
+    }
+    t.Logf("events per group member: %v", perMember)
+}
+
+// testChanges checks that subscription changes reach every listener.
+func testChanges(t *testing.T, bus ports.ChangeBroadcaster) {
+    run := testRun(t)
+    first, unsubscribeFirst := bus.SubscribeChanges()
+    defer unsubscribeFirst()
+    second, unsubscribeSecond := bus.SubscribeChanges()
+    defer unsubscribeSecond()
+
+    change := domain.SubscriptionChange{
+        Type:         domain.SubscriptionAdded,
+        Subscription: domain.Subscription{ChatID: "1", Blockchain: "ethereum", Address: run},
+        Remaining:    1,
+    }
+    bus.PublishChange(change)
+    for name, ch := range map[string]<-chan domain.SubscriptionChange{"first": first, "second": second} {
+        deadline := time.After(receiveTimeout)
+        for received := false; !received; {
+            select {
+            case got := <-ch:
+                received = got.Subscription.Address == run
+                if received && (got.Type != change.Type || got.Remaining != change.Remaining) {
+                    t.Errorf("%s listener got %+v, want %+v", name, got, change)
+                }
+            case <-deadline:
+                t.Fatalf("%s listener did not get the change", name)
+            }
+        }
+    }
+}
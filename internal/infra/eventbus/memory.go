@@ -1,45 +1,289 @@
 package eventbus
 
 import (
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "strconv"
+    "strings"
     "sync"
+    "sync/atomic"
+    "time"
+
     "github.com/you/wallet_transaction_notifier/internal/domain"
     "github.com/you/wallet_transaction_notifier/internal/ports"
 )
 
+// OverflowPolicy decides what Publish does when a subscriber's buffer is full.
+type OverflowPolicy string
+
+const (
+    OverflowDropNewest OverflowPolicy = "drop_newest" // discard the event being published
+    OverflowDropOldest OverflowPolicy = "drop_oldest" // discard the oldest buffered event
+    OverflowBlock      OverflowPolicy = "block"       // wait up to BlockTimeout, then drop
+    OverflowSpill      OverflowPolicy = "spill"       // append to a file and feed it back later
+)
+
+// SubscriberOptions configures the buffer of one subscriber.
+type SubscriberOptions struct {
+    BufferSize   int
+    Overflow     OverflowPolicy
+    BlockTimeout time.Duration
+    SpillDir     string
+}
+
+// MemoryOptions configures the in-memory bus. Subscribers maps subscriber names to options
+// that replace Default for them.
+type MemoryOptions struct {
+    Default     SubscriberOptions
+    Subscribers map[string]SubscriberOptions
+}
+
+func (o SubscriberOptions) withDefaults() SubscriberOptions {
+    if o.BufferSize <= 0 {
+        o.BufferSize = 32
+    }
+    if o.Overflow == "" {
+        o.Overflow = OverflowDropNewest
+    }
+    if o.BlockTimeout <= 0 {
+        o.BlockTimeout = time.Second
+    }
+    if o.SpillDir == "" {
+        o.SpillDir = os.TempDir()
+    }
+    return o
+}
+
+// ParseSubscriberOptions reads per-subscriber overrides written as
+// "name=size:policy,name=size:policy", e.g. "dispatcher=1024:spill". Fields that are left
+// out keep the value from base.
+func ParseSubscriberOptions(spec string, base SubscriberOptions) (map[string]SubscriberOptions, error) {
+    subs := make(map[string]SubscriberOptions)
+    for _, item := range strings.Split(spec, ",") {
+        item = strings.TrimSpace(item)
+        if item == "" {
+            continue
+        }
+        name, value, ok := strings.Cut(item, "=")
+        if !ok || name == "" {
+            return nil, fmt.Errorf("invalid subscriber option %q, want name=size:policy", item)
+        }
+        opts := base
+        size, policy, _ := strings.Cut(value, ":")
+        if size != "" {
+            n, err := strconv.Atoi(size)
+            if err != nil || n <= 0 {
+                return nil, fmt.Errorf("invalid buffer size in %q", item)
+            }
+            opts.BufferSize = n
+        }
+        if policy != "" {
+            switch p := OverflowPolicy(policy); p {
+            case OverflowDropNewest, OverflowDropOldest, OverflowBlock, OverflowSpill:
+                opts.Overflow = p
+            default:
+                return nil, fmt.Errorf("unknown overflow policy %q", policy)
+            }
+        }
+        subs[strings.TrimSpace(name)] = opts
+    }
+    return subs, nil
+}
+
+type subscriber struct {
+    name      string
+    opts      SubscriberOptions
+    ch        chan domain.TransactionEvent
+    // mu is held for reading while an event is handed to ch and for writing to close it;
+    // done tells a Publish blocked on a full buffer to give up once the subscriber leaves.
+    mu        sync.RWMutex
+    done      chan struct{}
+    closed    bool
+    spill     *spillQueue
+    delivered atomic.Uint64
+    dropped   atomic.Uint64
+    spilled   atomic.Uint64
+}
+
 type inMemoryEventBus struct {
     mu          sync.RWMutex
-    subscribers map[chan domain.TransactionEvent]struct{}
+    opts        MemoryOptions
+    subscribers map[*subscriber]struct{}
+    anonymous   atomic.Uint64
 }
 
+var (
+    _ ports.NamedSubscriber = (*inMemoryEventBus)(nil)
+    _ ports.MetricsProvider = (*inMemoryEventBus)(nil)
+)
+
 func NewInMemoryEventBus() ports.EventBus {
+    return NewInMemoryEventBusWithOptions(MemoryOptions{})
+}
+
+func NewInMemoryEventBusWithOptions(opts MemoryOptions) ports.EventBus {
     return &inMemoryEventBus{
-        subscribers: make(map[chan domain.TransactionEvent]struct{}),
+        opts:        opts,
+        subscribers: make(map[*subscriber]struct{}),
     }
 }
 
+// Publish hands the event to a snapshot of the subscribers taken without holding the bus
+// lock, so a subscriber with the block policy cannot hold up Subscribe and unsubscribe.
 func (b *inMemoryEventBus) Publish(event domain.TransactionEvent) {
     b.mu.RLock()
-    defer b.mu.RUnlock()
-    for ch := range b.subscribers {
+    subs := make([]*subscriber, 0, len(b.subscribers))
+    for s := range b.subscribers {
+        subs = append(subs, s)
+    }
+    b.mu.RUnlock()
+
+    for _, s := range subs {
+        s.mu.RLock()
+        if !s.closed {
+            b.deliver(s, event)
+        }
+        s.mu.RUnlock()
+    }
+}
+
+func (b *inMemoryEventBus) deliver(s *subscriber, event domain.TransactionEvent) {
+    // Once events are spilled, later ones follow them through the file to keep the order.
+    if s.spill != nil && s.spill.pending() > 0 {
+        b.spillEvent(s, event)
+        return
+    }
+    select {
+    case s.ch <- event:
+        s.delivered.Add(1)
+        return
+    default:
+    }
+
+    switch s.opts.Overflow {
+    case OverflowDropOldest:
+        for {
+            select {
+            case s.ch <- event:
+                s.delivered.Add(1)
+                return
+            default:
+            }
+            select {
+            case <-s.ch:
+                b.drop(s)
+            default:
+            }
+        }
+    case OverflowBlock:
+        timer := time.NewTimer(s.opts.BlockTimeout)
+        defer timer.Stop()
         select {
-        case ch <- event:
-        default:
+        case s.ch <- event:
+            s.delivered.Add(1)
+        case <-timer.C:
+            b.drop(s)
+        case <-s.done:
         }
+    case OverflowSpill:
+        b.spillEvent(s, event)
+    default:
+        b.drop(s)
+    }
+}
+
+func (b *inMemoryEventBus) spillEvent(s *subscriber, event domain.TransactionEvent) {
+    if err := s.spill.push(event); err != nil {
+        log.Printf("❌ Failed to spill event for subscriber %s: %v", s.name, err)
+        b.drop(s)
+        return
+    }
+    s.spilled.Add(1)
+}
+
+func (b *inMemoryEventBus) drop(s *subscriber) {
+    // Log the first drop and then every 100th so a stuck subscriber cannot flood the log.
+    if n := s.dropped.Add(1); n == 1 || n%100 == 0 {
+        log.Printf("⚠️ Event bus subscriber %s is falling behind: %d events dropped (%s)", s.name, n, s.opts.Overflow)
     }
 }
 
 func (b *inMemoryEventBus) Subscribe() (<-chan domain.TransactionEvent, func()) {
-    ch := make(chan domain.TransactionEvent, 32)
+    return b.SubscribeNamed(fmt.Sprintf("subscriber-%d", b.anonymous.Add(1)))
+}
+
+// SubscribeNamed subscribes with the options configured for name, falling back to the defaults.
+func (b *inMemoryEventBus) SubscribeNamed(name string) (<-chan domain.TransactionEvent, func()) {
+    opts, ok := b.opts.Subscribers[name]
+    if !ok {
+        opts = b.opts.Default
+    }
+    opts = opts.withDefaults()
+
+    s := &subscriber{
+        name: name,
+        opts: opts,
+        ch:   make(chan domain.TransactionEvent, opts.BufferSize),
+        done: make(chan struct{}),
+    }
+    if opts.Overflow == OverflowSpill {
+        spill, err := newSpillQueue(opts.SpillDir, name, s.ch)
+        if err != nil {
+            log.Printf("❌ Failed to create spill file for subscriber %s, dropping on overflow instead: %v", name, err)
+            s.opts.Overflow = OverflowDropNewest
+        } else {
+            s.spill = spill
+        }
+    }
+
     b.mu.Lock()
-    b.subscribers[ch] = struct{}{}
+    b.subscribers[s] = struct{}{}
     b.mu.Unlock()
+
+    var once sync.Once
     unsubscribe := func() {
-        b.mu.Lock()
-        delete(b.subscribers, ch)
-        close(ch)
-        b.mu.Unlock()
+        once.Do(func() {
+            if s.spill != nil {
+                s.spill.close()
+            }
+            b.mu.Lock()
+            delete(b.subscribers, s)
+            b.mu.Unlock()
+
+            close(s.done)
+            s.mu.Lock()
+            s.closed = true
+            close(s.ch)
+            s.mu.Unlock()
+        })
     }
-    return ch, unsubscribe
+    return s.ch, unsubscribe
 }
 
+func (b *inMemoryEventBus) Metrics() []ports.Metric {
+    b.mu.RLock()
+    subs := make([]*subscriber, 0, len(b.subscribers))
+    for s := range b.subscribers {
+        subs = append(subs, s)
+    }
+    b.mu.RUnlock()
+    sort.Slice(subs, func(i, j int) bool { return subs[i].name < subs[j].name })
 
+    var metrics []ports.Metric
+    for _, s := range subs {
+        labels := map[string]string{"subscriber": s.name, "policy": string(s.opts.Overflow)}
+        metrics = append(metrics,
+            ports.Metric{Name: "eventbus_delivered_total", Help: "Events handed to the subscriber buffer.", Type: "counter", Labels: labels, Value: float64(s.delivered.Load())},
+            ports.Metric{Name: "eventbus_dropped_total", Help: "Events dropped because the subscriber buffer was full.", Type: "counter", Labels: labels, Value: float64(s.dropped.Load())},
+            ports.Metric{Name: "eventbus_spilled_total", Help: "Events written to the subscriber spill file.", Type: "counter", Labels: labels, Value: float64(s.spilled.Load())},
+            ports.Metric{Name: "eventbus_buffered", Help: "Events waiting in the subscriber buffer.", Type: "gauge", Labels: labels, Value: float64(len(s.ch))},
+            ports.Metric{Name: "eventbus_buffer_capacity", Help: "Size of the subscriber buffer.", Type: "gauge", Labels: labels, Value: float64(cap(s.ch))},
+        )
+        if s.spill != nil {
+            metrics = append(metrics, ports.Metric{Name: "eventbus_spill_pending", Help: "Events waiting in the subscriber spill file.", Type: "gauge", Labels: labels, Value: float64(s.spill.pending())})
+        }
+    }
+    return metrics
+}
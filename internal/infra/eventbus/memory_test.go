@@ -0,0 +1,208 @@
+package eventbus
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+func newTestMemoryBus(opts SubscriberOptions) *inMemoryEventBus {
+    return NewInMemoryEventBusWithOptions(MemoryOptions{Default: opts}).(*inMemoryEventBus)
+}
+
+func publishN(bus ports.EventBus, n int) {
+    for i := 0; i < n; i++ {
+        bus.Publish(domain.TransactionEvent{Blockchain: "test", TxHash: fmt.Sprintf("tx-%d", i)})
+    }
+}
+
+// drain reads what is buffered without waiting for more.
+func drain(ch <-chan domain.TransactionEvent) []string {
+    var hashes []string
+    for {
+        select {
+        case evt := <-ch:
+            hashes = append(hashes, evt.TxHash)
+        default:
+            return hashes
+        }
+    }
+}
+
+func metricValue(t *testing.T, bus ports.MetricsProvider, name, subscriber string) float64 {
+    t.Helper()
+    for _, m := range bus.Metrics() {
+        if m.Name == name && m.Labels["subscriber"] == subscriber {
+            return m.Value
+        }
+    }
+    t.Fatalf("metric %s for %s not found", name, subscriber)
+    return 0
+}
+
+func TestMemoryDropPolicies(t *testing.T) {
+    tests := []struct {
+        policy OverflowPolicy
+        want   []string
+    }{
+        {OverflowDropNewest, []string{"tx-0", "tx-1"}},
+        {OverflowDropOldest, []string{"tx-3", "tx-4"}},
+    }
+    for _, tt := range tests {
+        t.Run(string(tt.policy), func(t *testing.T) {
+            bus := newTestMemoryBus(SubscriberOptions{BufferSize: 2, Overflow: tt.policy})
+            ch, unsubscribe := bus.SubscribeNamed("slow")
+            defer unsubscribe()
+
+            publishN(bus, 5)
+            if got := drain(ch); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+                t.Errorf("received %v, want %v", got, tt.want)
+            }
+            if got := metricValue(t, bus, "eventbus_dropped_total", "slow"); got != 3 {
+                t.Errorf("dropped %v events, want 3", got)
+            }
+        })
+    }
+}
+
+func TestMemoryBlockTimesOut(t *testing.T) {
+    bus := newTestMemoryBus(SubscriberOptions{BufferSize: 1, Overflow: OverflowBlock, BlockTimeout: 20 * time.Millisecond})
+    ch, unsubscribe := bus.SubscribeNamed("slow")
+    defer unsubscribe()
+
+    publishN(bus, 2)
+    if got := drain(ch); len(got) != 1 || got[0] != "tx-0" {
+        t.Errorf("received %v, want [tx-0]", got)
+    }
+    if got := metricValue(t, bus, "eventbus_dropped_total", "slow"); got != 1 {
+        t.Errorf("dropped %v events, want 1", got)
+    }
+}
+
+// A Publish waiting on a full buffer must not hold up other subscribers coming and going.
+func TestMemoryBlockDoesNotHoldBusLock(t *testing.T) {
+    bus := newTestMemoryBus(SubscriberOptions{BufferSize: 1, Overflow: OverflowBlock, BlockTimeout: time.Minute})
+    _, unsubscribe := bus.SubscribeNamed("slow")
+    publishN(bus, 1)
+
+    published := make(chan struct{})
+    go func() {
+        publishN(bus, 1)
+        close(published)
+    }()
+    time.Sleep(20 * time.Millisecond)
+
+    subscribed := make(chan struct{})
+    go func() {
+        _, unsubscribeOther := bus.Subscribe()
+        unsubscribeOther()
+        close(subscribed)
+    }()
+    select {
+    case <-subscribed:
+    case <-time.After(time.Second):
+        t.Fatal("Subscribe waited for the blocked Publish")
+    }
+
+    // Unsubscribing the full subscriber releases the Publish waiting on it.
+    unsubscribe()
+    select {
+    case <-published:
+    case <-time.After(time.Second):
+        t.Fatal("Publish still blocked after the subscriber left")
+    }
+}
+
+func TestMemorySpillKeepsOrder(t *testing.T) {
+    dir := t.TempDir()
+    bus := newTestMemoryBus(SubscriberOptions{BufferSize: 2, Overflow: OverflowSpill, SpillDir: dir})
+    ch, unsubscribe := bus.SubscribeNamed("slow")
+    defer unsubscribe()
+
+    const n = 50
+    publishN(bus, n)
+    if got := metricValue(t, bus, "eventbus_spilled_total", "slow"); got == 0 {
+        t.Error("no events spilled")
+    }
+    for i := 0; i < n; i++ {
+        select {
+        case evt := <-ch:
+            if want := fmt.Sprintf("tx-%d", i); evt.TxHash != want {
+                t.Fatalf("event %d is %s, want %s", i, evt.TxHash, want)
+            }
+        case <-time.After(time.Second):
+            t.Fatalf("received %d of %d events", i, n)
+        }
+    }
+    if got := metricValue(t, bus, "eventbus_dropped_total", "slow"); got != 0 {
+        t.Errorf("dropped %v events, want 0", got)
+    }
+
+    // The file is truncated once drained and removed on unsubscribe.
+    path := filepath.Join(dir, "eventbus-slow.spill")
+    deadline := time.Now().Add(time.Second)
+    for {
+        info, err := os.Stat(path)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if info.Size() == 0 {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("spill file still holds %d bytes", info.Size())
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    unsubscribe()
+    if _, err := os.Stat(path); !os.IsNotExist(err) {
+        t.Errorf("spill file not removed: %v", err)
+    }
+}
+
+func TestMemoryUnsubscribeDuringPublish(t *testing.T) {
+    bus := newTestMemoryBus(SubscriberOptions{BufferSize: 1, Overflow: OverflowDropOldest})
+    stop := make(chan struct{})
+    go func() {
+        for {
+            select {
+            case <-stop:
+                return
+            default:
+                publishN(bus, 1)
+            }
+        }
+    }()
+    for i := 0; i < 200; i++ {
+        _, unsubscribe := bus.Subscribe()
+        unsubscribe()
+    }
+    close(stop)
+}
+
+func TestParseSubscriberOptions(t *testing.T) {
+    base := SubscriberOptions{BufferSize: 32, Overflow: OverflowDropNewest}
+    got, err := ParseSubscriberOptions("dispatcher=1024:spill, stream=:drop_oldest,ws=8", base)
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := map[string]SubscriberOptions{
+        "dispatcher": {BufferSize: 1024, Overflow: OverflowSpill},
+        "stream":     {BufferSize: 32, Overflow: OverflowDropOldest},
+        "ws":         {BufferSize: 8, Overflow: OverflowDropNewest},
+    }
+    if fmt.Sprint(got) != fmt.Sprint(want) {
+        t.Errorf("got %v, want %v", got, want)
+    }
+
+    for _, spec := range []string{"dispatcher", "=8", "dispatcher=x", "dispatcher=0", "dispatcher=8:lose"} {
+        if _, err := ParseSubscriberOptions(spec, base); err == nil {
+            t.Errorf("%q: expected an error", spec)
+        }
+    }
+}
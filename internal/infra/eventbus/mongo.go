@@ -0,0 +1,535 @@
+package eventbus
+
+import (
+    "context"
+    "errors"
+    "log"
+    "sync"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// MongoOptions tunes the MongoDB backed event bus.
+type MongoOptions struct {
+    AckTimeout   time.Duration // unacknowledged events are redelivered after this long
+    PollInterval time.Duration // how often consumers look for new entries
+    Retention    time.Duration // log entries older than this are removed by a TTL index
+    MaxInFlight  int           // unacknowledged events per consumer before it stops reading
+}
+
+func (o *MongoOptions) setDefaults() {
+    if o.AckTimeout <= 0 {
+        o.AckTimeout = 30 * time.Second
+    }
+    if o.PollInterval <= 0 {
+        o.PollInterval = 500 * time.Millisecond
+    }
+    if o.Retention <= 0 {
+        o.Retention = 7 * 24 * time.Hour
+    }
+    if o.MaxInFlight <= 0 {
+        o.MaxInFlight = 100
+    }
+}
+
+// logEntry is one published event in the event_log collection. Entries are numbered by a
+// monotonically increasing sequence which consumer groups use as their offset.
+type logEntry struct {
+    Seq       int64                   `bson:"_id"`
+    Event     domain.TransactionEvent `bson:"event"`
+    CreatedAt time.Time               `bson:"createdAt"`
+}
+
+// mongoEventBus is an append-only event log in MongoDB. Consumer groups persist the offset
+// of the last event acknowledged without gaps, so after a crash they resume from there and
+// anything in flight at the time is delivered again. The offset is the only shared state of
+// a group, so one consumer reads for the group at a time: it holds the group's lease, and
+// other consumers of the group stand by until it is released or expires.
+type mongoEventBus struct {
+    opts      MongoOptions
+    log       *mongo.Collection
+    counters  *mongo.Collection
+    consumers *mongo.Collection
+}
+
+// NewMongoEventBus connects to the log in dbName. Consumer groups do not spread their events
+// over their consumers as with NATS or Redis: a single consumer of each group receives them
+// and a second one takes over when the first goes away.
+func NewMongoEventBus(uri string, dbName string, opts MongoOptions) (ports.AckingEventBus, error) {
+    opts.setDefaults()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+    if err != nil {
+        return nil, err
+    }
+    if err := client.Ping(ctx, nil); err != nil {
+        return nil, err
+    }
+
+    db := client.Database(dbName)
+    b := &mongoEventBus{
+        opts:      opts,
+        log:       db.Collection("event_log"),
+        counters:  db.Collection("event_counters"),
+        consumers: db.Collection("event_consumers"),
+    }
+    _, err = b.log.Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys:    bson.D{{Key: "createdAt", Value: 1}},
+        Options: options.Index().SetExpireAfterSeconds(int32(opts.Retention.Seconds())),
+    })
+    if err != nil {
+        return nil, err
+    }
+    log.Printf("Mongo event bus ready: ack timeout %s, retention %s", opts.AckTimeout, opts.Retention)
+    return b, nil
+}
+
+func (b *mongoEventBus) Publish(event domain.TransactionEvent) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    seq, err := b.nextSeq(ctx)
+    if err != nil {
+        log.Printf("❌ Failed to allocate event sequence for tx %s: %v", event.TxHash, err)
+        return
+    }
+    entry := logEntry{Seq: seq, Event: event, CreatedAt: time.Now()}
+    if _, err := b.log.InsertOne(ctx, entry); err != nil {
+        log.Printf("❌ Failed to append event for tx %s: %v", event.TxHash, err)
+    }
+}
+
+// Subscribe follows the log from its current end without persisting progress, which
+// mirrors the in-memory bus: events are acknowledged as soon as they are handed over.
+func (b *mongoEventBus) Subscribe() (<-chan domain.TransactionEvent, func()) {
+    ctx, cancel := context.WithCancel(context.Background())
+    deliveries := b.subscribe(ctx, "", false)
+    out := make(chan domain.TransactionEvent)
+    go func() {
+        defer close(out)
+        for d := range deliveries {
+            select {
+            case out <- d.Event:
+                _ = d.Ack()
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+    return out, cancel
+}
+
+func (b *mongoEventBus) SubscribeGroup(group string) (<-chan ports.AckableEvent, func()) {
+    ctx, cancel := context.WithCancel(context.Background())
+    return b.subscribe(ctx, group, true), cancel
+}
+
+func (b *mongoEventBus) subscribe(ctx context.Context, group string, durable bool) <-chan ports.AckableEvent {
+    c := &consumer{
+        bus:      b,
+        group:    group,
+        durable:  durable,
+        owner:    primitive.NewObjectID().Hex(),
+        out:      make(chan ports.AckableEvent),
+        inflight: make(map[int64]*inflightEvent),
+        missing:  make(map[int64]time.Time),
+    }
+    go c.run(ctx)
+    return c.out
+}
+
+func (b *mongoEventBus) nextSeq(ctx context.Context) (int64, error) {
+    var counter struct {
+        Seq int64 `bson:"seq"`
+    }
+    err := b.counters.FindOneAndUpdate(ctx,
+        bson.M{"_id": "event_log"},
+        bson.M{"$inc": bson.M{"seq": 1}},
+        options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+    ).Decode(&counter)
+    return counter.Seq, err
+}
+
+func (b *mongoEventBus) lastSeq(ctx context.Context) (int64, error) {
+    var counter struct {
+        Seq int64 `bson:"seq"`
+    }
+    err := b.counters.FindOne(ctx, bson.M{"_id": "event_log"}).Decode(&counter)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return 0, nil
+    }
+    return counter.Seq, err
+}
+
+const (
+    // publishGrace is how long a consumer waits for a missing sequence number to show up
+    // before delivering the events after it.
+    publishGrace = 5 * time.Second
+    // gapHorizon is how long a consumer keeps looking for a missing sequence number once it
+    // delivered the events after it. The group's offset stays before it until then, so an
+    // event inserted late is still delivered, also after a restart.
+    gapHorizon = 10 * time.Minute
+    // groupLease is how long a consumer holds its group without renewing the lease, which it
+    // does halfway through; another consumer of the group takes over once it expires. Expiry
+    // is judged by the consumers' clocks, which have to agree to within a few seconds.
+    groupLease = 30 * time.Second
+)
+
+type inflightEvent struct {
+    entry    logEntry
+    deadline time.Time
+    acked    bool
+}
+
+// consumer reads the log for one group. offset is the last sequence acknowledged with no
+// gaps before it; cursor is the last sequence read from the log. missing holds the sequence
+// numbers the cursor moved past without their entries, with the time it did.
+type consumer struct {
+    bus     *mongoEventBus
+    group   string
+    durable bool
+    owner   string // identifies the consumer in its group's lease
+    out     chan ports.AckableEvent
+
+    // Only used by run.
+    loaded     bool
+    leaseUntil time.Time
+    standingBy bool
+
+    mu       sync.Mutex
+    offset   int64
+    cursor   int64
+    inflight map[int64]*inflightEvent
+    missing  map[int64]time.Time
+}
+
+func (c *consumer) run(ctx context.Context) {
+    defer close(c.out)
+    if c.durable {
+        defer c.release()
+    }
+
+    ticker := time.NewTicker(c.bus.opts.PollInterval)
+    defer ticker.Stop()
+    for {
+        if c.ready(ctx) {
+            for _, entry := range c.due(ctx) {
+                if !c.deliver(ctx, entry) {
+                    return
+                }
+            }
+            c.commit(ctx)
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// ready reports whether the consumer reads the log now: durable consumers have to hold their
+// group's lease, which ready acquires or renews, and the offset has to be loaded.
+func (c *consumer) ready(ctx context.Context) bool {
+    if c.durable {
+        held, err := c.lease(ctx)
+        if err != nil {
+            if ctx.Err() == nil {
+                log.Printf("Event consumer %q failed to renew its group's lease: %v", c.group, err)
+            }
+            return false
+        }
+        if !held {
+            if c.loaded {
+                log.Printf("Event consumer %q lost its group's lease, standing by", c.group)
+                c.reset()
+            } else if !c.standingBy {
+                log.Printf("Event consumer %q stands by while another consumer reads for the group", c.group)
+            }
+            c.standingBy = true
+            return false
+        }
+        c.standingBy = false
+    }
+    if !c.loaded {
+        if err := c.loadOffset(ctx); err != nil {
+            if ctx.Err() == nil {
+                log.Printf("❌ Event consumer %q failed to load its offset: %v", c.group, err)
+            }
+            return false
+        }
+        c.loaded = true
+    }
+    return true
+}
+
+// lease acquires the group's lease or renews it once half of it has passed, and reports
+// whether the consumer holds it. A lease is free when it expired or was never taken.
+func (c *consumer) lease(ctx context.Context) (bool, error) {
+    now := time.Now()
+    if c.leaseUntil.Sub(now) > groupLease/2 {
+        return true, nil
+    }
+    until := now.Add(groupLease)
+    _, err := c.bus.consumers.UpdateOne(ctx,
+        bson.M{"_id": c.group, "$or": bson.A{
+            bson.M{"owner": c.owner},
+            bson.M{"owner": bson.M{"$exists": false}},
+            bson.M{"leaseUntil": bson.M{"$lt": now}},
+        }},
+        bson.M{"$set": bson.M{"owner": c.owner, "leaseUntil": until}},
+        options.Update().SetUpsert(true),
+    )
+    if mongo.IsDuplicateKeyError(err) {
+        // The group's document exists and another consumer holds its lease.
+        c.leaseUntil = time.Time{}
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    c.leaseUntil = until
+    return true, nil
+}
+
+// release gives the group's lease up, so a consumer standing by takes over on its next poll.
+func (c *consumer) release() {
+    if c.leaseUntil.IsZero() {
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    _, err := c.bus.consumers.UpdateOne(ctx,
+        bson.M{"_id": c.group, "owner": c.owner},
+        bson.M{"$set": bson.M{"leaseUntil": time.Time{}}},
+    )
+    if err != nil {
+        log.Printf("Event consumer %q failed to release its group's lease: %v", c.group, err)
+    }
+}
+
+// reset forgets what the consumer read after it lost the group's lease. Once it holds the
+// lease again it resumes from the offset the other consumer committed.
+func (c *consumer) reset() {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.loaded = false
+    c.standingBy = true
+    c.leaseUntil = time.Time{}
+    c.inflight = make(map[int64]*inflightEvent)
+    c.missing = make(map[int64]time.Time)
+}
+
+func (c *consumer) loadOffset(ctx context.Context) error {
+    var offset int64
+    if c.durable {
+        var doc struct {
+            Offset *int64 `bson:"offset"`
+        }
+        err := c.bus.consumers.FindOne(ctx, bson.M{"_id": c.group}).Decode(&doc)
+        if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+            return err
+        }
+        if doc.Offset != nil {
+            offset = *doc.Offset
+        } else if offset, err = c.bus.lastSeq(ctx); err != nil {
+            // A new group starts at the end of the log rather than replaying its history.
+            return err
+        }
+    } else {
+        var err error
+        if offset, err = c.bus.lastSeq(ctx); err != nil {
+            return err
+        }
+    }
+    c.offset, c.cursor = offset, offset
+    return nil
+}
+
+// due returns the events to hand out now: expired in-flight events first, then the missing
+// entries that showed up, then new log entries.
+func (c *consumer) due(ctx context.Context) []logEntry {
+    c.mu.Lock()
+    now := time.Now()
+    var entries []logEntry
+    for _, f := range c.inflight {
+        if !f.acked && now.After(f.deadline) {
+            log.Printf("Redelivering event %d to consumer %q after ack timeout", f.entry.Seq, c.group)
+            f.deadline = now.Add(c.bus.opts.AckTimeout)
+            entries = append(entries, f.entry)
+        }
+    }
+    room := c.bus.opts.MaxInFlight - len(c.inflight)
+    cursor := c.cursor
+    missing := c.waiting(now)
+    c.mu.Unlock()
+
+    if len(missing) > 0 {
+        if late, ok := c.read(ctx, bson.M{"_id": bson.M{"$in": missing}}, options.Find()); ok {
+            c.mu.Lock()
+            entries = append(entries, c.recover(late, now)...)
+            c.mu.Unlock()
+        }
+    }
+    if room <= 0 {
+        return entries
+    }
+    opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(room))
+    fresh, ok := c.read(ctx, bson.M{"_id": bson.M{"$gt": cursor}}, opts)
+    if !ok {
+        return entries
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return append(entries, c.advance(fresh, now)...)
+}
+
+func (c *consumer) read(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]logEntry, bool) {
+    cur, err := c.bus.log.Find(ctx, filter, opts)
+    if err != nil {
+        if ctx.Err() == nil {
+            log.Printf("Event consumer %q failed to read the log: %v", c.group, err)
+        }
+        return nil, false
+    }
+    var entries []logEntry
+    if err := cur.All(ctx, &entries); err != nil {
+        if ctx.Err() == nil {
+            log.Printf("Event consumer %q failed to decode log entries: %v", c.group, err)
+        }
+        return nil, false
+    }
+    return entries, true
+}
+
+// advance moves the cursor over fresh, the entries following it in the log, and returns
+// those to deliver. A gap may be a publish that allocated its sequence but has not been
+// inserted yet: the consumer waits for it a little before delivering the entries after it,
+// then looks for it until gapHorizon. Gaps wider than MaxInFlight are entries removed by the
+// retention rather than publishes in progress, and are not looked for. c.mu is held.
+func (c *consumer) advance(fresh []logEntry, now time.Time) []logEntry {
+    for i, entry := range fresh {
+        if gap := entry.Seq - c.cursor - 1; gap > 0 {
+            if now.Sub(entry.CreatedAt) < publishGrace {
+                return fresh[:i]
+            }
+            if gap <= int64(c.bus.opts.MaxInFlight) {
+                for seq := c.cursor + 1; seq < entry.Seq; seq++ {
+                    c.missing[seq] = now
+                }
+            }
+        }
+        c.inflight[entry.Seq] = &inflightEvent{entry: entry, deadline: now.Add(c.bus.opts.AckTimeout)}
+        c.cursor = entry.Seq
+    }
+    return fresh
+}
+
+// waiting returns the missing sequence numbers still looked for, giving up on those missing
+// for longer than gapHorizon. c.mu is held.
+func (c *consumer) waiting(now time.Time) []int64 {
+    var seqs []int64
+    for seq, since := range c.missing {
+        if now.Sub(since) > gapHorizon {
+            log.Printf("Event consumer %q gives up on missing event %d", c.group, seq)
+            delete(c.missing, seq)
+            continue
+        }
+        seqs = append(seqs, seq)
+    }
+    return seqs
+}
+
+// recover returns the entries of late that are still missing and takes them in flight.
+// c.mu is held.
+func (c *consumer) recover(late []logEntry, now time.Time) []logEntry {
+    var entries []logEntry
+    for _, entry := range late {
+        if _, ok := c.missing[entry.Seq]; !ok {
+            continue
+        }
+        log.Printf("Event consumer %q found missing event %d", c.group, entry.Seq)
+        delete(c.missing, entry.Seq)
+        c.inflight[entry.Seq] = &inflightEvent{entry: entry, deadline: now.Add(c.bus.opts.AckTimeout)}
+        entries = append(entries, entry)
+    }
+    return entries
+}
+
+func (c *consumer) deliver(ctx context.Context, entry logEntry) bool {
+    seq := entry.Seq
+    d := ports.AckableEvent{
+        Event: entry.Event,
+        Ack: func() error {
+            c.mu.Lock()
+            defer c.mu.Unlock()
+            if f, ok := c.inflight[seq]; ok {
+                f.acked = true
+            }
+            return nil
+        },
+    }
+    select {
+    case c.out <- d:
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
+
+// commit advances the offset over acknowledged events and persists it for durable groups.
+// Sequence numbers skipped by failed publishes are never acknowledged, so the offset also
+// moves past gaps once the consumer stopped looking for them.
+func (c *consumer) commit(ctx context.Context) {
+    start, offset := c.advanceOffset()
+    if !c.durable || offset == start {
+        return
+    }
+    // Only the holder of the lease stores the offset, so a consumer that lost it cannot
+    // move the group back.
+    res, err := c.bus.consumers.UpdateOne(ctx,
+        bson.M{"_id": c.group, "owner": c.owner},
+        bson.M{"$set": bson.M{"offset": offset, "updatedAt": time.Now()}},
+    )
+    if err != nil {
+        if ctx.Err() == nil {
+            log.Printf("Event consumer %q failed to store offset %d: %v", c.group, offset, err)
+        }
+        return
+    }
+    if res.MatchedCount == 0 {
+        log.Printf("Event consumer %q lost its group's lease, standing by", c.group)
+        c.reset()
+    }
+}
+
+// advanceOffset moves the offset over the acknowledged events and returns it before and after.
+func (c *consumer) advanceOffset() (start int64, offset int64) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    start = c.offset
+    for c.offset < c.cursor {
+        next := c.offset + 1
+        if _, ok := c.missing[next]; ok {
+            break
+        }
+        f, ok := c.inflight[next]
+        if ok && !f.acked {
+            break
+        }
+        delete(c.inflight, next)
+        c.offset = next
+    }
+    return start, c.offset
+}
@@ -0,0 +1,199 @@
+package eventbus
+
+import (
+    "context"
+    "os"
+    "testing"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// newTestConsumer is a consumer whose cursor and offset are at seq, for the bookkeeping that
+// does not touch the database.
+func newTestConsumer(seq int64) *consumer {
+    b := &mongoEventBus{opts: MongoOptions{}}
+    b.opts.setDefaults()
+    return &consumer{
+        bus:      b,
+        group:    "test",
+        offset:   seq,
+        cursor:   seq,
+        inflight: make(map[int64]*inflightEvent),
+        missing:  make(map[int64]time.Time),
+    }
+}
+
+func seqs(entries []logEntry) []int64 {
+    var s []int64
+    for _, e := range entries {
+        s = append(s, e.Seq)
+    }
+    return s
+}
+
+func ackAll(c *consumer) {
+    for _, f := range c.inflight {
+        f.acked = true
+    }
+}
+
+func TestMongoConsumerWaitsForMissingEvents(t *testing.T) {
+    c := newTestConsumer(10)
+    now := time.Now()
+
+    // 12 is missing; 13 is too recent to move past the gap yet.
+    fresh := []logEntry{{Seq: 11, CreatedAt: now}, {Seq: 13, CreatedAt: now}}
+    if got := seqs(c.advance(fresh, now)); len(got) != 1 || got[0] != 11 {
+        t.Fatalf("delivered %v, want [11]", got)
+    }
+
+    // After the grace period 13 is delivered and 12 is looked for.
+    later := now.Add(publishGrace + time.Second)
+    if got := seqs(c.advance(fresh[1:], later)); len(got) != 1 || got[0] != 13 {
+        t.Fatalf("delivered %v, want [13]", got)
+    }
+    if got := c.waiting(later); len(got) != 1 || got[0] != 12 {
+        t.Fatalf("waiting for %v, want [12]", got)
+    }
+    ackAll(c)
+    if _, offset := c.advanceOffset(); offset != 11 {
+        t.Fatalf("offset %d moved past the missing event, want 11", offset)
+    }
+
+    // 12 shows up well after the grace period and is delivered.
+    late := later.Add(time.Minute)
+    if got := seqs(c.recover([]logEntry{{Seq: 12}}, late)); len(got) != 1 || got[0] != 12 {
+        t.Fatalf("recovered %v, want [12]", got)
+    }
+    if got := seqs(c.recover([]logEntry{{Seq: 12}}, late)); len(got) != 0 {
+        t.Fatalf("recovered %v again", got)
+    }
+    if _, offset := c.advanceOffset(); offset != 11 {
+        t.Fatalf("offset %d moved past an unacknowledged event, want 11", offset)
+    }
+    ackAll(c)
+    if _, offset := c.advanceOffset(); offset != 13 {
+        t.Fatalf("offset %d, want 13", offset)
+    }
+}
+
+func TestMongoConsumerGivesUpOnMissingEvents(t *testing.T) {
+    c := newTestConsumer(0)
+    now := time.Now()
+    old := now.Add(-time.Hour)
+    c.advance([]logEntry{{Seq: 3, CreatedAt: old}}, now)
+    ackAll(c)
+    if _, offset := c.advanceOffset(); offset != 0 {
+        t.Fatalf("offset %d, want 0 while 1 and 2 are looked for", offset)
+    }
+    if got := c.waiting(now.Add(gapHorizon + time.Second)); len(got) != 0 {
+        t.Fatalf("still waiting for %v after the horizon", got)
+    }
+    if _, offset := c.advanceOffset(); offset != 3 {
+        t.Fatalf("offset %d, want 3", offset)
+    }
+
+    // Entries removed by the retention leave gaps that are not looked for.
+    c.advance([]logEntry{{Seq: 3 + int64(c.bus.opts.MaxInFlight) + 2, CreatedAt: old}}, now)
+    if got := c.waiting(now); len(got) != 0 {
+        t.Fatalf("waiting for %d expired events", len(got))
+    }
+}
+
+// newTestMongoBus connects to the MongoDB server in TEST_MONGO_URI, e.g.
+// mongodb://localhost:27017, using a database of its own.
+func newTestMongoBus(t *testing.T) *mongoEventBus {
+    t.Helper()
+    uri := os.Getenv("TEST_MONGO_URI")
+    if uri == "" {
+        t.Skip("TEST_MONGO_URI not set")
+    }
+    bus, err := NewMongoEventBus(uri, "wallet_notifier_test", MongoOptions{PollInterval: 50 * time.Millisecond})
+    if err != nil {
+        t.Fatalf("connect to MongoDB: %v", err)
+    }
+    b := bus.(*mongoEventBus)
+    t.Cleanup(func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        _ = b.log.Database().Client().Disconnect(ctx)
+    })
+    return b
+}
+
+// newTestGroup names a group that starts at the current end of the log. New groups start at
+// the end of the log when their consumer first reads it, which may be after the test published.
+func newTestGroup(t *testing.T, bus *mongoEventBus) string {
+    t.Helper()
+    group := testRun(t)
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    seq, err := bus.lastSeq(ctx)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := bus.consumers.InsertOne(ctx, bson.M{"_id": group, "offset": seq}); err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        _, _ = bus.consumers.DeleteOne(ctx, bson.M{"_id": group})
+    })
+    return group
+}
+
+// A group's events go to one consumer at a time, so testGroup sees each of them once.
+func TestMongoSubscribeGroupDeliversOnce(t *testing.T) {
+    bus := newTestMongoBus(t)
+    testGroup(t, bus, newTestGroup(t, bus))
+}
+
+func TestMongoGroupFailsOver(t *testing.T) {
+    bus := newTestMongoBus(t)
+    group := newTestGroup(t, bus)
+    events := testEvents(testRun(t), 2)
+
+    first, unsubscribeFirst := bus.SubscribeGroup(group)
+    defer unsubscribeFirst()
+    second, unsubscribeSecond := bus.SubscribeGroup(group)
+    defer unsubscribeSecond()
+    bus.Publish(events[0])
+    var active, standby <-chan ports.AckableEvent
+    select {
+    case d := <-first:
+        active, standby = first, second
+        d.Ack()
+    case d := <-second:
+        active, standby = second, first
+        d.Ack()
+    case <-time.After(receiveTimeout):
+        t.Fatal("no consumer of the group got the event")
+    }
+    select {
+    case d := <-standby:
+        t.Fatalf("the consumer standing by got %s", d.Event.TxHash)
+    case <-time.After(time.Second):
+    }
+
+    // The consumer standing by takes over once the other one goes away.
+    if active == first {
+        unsubscribeFirst()
+    } else {
+        unsubscribeSecond()
+    }
+    for range active {
+    }
+    bus.Publish(events[1])
+    select {
+    case d := <-standby:
+        if d.Event.TxHash != events[1].TxHash {
+            t.Fatalf("got %s, want %s", d.Event.TxHash, events[1].TxHash)
+        }
+    case <-time.After(receiveTimeout):
+        t.Fatal("the consumer standing by did not take over")
+    }
+}
@@ -0,0 +1,215 @@
+package eventbus
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/nats-io/nats.go"
+    "github.com/nats-io/nats.go/jetstream"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+const (
+    natsStream        = "WALLET_EVENTS"
+    natsSubjectPrefix = "wallet.events."
+    // natsChangesSubject carries subscription changes over core NATS, outside the stream.
+    natsChangesSubject = "wallet.subscriptions"
+)
+
+// natsEventBus publishes events to a JetStream stream. Consumer groups map to durable pull
+// consumers, so every process subscribing with the same group shares the work and
+// unacknowledged messages are redelivered by the server after the ack timeout.
+type natsEventBus struct {
+    nc         *nats.Conn
+    js         jetstream.JetStream
+    ackTimeout time.Duration
+}
+
+func NewNATSEventBus(url string, ackTimeout time.Duration, retention time.Duration) (ports.AckingEventBus, error) {
+    nc, err := nats.Connect(url, nats.Name("wallet-notifier"), nats.MaxReconnects(-1))
+    if err != nil {
+        return nil, err
+    }
+    js, err := jetstream.New(nc)
+    if err != nil {
+        nc.Close()
+        return nil, err
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+        Name:     natsStream,
+        Subjects: []string{natsSubjectPrefix + ">"},
+        Storage:  jetstream.FileStorage,
+        MaxAge:   retention,
+    })
+    if err != nil {
+        nc.Close()
+        return nil, err
+    }
+    log.Printf("NATS event bus ready on stream %s", natsStream)
+    return &natsEventBus{nc: nc, js: js, ackTimeout: ackTimeout}, nil
+}
+
+func (b *natsEventBus) Publish(event domain.TransactionEvent) {
+    data, err := json.Marshal(event)
+    if err != nil {
+        log.Printf("❌ Failed to encode event for tx %s: %v", event.TxHash, err)
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if _, err := b.js.Publish(ctx, natsSubjectPrefix+event.Blockchain, data); err != nil {
+        log.Printf("❌ Failed to publish event for tx %s: %v", event.TxHash, err)
+    }
+}
+
+// Subscribe reads new events through an ephemeral ordered consumer; nothing is acknowledged.
+func (b *natsEventBus) Subscribe() (<-chan domain.TransactionEvent, func()) {
+    out := make(chan domain.TransactionEvent)
+    ctx, cancel := context.WithCancel(context.Background())
+
+    cons, err := b.js.OrderedConsumer(ctx, natsStream, jetstream.OrderedConsumerConfig{
+        DeliverPolicy: jetstream.DeliverNewPolicy,
+    })
+    if err != nil {
+        log.Printf("❌ Failed to create NATS subscriber: %v", err)
+        close(out)
+        return out, cancel
+    }
+    go b.consume(ctx, cons, func(msg jetstream.Msg, evt domain.TransactionEvent) bool {
+        select {
+        case out <- evt:
+            return true
+        case <-ctx.Done():
+            return false
+        }
+    }, func() { close(out) })
+    return out, cancel
+}
+
+func (b *natsEventBus) SubscribeGroup(group string) (<-chan ports.AckableEvent, func()) {
+    out := make(chan ports.AckableEvent)
+    ctx, cancel := context.WithCancel(context.Background())
+
+    cons, err := b.js.CreateOrUpdateConsumer(ctx, natsStream, jetstream.ConsumerConfig{
+        Durable:       group,
+        AckPolicy:     jetstream.AckExplicitPolicy,
+        AckWait:       b.ackTimeout,
+        DeliverPolicy: jetstream.DeliverNewPolicy,
+    })
+    if err != nil {
+        log.Printf("❌ Failed to create NATS consumer %q: %v", group, err)
+        close(out)
+        return out, cancel
+    }
+    go b.consume(ctx, cons, func(msg jetstream.Msg, evt domain.TransactionEvent) bool {
+        d := ports.AckableEvent{Event: evt, Ack: msg.Ack}
+        select {
+        case out <- d:
+            return true
+        case <-ctx.Done():
+            return false
+        }
+    }, func() { close(out) })
+    return out, cancel
+}
+
+var _ ports.ChangeBroadcaster = (*natsEventBus)(nil)
+
+func (b *natsEventBus) PublishChange(change domain.SubscriptionChange) {
+    data, err := json.Marshal(change)
+    if err != nil {
+        log.Printf("❌ Failed to encode %s subscription change: %v", change.Type, err)
+        return
+    }
+    if err := b.nc.Publish(natsChangesSubject, data); err != nil {
+        log.Printf("❌ Failed to publish %s subscription change for %s: %v", change.Type, change.Subscription.Address, err)
+    }
+}
+
+// SubscribeChanges receives the changes published by every process, this one included.
+func (b *natsEventBus) SubscribeChanges() (<-chan domain.SubscriptionChange, func()) {
+    out := make(chan domain.SubscriptionChange, 64)
+    // The handler may still be running when unsubscribing, so closing waits for it.
+    var (
+        mu     sync.Mutex
+        closed bool
+    )
+    sub, err := b.nc.Subscribe(natsChangesSubject, func(msg *nats.Msg) {
+        var change domain.SubscriptionChange
+        if err := json.Unmarshal(msg.Data, &change); err != nil {
+            log.Printf("Dropping undecodable subscription change: %v", err)
+            return
+        }
+        mu.Lock()
+        defer mu.Unlock()
+        if closed {
+            return
+        }
+        select {
+        case out <- change:
+        default:
+            log.Printf("subscription change listener is full, dropping %s change for %s", change.Type, change.Subscription.Address)
+        }
+    })
+    if err != nil {
+        log.Printf("❌ Failed to subscribe to subscription changes: %v", err)
+        close(out)
+        return out, func() {}
+    }
+    // Make sure the server knows the subscription before changes are published.
+    if err := b.nc.Flush(); err != nil {
+        log.Printf("⚠️ Failed to flush NATS subscription: %v", err)
+    }
+    var once sync.Once
+    return out, func() {
+        once.Do(func() {
+            _ = sub.Unsubscribe()
+            mu.Lock()
+            closed = true
+            close(out)
+            mu.Unlock()
+        })
+    }
+}
+
+// consume pulls messages until ctx is cancelled, decoding each and passing it to handle.
+func (b *natsEventBus) consume(ctx context.Context, cons jetstream.Consumer, handle func(jetstream.Msg, domain.TransactionEvent) bool, done func()) {
+    defer done()
+    iter, err := cons.Messages()
+    if err != nil {
+        log.Printf("❌ Failed to start NATS message iterator: %v", err)
+        return
+    }
+    go func() {
+        <-ctx.Done()
+        iter.Stop()
+    }()
+    for {
+        msg, err := iter.Next()
+        if err != nil {
+            if errors.Is(err, jetstream.ErrMsgIteratorClosed) || ctx.Err() != nil {
+                return
+            }
+            log.Printf("NATS consumer error: %v", err)
+            continue
+        }
+        var evt domain.TransactionEvent
+        if err := json.Unmarshal(msg.Data(), &evt); err != nil {
+            log.Printf("Dropping undecodable NATS message on %s: %v", msg.Subject(), err)
+            _ = msg.Term()
+            continue
+        }
+        if !handle(msg, evt) {
+            return
+        }
+    }
+}
@@ -0,0 +1,44 @@
+package eventbus
+
+import (
+    "context"
+    "os"
+    "testing"
+    "time"
+)
+
+// newTestNATSBus connects to the JetStream server in TEST_NATS_URL, e.g. the one of
+// docker-compose --profile brokers.
+func newTestNATSBus(t *testing.T) *natsEventBus {
+    t.Helper()
+    url := os.Getenv("TEST_NATS_URL")
+    if url == "" {
+        t.Skip("TEST_NATS_URL not set")
+    }
+    bus, err := NewNATSEventBus(url, 5*time.Second, time.Hour)
+    if err != nil {
+        t.Fatalf("connect to NATS: %v", err)
+    }
+    b := bus.(*natsEventBus)
+    t.Cleanup(b.nc.Close)
+    return b
+}
+
+func TestNATSSubscribeFansOut(t *testing.T) {
+    testFanOut(t, newTestNATSBus(t))
+}
+
+func TestNATSSubscribeGroupSharesEvents(t *testing.T) {
+    bus := newTestNATSBus(t)
+    group := testRun(t)
+    t.Cleanup(func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+        defer cancel()
+        _ = bus.js.DeleteConsumer(ctx, natsStream, group)
+    })
+    testGroup(t, bus, group)
+}
+
+func TestNATSBroadcastsSubscriptionChanges(t *testing.T) {
+    testChanges(t, newTestNATSBus(t))
+}
@@ -0,0 +1,267 @@
+package eventbus
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/redis/go-redis/v9"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+const (
+    redisStream = "wallet:events"
+    // redisChangesChannel carries subscription changes over pub/sub, outside the stream.
+    redisChangesChannel = "wallet:subscriptions"
+)
+
+// redisEventBus appends events to a Redis stream. Consumer groups map to stream consumer
+// groups: every process reading with the same group gets a share of the entries, and entries
+// left pending longer than the ack timeout (e.g. by a crashed replica) are claimed again.
+type redisEventBus struct {
+    rdb        *redis.Client
+    ackTimeout time.Duration
+    retention  time.Duration
+    consumer   string // unique name of this process within consumer groups
+}
+
+func NewRedisEventBus(url string, ackTimeout time.Duration, retention time.Duration) (ports.AckingEventBus, error) {
+    opts, err := redis.ParseURL(url)
+    if err != nil {
+        return nil, err
+    }
+    rdb := redis.NewClient(opts)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    if err := rdb.Ping(ctx).Err(); err != nil {
+        rdb.Close()
+        return nil, err
+    }
+
+    host, _ := os.Hostname()
+    log.Printf("Redis event bus ready on stream %s", redisStream)
+    return &redisEventBus{
+        rdb:        rdb,
+        ackTimeout: ackTimeout,
+        retention:  retention,
+        consumer:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+    }, nil
+}
+
+func (b *redisEventBus) Publish(event domain.TransactionEvent) {
+    data, err := json.Marshal(event)
+    if err != nil {
+        log.Printf("❌ Failed to encode event for tx %s: %v", event.TxHash, err)
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    err = b.rdb.XAdd(ctx, &redis.XAddArgs{
+        Stream: redisStream,
+        MinID:  fmt.Sprintf("%d-0", time.Now().Add(-b.retention).UnixMilli()),
+        Approx: true,
+        Values: map[string]any{"event": data},
+    }).Err()
+    if err != nil {
+        log.Printf("❌ Failed to publish event for tx %s: %v", event.TxHash, err)
+    }
+}
+
+// Subscribe reads entries appended after the call; nothing is acknowledged.
+func (b *redisEventBus) Subscribe() (<-chan domain.TransactionEvent, func()) {
+    out := make(chan domain.TransactionEvent)
+    ctx, cancel := context.WithCancel(context.Background())
+    // Start after the current last entry rather than at "$" on the first read, which would
+    // miss entries appended before the goroutine gets to it.
+    lastID := "0-0"
+    if last, err := b.rdb.XRevRangeN(ctx, redisStream, "+", "-", 1).Result(); err != nil {
+        log.Printf("Redis subscriber failed to find the end of the stream, reading new entries only: %v", err)
+        lastID = "$"
+    } else if len(last) > 0 {
+        lastID = last[0].ID
+    }
+    go func() {
+        defer close(out)
+        for ctx.Err() == nil {
+            streams, err := b.rdb.XRead(ctx, &redis.XReadArgs{
+                Streams: []string{redisStream, lastID},
+                Count:   100,
+                Block:   time.Second,
+            }).Result()
+            if err != nil {
+                if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+                    log.Printf("Redis subscriber read error: %v", err)
+                    time.Sleep(time.Second)
+                }
+                continue
+            }
+            for _, msg := range streams[0].Messages {
+                lastID = msg.ID
+                evt, ok := decodeRedisEvent(msg)
+                if !ok {
+                    continue
+                }
+                select {
+                case out <- evt:
+                case <-ctx.Done():
+                    return
+                }
+            }
+        }
+    }()
+    return out, cancel
+}
+
+func (b *redisEventBus) SubscribeGroup(group string) (<-chan ports.AckableEvent, func()) {
+    out := make(chan ports.AckableEvent)
+    ctx, cancel := context.WithCancel(context.Background())
+
+    err := b.rdb.XGroupCreateMkStream(ctx, redisStream, group, "$").Err()
+    if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+        log.Printf("❌ Failed to create Redis consumer group %q: %v", group, err)
+        close(out)
+        return out, cancel
+    }
+
+    go func() {
+        defer close(out)
+        for ctx.Err() == nil {
+            msgs, err := b.readGroup(ctx, group)
+            if err != nil {
+                if ctx.Err() == nil {
+                    log.Printf("Redis consumer %q read error: %v", group, err)
+                    time.Sleep(time.Second)
+                }
+                continue
+            }
+            for _, msg := range msgs {
+                evt, ok := decodeRedisEvent(msg)
+                if !ok {
+                    b.rdb.XAck(ctx, redisStream, group, msg.ID)
+                    continue
+                }
+                id := msg.ID
+                d := ports.AckableEvent{
+                    Event: evt,
+                    Ack: func() error {
+                        return b.rdb.XAck(context.Background(), redisStream, group, id).Err()
+                    },
+                }
+                select {
+                case out <- d:
+                case <-ctx.Done():
+                    return
+                }
+            }
+        }
+    }()
+    return out, cancel
+}
+
+var _ ports.ChangeBroadcaster = (*redisEventBus)(nil)
+
+func (b *redisEventBus) PublishChange(change domain.SubscriptionChange) {
+    data, err := json.Marshal(change)
+    if err != nil {
+        log.Printf("❌ Failed to encode %s subscription change: %v", change.Type, err)
+        return
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if err := b.rdb.Publish(ctx, redisChangesChannel, data).Err(); err != nil {
+        log.Printf("❌ Failed to publish %s subscription change for %s: %v", change.Type, change.Subscription.Address, err)
+    }
+}
+
+// SubscribeChanges receives the changes published by every process, this one included.
+func (b *redisEventBus) SubscribeChanges() (<-chan domain.SubscriptionChange, func()) {
+    out := make(chan domain.SubscriptionChange, 64)
+    ctx, cancel := context.WithCancel(context.Background())
+    ps := b.rdb.Subscribe(ctx, redisChangesChannel)
+    // Wait for the confirmation so that changes published after returning are received.
+    if _, err := ps.Receive(ctx); err != nil {
+        log.Printf("❌ Failed to subscribe to subscription changes: %v", err)
+        ps.Close()
+        close(out)
+        return out, cancel
+    }
+    go func() {
+        defer close(out)
+        defer ps.Close()
+        messages := ps.Channel()
+        for {
+            select {
+            case <-ctx.Done():
+                return
+            case msg, ok := <-messages:
+                if !ok {
+                    return
+                }
+                var change domain.SubscriptionChange
+                if err := json.Unmarshal([]byte(msg.Payload), &change); err != nil {
+                    log.Printf("Dropping undecodable subscription change: %v", err)
+                    continue
+                }
+                select {
+                case out <- change:
+                default:
+                    log.Printf("subscription change listener is full, dropping %s change for %s", change.Type, change.Subscription.Address)
+                }
+            }
+        }
+    }()
+    return out, cancel
+}
+
+// readGroup first claims entries other consumers left pending past the ack timeout, then
+// waits for new entries.
+func (b *redisEventBus) readGroup(ctx context.Context, group string) ([]redis.XMessage, error) {
+    claimed, _, err := b.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+        Stream:   redisStream,
+        Group:    group,
+        Consumer: b.consumer,
+        MinIdle:  b.ackTimeout,
+        Start:    "0-0",
+        Count:    100,
+    }).Result()
+    if err != nil {
+        return nil, err
+    }
+    if len(claimed) > 0 {
+        log.Printf("Redis consumer %q claimed %d pending events for redelivery", group, len(claimed))
+        return claimed, nil
+    }
+
+    streams, err := b.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+        Group:    group,
+        Consumer: b.consumer,
+        Streams:  []string{redisStream, ">"},
+        Count:    100,
+        Block:    time.Second,
+    }).Result()
+    if errors.Is(err, redis.Nil) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return streams[0].Messages, nil
+}
+
+func decodeRedisEvent(msg redis.XMessage) (domain.TransactionEvent, bool) {
+    var evt domain.TransactionEvent
+    raw, _ := msg.Values["event"].(string)
+    if err := json.Unmarshal([]byte(raw), &evt); err != nil {
+        log.Printf("Dropping undecodable Redis entry %s: %v", msg.ID, err)
+        return evt, false
+    }
+    return evt, true
+}
@@ -0,0 +1,44 @@
+package eventbus
+
+import (
+    "context"
+    "os"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedisBus connects to the Redis server in TEST_REDIS_URL, e.g. the one of
+// docker-compose --profile brokers, or to an embedded server without it.
+func newTestRedisBus(t *testing.T) *redisEventBus {
+    t.Helper()
+    url := os.Getenv("TEST_REDIS_URL")
+    if url == "" {
+        url = "redis://" + miniredis.RunT(t).Addr()
+    }
+    bus, err := NewRedisEventBus(url, 5*time.Second, time.Hour)
+    if err != nil {
+        t.Fatalf("connect to Redis: %v", err)
+    }
+    b := bus.(*redisEventBus)
+    t.Cleanup(func() { b.rdb.Close() })
+    return b
+}
+
+func TestRedisSubscribeFansOut(t *testing.T) {
+    testFanOut(t, newTestRedisBus(t))
+}
+
+func TestRedisSubscribeGroupSharesEvents(t *testing.T) {
+    bus := newTestRedisBus(t)
+    group := testRun(t)
+    t.Cleanup(func() {
+        bus.rdb.XGroupDestroy(context.Background(), redisStream, group)
+    })
+    testGroup(t, bus, group)
+}
+
+func TestRedisBroadcastsSubscriptionChanges(t *testing.T) {
+    testChanges(t, newTestRedisBus(t))
+}
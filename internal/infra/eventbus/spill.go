@@ -0,0 +1,181 @@
+package eventbus
+
+import (
+    "bufio"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// spillQueue is the overflow file of a subscriber using the spill policy. Publish appends
+// events as JSON lines; a drain goroutine feeds them back into the subscriber channel in
+// order and truncates the file whenever it has caught up, so it only grows while the
+// subscriber is behind.
+type spillQueue struct {
+    mu     sync.Mutex
+    path   string
+    w      *os.File
+    r      *bufio.Reader
+    rf     *os.File
+    count  int
+    wake   chan struct{}
+    stop   chan struct{}
+    done   chan struct{}
+    closed bool
+}
+
+func newSpillQueue(dir string, name string, out chan<- domain.TransactionEvent) (*spillQueue, error) {
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return nil, err
+    }
+    path := filepath.Join(dir, fmt.Sprintf("eventbus-%s.spill", strings.ReplaceAll(name, string(os.PathSeparator), "_")))
+    w, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o600)
+    if err != nil {
+        return nil, err
+    }
+    rf, err := os.Open(path)
+    if err != nil {
+        w.Close()
+        return nil, err
+    }
+    q := &spillQueue{
+        path: path,
+        w:    w,
+        rf:   rf,
+        r:    bufio.NewReader(rf),
+        wake: make(chan struct{}, 1),
+        stop: make(chan struct{}),
+        done: make(chan struct{}),
+    }
+    go q.drain(out)
+    return q, nil
+}
+
+func (q *spillQueue) push(event domain.TransactionEvent) error {
+    line, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if q.closed {
+        return errors.New("spill queue closed")
+    }
+    if _, err := q.w.Write(append(line, '\n')); err != nil {
+        return err
+    }
+    q.count++
+    select {
+    case q.wake <- struct{}{}:
+    default:
+    }
+    return nil
+}
+
+func (q *spillQueue) pending() int {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    return q.count
+}
+
+func (q *spillQueue) drain(out chan<- domain.TransactionEvent) {
+    defer close(q.done)
+    for {
+        select {
+        case <-q.stop:
+            return
+        case <-q.wake:
+        }
+        for {
+            event, ok := q.next()
+            if !ok {
+                break
+            }
+            select {
+            case out <- event:
+                q.consumed()
+            case <-q.stop:
+                return
+            }
+        }
+    }
+}
+
+// next reads the oldest spilled event, or reports false when the file is drained.
+func (q *spillQueue) next() (domain.TransactionEvent, bool) {
+    var event domain.TransactionEvent
+    for {
+        q.mu.Lock()
+        if q.count == 0 {
+            q.mu.Unlock()
+            return event, false
+        }
+        line, err := q.r.ReadBytes('\n')
+        q.mu.Unlock()
+        if err != nil {
+            // The writer may still be flushing the line; try again on the next wake-up.
+            log.Printf("spill file %s: %v", q.path, err)
+            return event, false
+        }
+        if err := json.Unmarshal(line, &event); err != nil {
+            log.Printf("spill file %s: skipping corrupt line: %v", q.path, err)
+            q.consumed()
+            continue
+        }
+        return event, true
+    }
+}
+
+// consumed accounts for one event handed back to the subscriber and truncates the file
+// once all spilled events are gone.
+func (q *spillQueue) consumed() {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    q.count--
+    if q.count > 0 {
+        return
+    }
+    if err := q.w.Truncate(0); err != nil {
+        log.Printf("spill file %s: truncate failed: %v", q.path, err)
+        return
+    }
+    if _, err := q.w.Seek(0, 0); err != nil {
+        log.Printf("spill file %s: seek failed: %v", q.path, err)
+        return
+    }
+    if _, err := q.rf.Seek(0, 0); err != nil {
+        log.Printf("spill file %s: seek failed: %v", q.path, err)
+        return
+    }
+    q.r.Reset(q.rf)
+}
+
+// close stops the drain goroutine and removes the file. Events still spilled are lost.
+func (q *spillQueue) close() {
+    q.mu.Lock()
+    if q.closed {
+        q.mu.Unlock()
+        return
+    }
+    q.closed = true
+    q.mu.Unlock()
+
+    close(q.stop)
+    <-q.done
+
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    if q.count > 0 {
+        log.Printf("spill file %s: discarding %d undelivered events", q.path, q.count)
+    }
+    q.w.Close()
+    q.rf.Close()
+    os.Remove(q.path)
+}
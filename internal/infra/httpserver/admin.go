@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+    "crypto/subtle"
+    "errors"
+    "net/http"
+    "strconv"
+
+    "github.com/labstack/echo/v4"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// AdminAuth only lets requests through that carry the admin token in X-Admin-Token.
+// Without a configured token the admin endpoints are disabled.
+func AdminAuth(token string) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            if token == "" {
+                return c.JSON(http.StatusNotFound, map[string]string{"error": "admin endpoints are disabled"})
+            }
+            given := c.Request().Header.Get("X-Admin-Token")
+            if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+                return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid admin token"})
+            }
+            return next(c)
+        }
+    }
+}
+
+// ListDeadLettersHandler lists the most recent dead-lettered deliveries.
+func ListDeadLettersHandler(queue func() ports.DeliveryQueue) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        q := queue()
+        if q == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "delivery queue not available"})
+        }
+        limit, err := strconv.Atoi(c.QueryParam("limit"))
+        if err != nil || limit <= 0 {
+            limit = 50
+        }
+        items, err := q.ListDeadLetters(c.Request().Context(), limit)
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, items)
+    }
+}
+
+// ReplayDeadLetterHandler queues a dead-lettered delivery again.
+func ReplayDeadLetterHandler(queue func() ports.DeliveryQueue) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        q := queue()
+        if q == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "delivery queue not available"})
+        }
+        err := q.ReplayDeadLetter(c.Request().Context(), c.Param("id"))
+        if errors.Is(err, domain.ErrDeadLetterNotFound) {
+            return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+        }
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusAccepted, map[string]string{"status": "queued"})
+    }
+}
@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/labstack/echo/v4"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/services"
+)
+
+type createAlertRequest struct {
+    Type   string         `json:"type"`
+    Config map[string]any `json:"config"`
+}
+
+type routeRequest struct {
+    Channels []string `json:"channels"`
+}
+
+// ListAlertsHandler lists the alert channels of a chat, without their secrets.
+func ListAlertsHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        items, err := svc.List(c.Request().Context(), c.Param("chatId"))
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, items)
+    }
+}
+
+// CreateAlertHandler adds an alert channel to a chat. The response is the only one that
+// includes a generated webhook secret. Telegram channels are created unconfirmed and start
+// getting alerts once their chat accepts them through the bot.
+func CreateAlertHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        var req createAlertRequest
+        if err := c.Bind(&req); err != nil {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+        }
+        alert, err := svc.Create(c.Request().Context(), c.Param("chatId"), req.Type, req.Config)
+        if err != nil {
+            return alertResult(c, err)
+        }
+        return c.JSON(http.StatusCreated, alert)
+    }
+}
+
+// DeleteAlertHandler removes an alert channel from a chat.
+func DeleteAlertHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        return alertResult(c, svc.Delete(c.Request().Context(), c.Param("chatId"), c.Param("id")))
+    }
+}
+
+// EnableAlertHandler turns an alert channel back on after it was disabled, e.g. for failing.
+func EnableAlertHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        return alertResult(c, svc.Enable(c.Request().Context(), c.Param("chatId"), c.Param("id")))
+    }
+}
+
+// DisableAlertHandler pauses an alert channel.
+func DisableAlertHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        return alertResult(c, svc.Disable(c.Request().Context(), c.Param("chatId"), c.Param("id")))
+    }
+}
+
+// TestAlertHandler sends a sample alert to a channel and reports whether it was delivered.
+func TestAlertHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        err := svc.Test(c.Request().Context(), c.Param("chatId"), c.Param("id"))
+        if errors.Is(err, domain.ErrAlertNotFound) || errors.Is(err, domain.ErrChannelUnavailable) || errors.Is(err, domain.ErrAlertUnconfirmed) {
+            return alertResult(c, err)
+        }
+        if err != nil {
+            // The channel failed, not the request.
+            return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+        }
+        return c.NoContent(http.StatusNoContent)
+    }
+}
+
+// RouteSubscriptionHandler picks the channels the alerts of one subscription go to.
+func RouteSubscriptionHandler(alerts func() *services.AlertService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := alerts()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "alerts not available"})
+        }
+        var req routeRequest
+        if err := c.Bind(&req); err != nil {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+        }
+        return alertResult(c, svc.Route(c.Request().Context(), c.Param("chatId"), c.Param("blockchain"), c.Param("address"), req.Channels))
+    }
+}
+
+func alertResult(c echo.Context, err error) error {
+    if errors.Is(err, domain.ErrAlertNotFound) || errors.Is(err, domain.ErrSubscriptionNotFound) {
+        return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+    }
+    if errors.Is(err, domain.ErrInvalidAlert) {
+        return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+    }
+    if errors.Is(err, domain.ErrAlertUnconfirmed) {
+        return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+    }
+    if errors.Is(err, domain.ErrChannelUnavailable) {
+        return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+    }
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    return c.NoContent(http.StatusNoContent)
+}
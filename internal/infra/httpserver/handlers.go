@@ -1,13 +1,17 @@
 package httpserver
 
 import (
+    "errors"
     "net/http"
+    "slices"
+    "strconv"
     "time"
 
     "github.com/golang-jwt/jwt/v5"
     "github.com/labstack/echo/v4"
 
     "github.com/you/wallet_transaction_notifier/internal/config"
+    "github.com/you/wallet_transaction_notifier/internal/domain"
     "github.com/you/wallet_transaction_notifier/internal/services"
 )
 
@@ -36,6 +40,95 @@ func LoginHandler(cfg config.Config) echo.HandlerFunc {
     }
 }
 
+// authUser returns the subject of the request's JWT.
+func authUser(c echo.Context) string {
+    token, ok := c.Get("user").(*jwt.Token)
+    if !ok {
+        return ""
+    }
+    sub, _ := token.Claims.GetSubject()
+    return sub
+}
+
+// ChatAuth only lets requests for /chats/:chatId through whose JWT subject is that chat,
+// so a token cannot read or change another chat's notifications, rules or alert channels.
+func ChatAuth(next echo.HandlerFunc) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        if user := authUser(c); user == "" || user != c.Param("chatId") {
+            return c.JSON(http.StatusForbidden, map[string]string{"error": "token is not valid for this chat"})
+        }
+        return next(c)
+    }
+}
+
+// parseToken verifies a JWT signed with the configured secret. Stream tickets are signed
+// with it too but only open the stream, see StreamTicketAuth.
+func parseToken(secret string) func(auth string, c echo.Context) (interface{}, error) {
+    return func(auth string, c echo.Context) (interface{}, error) {
+        token, err := jwt.Parse(auth, func(t *jwt.Token) (interface{}, error) {
+            return []byte(secret), nil
+        }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+        if err != nil {
+            return nil, err
+        }
+        if audience, _ := token.Claims.GetAudience(); slices.Contains(audience, streamTicketAudience) {
+            return nil, errors.New("stream tickets are not API tokens")
+        }
+        return token, nil
+    }
+}
+
+type addWalletRequest struct {
+    Blockchain string `json:"blockchain"`
+    Address    string `json:"address"`
+}
+
+// ListOwnWalletsHandler lists the wallets of the authenticated user.
+func ListOwnWalletsHandler(api *services.APIService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        items, err := api.ListUserWallets(c.Request().Context(), authUser(c))
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, items)
+    }
+}
+
+// AddWalletHandler adds a wallet of the authenticated user.
+func AddWalletHandler(api *services.APIService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        var req addWalletRequest
+        if err := c.Bind(&req); err != nil {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+        }
+        wallet, err := api.AddWallet(c.Request().Context(), authUser(c), req.Blockchain, req.Address)
+        if errors.Is(err, domain.ErrInvalidWallet) {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+        }
+        if errors.Is(err, domain.ErrWalletExists) {
+            return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+        }
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusCreated, wallet)
+    }
+}
+
+// DeleteWalletHandler removes a wallet of the authenticated user.
+func DeleteWalletHandler(api *services.APIService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        err := api.DeleteWallet(c.Request().Context(), authUser(c), c.Param("id"))
+        if errors.Is(err, domain.ErrWalletNotFound) {
+            return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+        }
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.NoContent(http.StatusNoContent)
+    }
+}
+
 // ListWalletsHandler calls API service to list wallets.
 func ListWalletsHandler(api *services.APIService) echo.HandlerFunc {
     return func(c echo.Context) error {
@@ -48,4 +141,22 @@ func ListWalletsHandler(api *services.APIService) echo.HandlerFunc {
     }
 }
 
-
+// ListNotificationsHandler lists a chat's notifications with their delivery status per channel.
+func ListNotificationsHandler(api *services.APIService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        address := c.QueryParam("address")
+        blockchain := c.QueryParam("blockchain")
+        if address != "" && blockchain == "" {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "blockchain is required with address"})
+        }
+        limit, err := strconv.Atoi(c.QueryParam("limit"))
+        if err != nil || limit <= 0 {
+            limit = 20
+        }
+        items, err := api.ListChatNotifications(c.Request().Context(), c.Param("chatId"), blockchain, address, limit)
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, items)
+    }
+}
@@ -0,0 +1,62 @@
+package httpserver
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+
+    "github.com/you/wallet_transaction_notifier/internal/config"
+    "github.com/you/wallet_transaction_notifier/internal/infra/eventbus"
+)
+
+func testToken(t *testing.T, secret, sub string) string {
+    t.Helper()
+    token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "sub": sub,
+        "exp": time.Now().Add(time.Hour).Unix(),
+    }).SignedString([]byte(secret))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return token
+}
+
+func TestChatRoutesNeedTheChatsToken(t *testing.T) {
+    cfg := config.Config{JWTSecret: "secret", AppPort: "0"}
+    s := NewServer(cfg, eventbus.NewInMemoryEventBus(), nil, nil)
+
+    requests := []struct{ method, path string }{
+        {http.MethodGet, "/chats/42/alerts"},
+        {http.MethodPost, "/chats/42/alerts"},
+        {http.MethodDelete, "/chats/42/alerts/a1"},
+        {http.MethodPost, "/chats/42/alerts/a1/test"},
+        {http.MethodPost, "/chats/42/alerts/a1/enable"},
+        {http.MethodPut, "/chats/42/subscriptions/ethereum/0xabc/channels"},
+        {http.MethodGet, "/chats/42/rules"},
+    }
+    tokens := []struct {
+        name  string
+        token string
+        want  int
+    }{
+        {"no token", "", http.StatusBadRequest}, // echo reports a missing JWT as malformed
+        {"other chat", testToken(t, cfg.JWTSecret, "7"), http.StatusForbidden},
+        {"own chat", testToken(t, cfg.JWTSecret, "42"), http.StatusServiceUnavailable},
+    }
+    for _, r := range requests {
+        for _, tt := range tokens {
+            req := httptest.NewRequest(r.method, r.path, nil)
+            if tt.token != "" {
+                req.Header.Set("Authorization", "Bearer "+tt.token)
+            }
+            rec := httptest.NewRecorder()
+            s.echo.ServeHTTP(rec, req)
+            if rec.Code != tt.want {
+                t.Errorf("%s %s with %s: status %d, want %d", r.method, r.path, tt.name, rec.Code, tt.want)
+            }
+        }
+    }
+}
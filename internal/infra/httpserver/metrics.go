@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+    "fmt"
+    "net/http"
+    "sort"
+    "strings"
+
+    "github.com/labstack/echo/v4"
+
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// MetricsHandler serves the metrics of the registered providers in the Prometheus text format.
+func MetricsHandler(providers func() []ports.MetricsProvider) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        var b strings.Builder
+        described := make(map[string]bool)
+        for _, p := range providers() {
+            for _, m := range p.Metrics() {
+                if !described[m.Name] {
+                    described[m.Name] = true
+                    fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.Name, m.Help, m.Name, m.Type)
+                }
+                fmt.Fprintf(&b, "%s%s %v\n", m.Name, formatLabels(m.Labels), m.Value)
+            }
+        }
+        return c.Blob(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+    }
+}
+
+func formatLabels(labels map[string]string) string {
+    if len(labels) == 0 {
+        return ""
+    }
+    keys := make([]string, 0, len(labels))
+    for k := range labels {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    parts := make([]string, len(keys))
+    for i, k := range keys {
+        v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+        parts[i] = fmt.Sprintf(`%s="%s"`, k, v)
+    }
+    return "{" + strings.Join(parts, ",") + "}"
+}
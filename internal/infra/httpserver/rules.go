@@ -0,0 +1,71 @@
+package httpserver
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/labstack/echo/v4"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/services"
+)
+
+type createRuleRequest struct {
+    Name       string `json:"name"`
+    Expression string `json:"expression"`
+}
+
+// ListRulesHandler lists the expression rules of a chat.
+func ListRulesHandler(rules func() *services.ChatRuleService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := rules()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "rules not available"})
+        }
+        items, err := svc.List(c.Request().Context(), c.Param("chatId"))
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, items)
+    }
+}
+
+// CreateRuleHandler adds a rule to a chat; invalid expressions are rejected with 400.
+func CreateRuleHandler(rules func() *services.ChatRuleService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := rules()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "rules not available"})
+        }
+        var req createRuleRequest
+        if err := c.Bind(&req); err != nil {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+        }
+        rule, err := svc.Create(c.Request().Context(), c.Param("chatId"), req.Name, req.Expression)
+        if errors.Is(err, domain.ErrInvalidRule) {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+        }
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusCreated, rule)
+    }
+}
+
+// DeleteRuleHandler removes a rule from a chat.
+func DeleteRuleHandler(rules func() *services.ChatRuleService) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        svc := rules()
+        if svc == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "rules not available"})
+        }
+        err := svc.Delete(c.Request().Context(), c.Param("chatId"), c.Param("id"))
+        if errors.Is(err, domain.ErrRuleNotFound) {
+            return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+        }
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.NoContent(http.StatusNoContent)
+    }
+}
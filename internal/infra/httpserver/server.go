@@ -4,6 +4,7 @@ import (
     "context"
     "fmt"
     "log"
+    "strings"
 
     "github.com/labstack/echo/v4"
     "github.com/labstack/echo/v4/middleware"
@@ -21,25 +22,41 @@ type Server struct {
     closed bool
     wallets ports.WalletRepository
     api    *services.APIService
+    metrics []ports.MetricsProvider
+    deliveries ports.DeliveryQueue
+    rules   *services.ChatRuleService
+    alerts  *services.AlertService
+    feed    *services.LiveFeed
+    tickets *streamTickets
+    bot     *services.TelegramBotService
 }
 
-func NewServer(cfg config.Config, eb ports.EventBus, wallets ports.WalletRepository) *Server {
+func NewServer(cfg config.Config, eb ports.EventBus, wallets ports.WalletRepository, notifs ports.NotificationRepository) *Server {
     e := echo.New()
     e.HideBanner = true
     e.Use(middleware.Recover())
-    e.Use(middleware.Logger())
+    // Paths are logged without their query, which may hold a stream ticket.
+    e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+        Format: strings.Replace(middleware.DefaultLoggerConfig.Format, `"uri":"${uri}"`, `"path":"${path}"`, 1),
+    }))
     e.Use(middleware.JWTWithConfig(middleware.JWTConfig{
         SigningKey: []byte(cfg.JWTSecret),
         Skipper: func(c echo.Context) bool {
             // Allow health and auth endpoints without JWT
             path := c.Path()
-            if path == "/health" || path == "/auth/login" {
+            if path == "/health" || path == "/auth/login" || path == "/metrics" {
+                return true
+            }
+            // Stream requests with a ticket are checked by StreamTicketAuth
+            if path == streamPath && c.QueryParam("ticket") != "" {
                 return true
             }
-            return false
+            // Admin endpoints check the admin token instead, the Telegram webhook its secret
+            return strings.HasPrefix(path, "/admin/") || path == telegramWebhookRoute
         },
         ContextKey: "user",
         TokenLookup: "header:Authorization:Bearer ",
+        ParseTokenFunc: parseToken(cfg.JWTSecret),
     }))
 
     s := &Server{
@@ -48,16 +65,76 @@ func NewServer(cfg config.Config, eb ports.EventBus, wallets ports.WalletReposit
         echo: e,
         addr: fmt.Sprintf(":%s", cfg.AppPort),
         wallets: wallets,
+        tickets: newStreamTickets(cfg.JWTSecret),
+    }
+    s.api = services.NewAPIService(wallets, notifs)
+    if p, ok := eb.(ports.MetricsProvider); ok {
+        s.RegisterMetrics(p)
     }
-    s.api = services.NewAPIService(wallets)
     s.registerRoutes()
     return s
 }
 
+// UseDeliveryQueue enables the admin endpoints for dead-lettered deliveries.
+func (s *Server) UseDeliveryQueue(q ports.DeliveryQueue) {
+    s.deliveries = q
+}
+
+// UseChatRules enables the chat rule endpoints.
+func (s *Server) UseChatRules(rules *services.ChatRuleService) {
+    s.rules = rules
+}
+
+// UseAlerts enables the alert channel endpoints.
+func (s *Server) UseAlerts(alerts *services.AlertService) {
+    s.alerts = alerts
+}
+
+// UseLiveFeed enables the live event stream.
+func (s *Server) UseLiveFeed(feed *services.LiveFeed) {
+    s.feed = feed
+}
+
+// UseTelegramBot makes the API receive the bot's updates from Telegram in webhook mode.
+func (s *Server) UseTelegramBot(bot *services.TelegramBotService) {
+    s.bot = bot
+}
+
+// RegisterMetrics adds a component whose metrics are served on /metrics.
+func (s *Server) RegisterMetrics(p ports.MetricsProvider) {
+    s.metrics = append(s.metrics, p)
+}
+
 func (s *Server) registerRoutes() {
     s.echo.GET("/health", HealthHandler)
     s.echo.POST("/auth/login", LoginHandler(s.cfg))
     s.echo.GET("/users/:userId/wallets", ListWalletsHandler(s.api))
+    s.echo.GET("/wallets", ListOwnWalletsHandler(s.api))
+    s.echo.POST("/wallets", AddWalletHandler(s.api))
+    s.echo.DELETE("/wallets/:id", DeleteWalletHandler(s.api))
+    s.echo.GET(streamPath, StreamEventsHandler(func() *services.LiveFeed { return s.feed }, newStreamUpgrader(s.cfg.StreamOrigins)), StreamTicketAuth(s.tickets))
+    s.echo.POST(streamTicketPath, StreamTicketHandler(s.tickets))
+    chats := s.echo.Group("/chats/:chatId", ChatAuth)
+    chats.GET("/notifications", ListNotificationsHandler(s.api))
+    rules := func() *services.ChatRuleService { return s.rules }
+    chats.GET("/rules", ListRulesHandler(rules))
+    chats.POST("/rules", CreateRuleHandler(rules))
+    chats.DELETE("/rules/:id", DeleteRuleHandler(rules))
+    alerts := func() *services.AlertService { return s.alerts }
+    chats.GET("/alerts", ListAlertsHandler(alerts))
+    chats.POST("/alerts", CreateAlertHandler(alerts))
+    chats.DELETE("/alerts/:id", DeleteAlertHandler(alerts))
+    chats.POST("/alerts/:id/enable", EnableAlertHandler(alerts))
+    chats.POST("/alerts/:id/disable", DisableAlertHandler(alerts))
+    chats.POST("/alerts/:id/test", TestAlertHandler(alerts))
+    chats.PUT("/subscriptions/:blockchain/:address/channels", RouteSubscriptionHandler(alerts))
+    s.echo.GET("/metrics", MetricsHandler(func() []ports.MetricsProvider { return s.metrics }))
+    s.echo.POST(telegramWebhookRoute, TelegramWebhookHandler(func() *services.TelegramBotService { return s.bot }, s.cfg.TelegramWebhookSecret))
+
+    admin := s.echo.Group("/admin", AdminAuth(s.cfg.AdminToken))
+    deliveries := func() ports.DeliveryQueue { return s.deliveries }
+    admin.GET("/dead-letters", ListDeadLettersHandler(deliveries))
+    admin.POST("/dead-letters/:id/replay", ReplayDeadLetterHandler(deliveries))
 }
 
 func (s *Server) Start() error {
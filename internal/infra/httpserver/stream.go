@@ -0,0 +1,305 @@
+package httpserver
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/gorilla/websocket"
+    "github.com/labstack/echo/v4"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/services"
+)
+
+// streamPath is served as Server-Sent Events, or as a WebSocket when the request asks to
+// upgrade. Browsers cannot set headers on either, so it also accepts a ?ticket= issued by
+// streamTicketPath.
+const (
+    streamPath       = "/events/stream"
+    streamTicketPath = "/events/stream/ticket"
+)
+
+// streamHeartbeat keeps idle connections from being closed by proxies.
+const streamHeartbeat = 15 * time.Second
+
+// streamTicketTTL is how long a stream ticket can be redeemed.
+const streamTicketTTL = 30 * time.Second
+
+// streamTicketAudience marks a JWT as a stream ticket, which opens the live stream once and
+// is not accepted as an API token.
+const streamTicketAudience = "events/stream"
+
+// streamTickets issues short-lived tickets that open the live stream once, so the user's
+// token never appears in a URL, where proxies and access logs would keep it.
+type streamTickets struct {
+    secret []byte
+
+    mu   sync.Mutex
+    used map[string]time.Time // IDs of redeemed tickets until they expire
+}
+
+func newStreamTickets(secret string) *streamTickets {
+    return &streamTickets{secret: []byte(secret), used: make(map[string]time.Time)}
+}
+
+func (t *streamTickets) issue(userID string, now time.Time) (string, error) {
+    id := make([]byte, 16)
+    if _, err := rand.Read(id); err != nil {
+        return "", err
+    }
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+        Subject:   userID,
+        Audience:  jwt.ClaimStrings{streamTicketAudience},
+        ExpiresAt: jwt.NewNumericDate(now.Add(streamTicketTTL)),
+        ID:        hex.EncodeToString(id),
+    })
+    return token.SignedString(t.secret)
+}
+
+// redeem verifies a ticket and marks it used. Used tickets are remembered by each API process,
+// so behind several replicas a ticket could be redeemed once per replica until it expires.
+func (t *streamTickets) redeem(ticket string, now time.Time) (*jwt.Token, error) {
+    token, err := jwt.Parse(ticket, func(*jwt.Token) (interface{}, error) {
+        return t.secret, nil
+    }, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(streamTicketAudience), jwt.WithExpirationRequired(), jwt.WithTimeFunc(func() time.Time { return now }))
+    if err != nil {
+        return nil, err
+    }
+    claims, _ := token.Claims.(jwt.MapClaims)
+    id, _ := claims["jti"].(string)
+    expires, _ := claims.GetExpirationTime()
+    if id == "" || expires == nil {
+        return nil, errors.New("ticket has no ID")
+    }
+
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    for used, until := range t.used {
+        if now.After(until) {
+            delete(t.used, used)
+        }
+    }
+    if _, ok := t.used[id]; ok {
+        return nil, errors.New("ticket was already used")
+    }
+    t.used[id] = expires.Time
+    return token, nil
+}
+
+// StreamTicketHandler issues a ticket the authenticated user opens the live stream with.
+func StreamTicketHandler(tickets *streamTickets) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        userID := authUser(c)
+        if userID == "" {
+            return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token has no subject"})
+        }
+        ticket, err := tickets.issue(userID, time.Now())
+        if err != nil {
+            return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        }
+        return c.JSON(http.StatusOK, map[string]any{"ticket": ticket, "expiresIn": int(streamTicketTTL.Seconds())})
+    }
+}
+
+// StreamTicketAuth authenticates stream requests that carry a ticket; the JWT middleware
+// skips those.
+func StreamTicketAuth(tickets *streamTickets) echo.MiddlewareFunc {
+    return func(next echo.HandlerFunc) echo.HandlerFunc {
+        return func(c echo.Context) error {
+            ticket := c.QueryParam("ticket")
+            if ticket == "" {
+                return next(c)
+            }
+            token, err := tickets.redeem(ticket, time.Now())
+            if err != nil {
+                return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or used stream ticket"})
+            }
+            c.Set("user", token)
+            return next(c)
+        }
+    }
+}
+
+// newStreamUpgrader accepts WebSocket streams from pages of the API's own origin and of the
+// allowed origins, and from clients that are not browsers and send no Origin.
+func newStreamUpgrader(origins []string) *websocket.Upgrader {
+    allowed := make(map[string]bool, len(origins))
+    for _, o := range origins {
+        allowed[strings.ToLower(strings.TrimRight(o, "/"))] = true
+    }
+    return &websocket.Upgrader{
+        CheckOrigin: func(r *http.Request) bool {
+            origin := r.Header.Get("Origin")
+            if origin == "" || allowed[strings.ToLower(origin)] {
+                return true
+            }
+            u, err := url.Parse(origin)
+            return err == nil && strings.EqualFold(u.Host, r.Host)
+        },
+    }
+}
+
+// streamMessage is a WebSocket frame: a "transaction" with the event, or a "reset" telling
+// the client that events were missed since the one it resumed from.
+type streamMessage struct {
+    Type string                   `json:"type"`
+    ID   string                   `json:"id,omitempty"`
+    Data *domain.TransactionEvent `json:"data,omitempty"`
+}
+
+// StreamEventsHandler streams the events of the authenticated user's wallets. Clients resume
+// with the Last-Event-ID header or the lastEventId query parameter.
+func StreamEventsHandler(feed func() *services.LiveFeed, upgrader *websocket.Upgrader) echo.HandlerFunc {
+    return func(c echo.Context) error {
+        f := feed()
+        if f == nil {
+            return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "live feed not available"})
+        }
+        userID := authUser(c)
+        if userID == "" {
+            return c.JSON(http.StatusUnauthorized, map[string]string{"error": "token has no subject"})
+        }
+        lastEventID := c.Request().Header.Get("Last-Event-ID")
+        if lastEventID == "" {
+            lastEventID = c.QueryParam("lastEventId")
+        }
+        if websocket.IsWebSocketUpgrade(c.Request()) {
+            return streamWebSocket(c, f, upgrader, userID, lastEventID)
+        }
+        return streamSSE(c, f, userID, lastEventID)
+    }
+}
+
+func streamSSE(c echo.Context, f *services.LiveFeed, userID, lastEventID string) error {
+    ctx := c.Request().Context()
+    sub, err := f.Subscribe(ctx, userID, lastEventID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+
+    w := c.Response()
+    w.Header().Set(echo.HeaderContentType, "text/event-stream")
+    w.Header().Set(echo.HeaderCacheControl, "no-cache")
+    w.Header().Set(echo.HeaderConnection, "keep-alive")
+    w.Header().Set("X-Accel-Buffering", "no") // nginx
+    w.WriteHeader(http.StatusOK)
+
+    fmt.Fprint(w, "retry: 5000\n\n")
+    if sub.Gap {
+        fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+    }
+    for _, evt := range sub.Backlog {
+        if err := writeSSE(w, evt); err != nil {
+            return nil
+        }
+    }
+    w.Flush()
+
+    heartbeat := time.NewTicker(streamHeartbeat)
+    defer heartbeat.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-heartbeat.C:
+            if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+                return nil
+            }
+        case evt, ok := <-sub.Events:
+            if !ok {
+                // Fell behind; the client reconnects with Last-Event-ID.
+                return nil
+            }
+            if err := writeSSE(w, evt); err != nil {
+                return nil
+            }
+        }
+        w.Flush()
+    }
+}
+
+func writeSSE(w *echo.Response, evt domain.TransactionEvent) error {
+    data, err := json.Marshal(evt)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "id: %s\nevent: transaction\ndata: %s\n\n", evt.ID, data)
+    return err
+}
+
+func streamWebSocket(c echo.Context, f *services.LiveFeed, upgrader *websocket.Upgrader, userID, lastEventID string) error {
+    ctx := c.Request().Context()
+    sub, err := f.Subscribe(ctx, userID, lastEventID)
+    if err != nil {
+        return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+    }
+    conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+    if err != nil {
+        // The upgrader has already answered with an error.
+        return nil
+    }
+    defer conn.Close()
+
+    // Nothing is expected from the client, but reading is what notices it going away and
+    // processes its pongs.
+    closed := make(chan struct{})
+    conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+    conn.SetPongHandler(func(string) error {
+        return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+    })
+    go func() {
+        defer close(closed)
+        for {
+            if _, _, err := conn.ReadMessage(); err != nil {
+                return
+            }
+        }
+    }()
+
+    send := func(msg streamMessage) error {
+        conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+        return conn.WriteJSON(msg)
+    }
+    if sub.Gap {
+        if err := send(streamMessage{Type: "reset"}); err != nil {
+            return nil
+        }
+    }
+    for i := range sub.Backlog {
+        if err := send(streamMessage{Type: "transaction", ID: sub.Backlog[i].ID, Data: &sub.Backlog[i]}); err != nil {
+            return nil
+        }
+    }
+
+    heartbeat := time.NewTicker(streamHeartbeat)
+    defer heartbeat.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-closed:
+            return nil
+        case <-heartbeat.C:
+            if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+                return nil
+            }
+        case evt, ok := <-sub.Events:
+            if !ok {
+                conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "fell behind, resume with lastEventId"), time.Now().Add(time.Second))
+                return nil
+            }
+            if err := send(streamMessage{Type: "transaction", ID: evt.ID, Data: &evt}); err != nil {
+                return nil
+            }
+        }
+    }
+}
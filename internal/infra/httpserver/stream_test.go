@@ -0,0 +1,206 @@
+package httpserver
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/config"
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/eventbus"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+    "github.com/you/wallet_transaction_notifier/internal/services"
+)
+
+// fakeWallets keeps wallets in memory and announces their writes like the Mongo repository.
+type fakeWallets struct {
+    ports.WalletRepository
+    changes chan domain.SubscriptionChange
+
+    mu      sync.Mutex
+    wallets []domain.Wallet
+}
+
+func (f *fakeWallets) Create(ctx context.Context, wallet domain.Wallet) (domain.Wallet, error) {
+    f.mu.Lock()
+    wallet.ID = wallet.Address
+    f.wallets = append(f.wallets, wallet)
+    f.mu.Unlock()
+    f.changes <- domain.SubscriptionChange{
+        Type:         domain.SubscriptionAdded,
+        Subscription: domain.Subscription{Blockchain: wallet.Blockchain, Address: wallet.Address},
+        Remaining:    1,
+        UserID:       wallet.UserID,
+    }
+    return wallet, nil
+}
+
+func (f *fakeWallets) ListByUser(ctx context.Context, userID string) ([]domain.Wallet, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    var wallets []domain.Wallet
+    for _, w := range f.wallets {
+        if w.UserID == userID {
+            wallets = append(wallets, w)
+        }
+    }
+    return wallets, nil
+}
+
+func streamStatus(s *Server, path, bearer string) int {
+    req := httptest.NewRequest(http.MethodGet, path, nil)
+    if bearer != "" {
+        req.Header.Set("Authorization", "Bearer "+bearer)
+    }
+    rec := httptest.NewRecorder()
+    s.echo.ServeHTTP(rec, req)
+    return rec.Code
+}
+
+// Without a live feed the stream answers 503 once the request is authenticated.
+func TestStreamTicketsOpenTheStreamOnce(t *testing.T) {
+    cfg := config.Config{JWTSecret: "secret", AppPort: "0"}
+    s := NewServer(cfg, eventbus.NewInMemoryEventBus(), nil, nil)
+    token := testToken(t, cfg.JWTSecret, "42")
+
+    req := httptest.NewRequest(http.MethodPost, streamTicketPath, nil)
+    req.Header.Set("Authorization", "Bearer "+token)
+    rec := httptest.NewRecorder()
+    s.echo.ServeHTTP(rec, req)
+    if rec.Code != http.StatusOK {
+        t.Fatalf("ticket request: status %d", rec.Code)
+    }
+    var body struct{ Ticket string }
+    if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Ticket == "" {
+        t.Fatalf("ticket response %s: %v", rec.Body, err)
+    }
+
+    if code := streamStatus(s, streamPath+"?ticket="+body.Ticket, ""); code != http.StatusServiceUnavailable {
+        t.Errorf("first use of the ticket: status %d, want %d", code, http.StatusServiceUnavailable)
+    }
+    if code := streamStatus(s, streamPath+"?ticket="+body.Ticket, ""); code != http.StatusUnauthorized {
+        t.Errorf("second use of the ticket: status %d, want %d", code, http.StatusUnauthorized)
+    }
+    if code := streamStatus(s, "/wallets", body.Ticket); code != http.StatusUnauthorized {
+        t.Errorf("ticket as API token: status %d, want %d", code, http.StatusUnauthorized)
+    }
+    if code := streamStatus(s, streamPath+"?access_token="+token, ""); code == http.StatusServiceUnavailable {
+        t.Error("stream accepted the JWT in the query")
+    }
+    if code := streamStatus(s, streamPath, token); code != http.StatusServiceUnavailable {
+        t.Errorf("stream with the JWT header: status %d, want %d", code, http.StatusServiceUnavailable)
+    }
+}
+
+func TestStreamTicketsExpire(t *testing.T) {
+    tickets := newStreamTickets("secret")
+    now := time.Now()
+    ticket, err := tickets.issue("42", now)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := tickets.redeem(ticket, now.Add(streamTicketTTL+time.Second)); err == nil {
+        t.Error("expired ticket was redeemed")
+    }
+    if _, err := newStreamTickets("other").redeem(ticket, now); err == nil {
+        t.Error("ticket signed with another secret was redeemed")
+    }
+}
+
+func TestStreamUpgraderChecksOrigin(t *testing.T) {
+    upgrader := newStreamUpgrader([]string{"https://Dashboard.example.com/"})
+    tests := []struct {
+        origin string
+        want   bool
+    }{
+        {"", true},
+        {"https://api.example.com", true},
+        {"https://dashboard.example.com", true},
+        {"https://evil.example", false},
+        {"http://dashboard.example.com", false},
+    }
+    for _, tt := range tests {
+        req := httptest.NewRequest(http.MethodGet, "https://api.example.com"+streamPath, nil)
+        if tt.origin != "" {
+            req.Header.Set("Origin", tt.origin)
+        }
+        if got := upgrader.CheckOrigin(req); got != tt.want {
+            t.Errorf("origin %q allowed = %v, want %v", tt.origin, got, tt.want)
+        }
+    }
+}
+
+// A wallet added through the API while the user's stream is open shows up on it.
+func TestStreamShowsWalletAddedThroughTheAPI(t *testing.T) {
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    cfg := config.Config{JWTSecret: "secret", AppPort: "0"}
+    bus := eventbus.NewInMemoryEventBus()
+    wallets := &fakeWallets{changes: make(chan domain.SubscriptionChange, 1)}
+    s := NewServer(cfg, bus, wallets, nil)
+    feed := services.NewLiveFeed(wallets, 10)
+    go feed.Run(ctx, bus)
+    go feed.Follow(ctx, wallets.changes)
+    s.UseLiveFeed(feed)
+    srv := httptest.NewServer(s.echo)
+    defer srv.Close()
+    token := testToken(t, cfg.JWTSecret, "42")
+
+    req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+streamPath, nil)
+    req.Header.Set("Authorization", "Bearer "+token)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    lines := make(chan string)
+    go func() {
+        defer close(lines)
+        scanner := bufio.NewScanner(resp.Body)
+        for scanner.Scan() {
+            lines <- scanner.Text()
+        }
+    }()
+    if line := <-lines; !strings.HasPrefix(line, "retry:") {
+        t.Fatalf("stream starts with %q", line)
+    }
+
+    address := "0x" + strings.Repeat("ab", 20)
+    add, _ := http.NewRequest(http.MethodPost, srv.URL+"/wallets", strings.NewReader(`{"blockchain":"ethereum","address":"0x`+strings.ToUpper(address[2:])+`"}`))
+    add.Header.Set("Authorization", "Bearer "+token)
+    add.Header.Set("Content-Type", "application/json")
+    added, err := http.DefaultClient.Do(add)
+    if err != nil {
+        t.Fatal(err)
+    }
+    added.Body.Close()
+    if added.StatusCode != http.StatusCreated {
+        t.Fatalf("adding the wallet: status %d", added.StatusCode)
+    }
+
+    // The feed reloads the user's wallets in the background, so publish until it passes.
+    publish := time.NewTicker(20 * time.Millisecond)
+    defer publish.Stop()
+    timeout := time.After(5 * time.Second)
+    for {
+        select {
+        case <-publish.C:
+            bus.Publish(domain.TransactionEvent{Blockchain: "ethereum", WalletID: address, TxHash: "0x1", Direction: domain.DirectionIncoming})
+        case line, ok := <-lines:
+            if !ok {
+                t.Fatal("stream closed")
+            }
+            if strings.HasPrefix(line, "data:") && strings.Contains(line, `"txHash":"0x1"`) {
+                return
+            }
+        case <-timeout:
+            t.Fatal("the new wallet's event was not streamed")
+        }
+    }
+}
@@ -0,0 +1,42 @@
+package httpserver
+
+import (
+    "context"
+    "crypto/subtle"
+    "encoding/json"
+    "net/http"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/labstack/echo/v4"
+
+    "github.com/you/wallet_transaction_notifier/internal/services"
+)
+
+// telegramWebhookRoute matches the path of services.TelegramWebhookPath.
+const telegramWebhookRoute = "/telegram/webhook/:hash"
+
+// TelegramWebhookHandler receives the bot's updates from Telegram in webhook mode. Only
+// requests to the path derived from secret that carry it in X-Telegram-Bot-Api-Secret-Token
+// are handled. Updates are handled before answering, since Telegram sends the next update
+// of a chat only once the previous one was answered; failures are not retried, so they are
+// answered with 200 as well.
+func TelegramWebhookHandler(bot func() *services.TelegramBotService, secret string) echo.HandlerFunc {
+    path := services.TelegramWebhookPath(secret)
+    return func(c echo.Context) error {
+        b := bot()
+        if b == nil || secret == "" || subtle.ConstantTimeCompare([]byte(c.Request().URL.Path), []byte(path)) != 1 {
+            return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+        }
+        given := c.Request().Header.Get("X-Telegram-Bot-Api-Secret-Token")
+        if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+            return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid secret token"})
+        }
+        var update tgbotapi.Update
+        if err := json.NewDecoder(http.MaxBytesReader(c.Response(), c.Request().Body, 1<<20)).Decode(&update); err != nil {
+            return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid update"})
+        }
+        // Telegram may hang up before a slow update is handled; finish it anyway.
+        b.HandleUpdate(context.WithoutCancel(c.Request().Context()), update)
+        return c.NoContent(http.StatusOK)
+    }
+}
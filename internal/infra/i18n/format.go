@@ -0,0 +1,142 @@
+package i18n
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// locale is how a language writes numbers and dates.
+type locale struct {
+    digits  []rune // zero to nine, nil for ASCII digits
+    group   string // thousands separator
+    decimal string
+    months  [12]string
+    // dateTime writes a date with its time of day in ASCII digits.
+    dateTime func(year, month, day int, monthName, clock string) string
+    // calendar converts a Gregorian date, nil for the Gregorian calendar.
+    calendar func(year, month, day int) (int, int, int)
+}
+
+var locales = map[string]locale{
+    "en": {
+        group:   ",",
+        decimal: ".",
+        dateTime: func(year, month, day int, _, clock string) string {
+            return fmt.Sprintf("%04d-%02d-%02d %s", year, month, day, clock)
+        },
+    },
+    "ru": {
+        group:   " ",
+        decimal: ",",
+        months:  [12]string{"января", "февраля", "марта", "апреля", "мая", "июня", "июля", "августа", "сентября", "октября", "ноября", "декабря"},
+        dateTime: func(year, _, day int, monthName, clock string) string {
+            return fmt.Sprintf("%d %s %d, %s", day, monthName, year, clock)
+        },
+    },
+    "fa": {
+        digits:  []rune("۰۱۲۳۴۵۶۷۸۹"),
+        group:   "٬",
+        decimal: "٫",
+        months:  [12]string{"فروردین", "اردیبهشت", "خرداد", "تیر", "مرداد", "شهریور", "مهر", "آبان", "آذر", "دی", "بهمن", "اسفند"},
+        dateTime: func(year, _, day int, monthName, clock string) string {
+            return fmt.Sprintf("%d %s %d، %s", day, monthName, year, clock)
+        },
+        calendar: gregorianToJalali,
+    },
+}
+
+func (l Localizer) locale() locale {
+    return locales[l.Lang()]
+}
+
+// Int formats an integer with the language's digits and thousands separator.
+func (l Localizer) Int(n int) string {
+    return l.Number(float64(n))
+}
+
+// Number formats an amount with up to eight decimals, dropping trailing zeros.
+func (l Localizer) Number(v float64) string {
+    return l.decimal(strings.TrimRight(strings.TrimRight(strconv.FormatFloat(v, 'f', 8, 64), "0"), "."))
+}
+
+// Money formats a fiat amount with two decimals and its currency code, e.g. "1,234.50 USD".
+func (l Localizer) Money(v float64, currency string) string {
+    return l.decimal(strconv.FormatFloat(v, 'f', 2, 64)) + " " + strings.ToUpper(currency)
+}
+
+// decimal writes a formatted decimal number with the language's separators and digits.
+func (l Localizer) decimal(text string) string {
+    loc := l.locale()
+    sign := ""
+    if strings.HasPrefix(text, "-") {
+        sign, text = "-", text[1:]
+    }
+    whole, frac, _ := strings.Cut(text, ".")
+
+    var b strings.Builder
+    for i, c := range whole {
+        if i > 0 && (len(whole)-i)%3 == 0 {
+            b.WriteString(loc.group)
+        }
+        b.WriteRune(c)
+    }
+    if frac != "" {
+        b.WriteString(loc.decimal)
+        b.WriteString(frac)
+    }
+    return sign + l.digits(b.String())
+}
+
+// DateTime formats a date and time of day in t's time zone, e.g. "2023-11-14 22:13 UTC",
+// "14 ноября 2023, 22:13 UTC" or "۲۳ آبان ۱۴۰۲، ۲۲:۱۳ UTC".
+func (l Localizer) DateTime(t time.Time) string {
+    loc := l.locale()
+    year, month, day := t.Year(), int(t.Month()), t.Day()
+    if loc.calendar != nil {
+        year, month, day = loc.calendar(year, month, day)
+    }
+    return l.digits(loc.dateTime(year, month, day, loc.months[month-1], t.Format("15:04 MST")))
+}
+
+// Clock formats the time of day of t, e.g. "22:13".
+func (l Localizer) Clock(t time.Time) string {
+    return l.digits(t.Format("15:04"))
+}
+
+// digits replaces ASCII digits with the language's own.
+func (l Localizer) digits(s string) string {
+    loc := l.locale()
+    if loc.digits == nil {
+        return s
+    }
+    return strings.Map(func(r rune) rune {
+        if r >= '0' && r <= '9' {
+            return loc.digits[r-'0']
+        }
+        return r
+    }, s)
+}
+
+// gregorianToJalali converts a Gregorian date to the Solar Hijri calendar used in Iran.
+func gregorianToJalali(gy, gm, gd int) (int, int, int) {
+    daysBefore := [12]int{0, 31, 59, 90, 120, 151, 181, 212, 243, 273, 304, 334}
+    gy2 := gy
+    if gm > 2 {
+        gy2++
+    }
+    days := 355666 + 365*gy + (gy2+3)/4 - (gy2+99)/100 + (gy2+399)/400 + gd + daysBefore[gm-1]
+    jy := -1595 + 33*(days/12053)
+    days %= 12053
+    jy += 4 * (days / 1461)
+    days %= 1461
+    if days > 365 {
+        jy += (days - 1) / 365
+        days = (days - 1) % 365
+    }
+    if days < 186 {
+        return jy, 1 + days/31, 1 + days%31
+    }
+    return jy, 7 + (days-186)/30, 1 + (days-186)%30
+}
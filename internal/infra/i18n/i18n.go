@@ -0,0 +1,144 @@
+package i18n
+
+import (
+    "context"
+    "embed"
+    "encoding/json"
+    "fmt"
+    "strings"
+)
+
+// Default is the language of texts missing from a catalog and of chats whose language is
+// unknown or not translated.
+const Default = "en"
+
+// Languages are the languages with a catalog, the default first.
+var Languages = []string{"en", "fa", "ru"}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps a language to its messages, keyed by message ID. Messages are fmt formats
+// whose verbs are all %s; numeric arguments are localised before they are formatted.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+    catalogs := make(map[string]map[string]string)
+    for _, lang := range Languages {
+        data, err := localeFiles.ReadFile("locales/" + lang + ".json")
+        if err != nil {
+            panic(err)
+        }
+        var messages map[string]string
+        if err := json.Unmarshal(data, &messages); err != nil {
+            panic(fmt.Sprintf("i18n: locales/%s.json: %v", lang, err))
+        }
+        catalogs[lang] = messages
+    }
+    return catalogs
+}
+
+// Match returns the supported language of an IETF language tag such as "fa-IR", or Default.
+func Match(tag string) string {
+    base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+    base, _, _ = strings.Cut(base, "_")
+    if _, ok := catalogs[base]; ok {
+        return base
+    }
+    return Default
+}
+
+// Localizer translates messages and formats numbers and dates for one language. The zero
+// value uses the default language.
+type Localizer struct {
+    lang string
+}
+
+// New returns the Localizer of the language matching tag.
+func New(tag string) Localizer {
+    return Localizer{lang: Match(tag)}
+}
+
+func (l Localizer) Lang() string {
+    if l.lang == "" {
+        return Default
+    }
+    return l.lang
+}
+
+// T returns the message with ID key formatted with args. Messages missing from the
+// language's catalog are taken from the default one, unknown keys are returned as they are.
+func (l Localizer) T(key string, args ...any) string {
+    msg, ok := catalogs[l.Lang()][key]
+    if !ok {
+        if msg, ok = catalogs[Default][key]; !ok {
+            return key
+        }
+    }
+    if len(args) == 0 {
+        return msg
+    }
+    for i, arg := range args {
+        args[i] = l.localize(arg)
+    }
+    return fmt.Sprintf(msg, args...)
+}
+
+// N returns the plural form of the message with ID key that fits n, e.g. key.one or
+// key.many, formatted with n followed by args.
+func (l Localizer) N(key string, n int, args ...any) string {
+    form := key + "." + pluralForm(l.Lang(), n)
+    if _, ok := catalogs[l.Lang()][form]; !ok {
+        form = key + ".other"
+    }
+    return l.T(form, append([]any{n}, args...)...)
+}
+
+// pluralForm names the CLDR plural category of n in lang.
+func pluralForm(lang string, n int) string {
+    if n < 0 {
+        n = -n
+    }
+    switch lang {
+    case "ru":
+        switch {
+        case n%10 == 1 && n%100 != 11:
+            return "one"
+        case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+            return "few"
+        }
+        return "many"
+    case "fa":
+        return "other"
+    }
+    if n == 1 {
+        return "one"
+    }
+    return "other"
+}
+
+// localize formats numbers the way the language writes them.
+func (l Localizer) localize(arg any) any {
+    switch v := arg.(type) {
+    case int:
+        return l.Int(v)
+    case int64:
+        return l.Int(int(v))
+    case float64:
+        return l.Number(v)
+    }
+    return arg
+}
+
+type contextKey struct{}
+
+// WithLocalizer returns a context carrying l, for code that renders texts further down.
+func WithLocalizer(ctx context.Context, l Localizer) context.Context {
+    return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Localizer of ctx, or the default language's.
+func FromContext(ctx context.Context) Localizer {
+    l, _ := ctx.Value(contextKey{}).(Localizer)
+    return l
+}
@@ -0,0 +1,99 @@
+package i18n
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestPluralForm(t *testing.T) {
+    tests := []struct {
+        lang string
+        n    int
+        want string
+    }{
+        {"en", 0, "other"},
+        {"en", 1, "one"},
+        {"en", 2, "other"},
+        {"ru", 1, "one"},
+        {"ru", 21, "one"},
+        {"ru", 101, "one"},
+        {"ru", 2, "few"},
+        {"ru", 4, "few"},
+        {"ru", 22, "few"},
+        {"ru", 0, "many"},
+        {"ru", 5, "many"},
+        {"ru", 11, "many"},
+        {"ru", 12, "many"},
+        {"ru", 14, "many"},
+        {"ru", 111, "many"},
+        {"ru", -3, "few"},
+        {"fa", 0, "other"},
+        {"fa", 1, "other"},
+        {"fa", 2, "other"},
+        {"fa", 5, "other"},
+    }
+    for _, tt := range tests {
+        if got := pluralForm(tt.lang, tt.n); got != tt.want {
+            t.Errorf("pluralForm(%q, %d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+        }
+    }
+}
+
+func TestN(t *testing.T) {
+    tests := []struct {
+        lang string
+        n    int
+        want string
+    }{
+        {"en", 1, "1 more transfer"},
+        {"en", 3, "3 more transfers"},
+        {"ru", 1, "Ещё 1 перевод"},
+        {"ru", 3, "Ещё 3 перевода"},
+        {"ru", 5, "Ещё 5 переводов"},
+        {"fa", 1, "۱ انتقال دیگر"},
+    }
+    for _, tt := range tests {
+        if got := New(tt.lang).N("digest.more", tt.n); got != tt.want {
+            t.Errorf("%s: N(digest.more, %d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+        }
+    }
+}
+
+// pluralForms are the forms pluralForm returns for each language.
+var pluralForms = map[string][]string{
+    "en": {"one", "other"},
+    "fa": {"other"},
+    "ru": {"one", "few", "many"},
+}
+
+func TestCatalogsHaveEveryKey(t *testing.T) {
+    for _, lang := range Languages[1:] {
+        for key := range catalogs[Default] {
+            if base, ok := pluralBase(key); ok {
+                // Plural messages have the forms of their language instead of English's;
+                // N falls back to the other form for those that are missing.
+                for _, form := range pluralForms[lang] {
+                    _, found := catalogs[lang][base+"."+form]
+                    _, other := catalogs[lang][base+".other"]
+                    if !found && !other {
+                        t.Errorf("%s has no %s.%s", lang, base, form)
+                    }
+                }
+                continue
+            }
+            if _, ok := catalogs[lang][key]; !ok {
+                t.Errorf("%s has no %s", lang, key)
+            }
+        }
+    }
+}
+
+// pluralBase returns the key of the plural message key is a form of.
+func pluralBase(key string) (string, bool) {
+    for _, form := range []string{"one", "few", "many", "other"} {
+        if base, ok := strings.CutSuffix(key, "."+form); ok {
+            return base, true
+        }
+    }
+    return "", false
+}
@@ -0,0 +1,195 @@
+package matcher
+
+import (
+    "hash/maphash"
+    "math"
+    "sync"
+
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+const (
+    defaultBloomCapacity = 1024
+    defaultBloomFPRate   = 0.01
+)
+
+// BloomMatcher keeps a blocked bloom filter in front of the exact set. Almost every address
+// seen on chain is not watched, and the filter rejects those after touching a single cache
+// line, which is cheaper than hashing into a large map. The bits answer lookups; a parallel
+// array of counters makes removals possible. A counter that saturates is never decremented
+// again, which can only cost extra false positives, never a missed match.
+type BloomMatcher struct {
+    mu       sync.RWMutex
+    seed     maphash.Seed
+    bits     []uint64
+    counters []uint8 // one per bit
+    blocks   uint64
+    k        uint64
+    capacity int
+    fpRate   float64
+    exact    map[string]struct{}
+}
+
+// Each block is one 64-byte cache line; all k positions of an address fall in one block.
+const (
+    blockWords = 8
+    blockBits  = blockWords * 64
+)
+
+var _ ports.AddressMatcher = (*BloomMatcher)(nil)
+
+// NewBloomMatcher sizes the filter for capacity addresses at the given false positive rate.
+// The filter is rebuilt twice as large whenever the watch list outgrows its capacity.
+func NewBloomMatcher(capacity int, fpRate float64) *BloomMatcher {
+    if capacity <= 0 {
+        capacity = defaultBloomCapacity
+    }
+    if fpRate <= 0 || fpRate >= 1 {
+        fpRate = defaultBloomFPRate
+    }
+    m := &BloomMatcher{
+        seed:   maphash.MakeSeed(),
+        fpRate: fpRate,
+        exact:  make(map[string]struct{}),
+    }
+    m.resize(capacity)
+    return m
+}
+
+func (m *BloomMatcher) Add(address string) {
+    address = lower(address)
+    m.mu.Lock()
+    m.add(address)
+    m.mu.Unlock()
+}
+
+func (m *BloomMatcher) Remove(address string) {
+    address = lower(address)
+    m.mu.Lock()
+    m.remove(address)
+    m.mu.Unlock()
+}
+
+func (m *BloomMatcher) Match(address string) bool {
+    var buf [foldBufLen]byte
+    key := appendLower(buf[:0], address)
+    m.mu.RLock()
+    ok := m.mayContain(maphash.Bytes(m.seed, key))
+    if ok {
+        _, ok = m.exact[string(key)]
+    }
+    m.mu.RUnlock()
+    return ok
+}
+
+func (m *BloomMatcher) Sync(addresses []string) (added int, removed int) {
+    want := toSet(addresses)
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for addr := range m.exact {
+        if _, ok := want[addr]; !ok {
+            m.remove(addr)
+            removed++
+        }
+    }
+    for addr := range want {
+        if m.add(addr) {
+            added++
+        }
+    }
+    return added, removed
+}
+
+func (m *BloomMatcher) Len() int {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return len(m.exact)
+}
+
+func (m *BloomMatcher) add(address string) bool {
+    if _, ok := m.exact[address]; ok {
+        return false
+    }
+    m.exact[address] = struct{}{}
+    if len(m.exact) > m.capacity {
+        m.resize(m.capacity * 2)
+        return true
+    }
+    m.insert(address)
+    return true
+}
+
+func (m *BloomMatcher) remove(address string) {
+    if _, ok := m.exact[address]; !ok {
+        return
+    }
+    delete(m.exact, address)
+    h := maphash.String(m.seed, address)
+    base := m.locate(h)
+    step := h>>32 | 1
+    for i := uint64(0); i < m.k; i, h = i+1, h+step {
+        bit := base + h%blockBits
+        switch c := m.counters[bit]; {
+        case c == 1:
+            m.counters[bit] = 0
+            m.bits[bit/64] &^= 1 << (bit % 64)
+        case c > 1 && c < math.MaxUint8:
+            m.counters[bit]--
+        }
+    }
+}
+
+func (m *BloomMatcher) insert(address string) {
+    h := maphash.String(m.seed, address)
+    base := m.locate(h)
+    step := h>>32 | 1
+    for i := uint64(0); i < m.k; i, h = i+1, h+step {
+        bit := base + h%blockBits
+        if m.counters[bit] < math.MaxUint8 {
+            m.counters[bit]++
+        }
+        m.bits[bit/64] |= 1 << (bit % 64)
+    }
+}
+
+// mayContain reports whether the address with hash h may be in the filter. Hashes of the
+// same address from maphash.String and maphash.Bytes are equal.
+func (m *BloomMatcher) mayContain(h uint64) bool {
+    base := m.locate(h)
+    block := m.bits[base/64 : base/64+blockWords : base/64+blockWords]
+    step := h>>32 | 1
+    for i := uint64(0); i < m.k; i++ {
+        bit := h % blockBits
+        if block[bit/64]&(1<<(bit%64)) == 0 {
+            return false
+        }
+        h += step
+    }
+    return true
+}
+
+// locate returns the first bit of the block of the address with hash h; h also gives the
+// positions in the block.
+func (m *BloomMatcher) locate(h uint64) uint64 {
+    block := (h >> 32) * m.blocks >> 32 // maps the upper hash bits onto [0, blocks) without a division
+    return block * blockBits
+}
+
+// resize rebuilds the filter for the given capacity from the exact set.
+func (m *BloomMatcher) resize(capacity int) {
+    size := -float64(capacity) * math.Log(m.fpRate) / (math.Ln2 * math.Ln2)
+    k := math.Round(size / float64(capacity) * math.Ln2)
+    if k < 1 {
+        k = 1
+    }
+    // Blocking raises the false positive rate a little; one spare bit per address compensates.
+    blocks := uint64(math.Ceil((size + float64(capacity)) / blockBits))
+    m.capacity = capacity
+    m.blocks = blocks
+    m.bits = make([]uint64, blocks*blockWords)
+    m.counters = make([]uint8, blocks*blockBits)
+    m.k = uint64(k)
+    for addr := range m.exact {
+        m.insert(addr)
+    }
+}
@@ -0,0 +1,223 @@
+package matcher
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+func randomAddresses(tb testing.TB, n int) []string {
+    tb.Helper()
+    addrs := make([]string, n)
+    buf := make([]byte, 20)
+    for i := range addrs {
+        if _, err := rand.Read(buf); err != nil {
+            tb.Fatal(err)
+        }
+        addrs[i] = "0x" + hex.EncodeToString(buf)
+    }
+    return addrs
+}
+
+// assertMatches fails for every watched address the matcher misses and every removed one
+// it still matches; the exact set behind the filter rules out false positives entirely.
+func assertMatches(t *testing.T, m ports.AddressMatcher, watched, removed []string) {
+    t.Helper()
+    for _, addr := range watched {
+        if !m.Match(addr) {
+            t.Fatalf("watched address %s not matched", addr)
+        }
+    }
+    for _, addr := range removed {
+        if m.Match(addr) {
+            t.Fatalf("removed address %s still matched", addr)
+        }
+    }
+    if m.Len() != len(watched) {
+        t.Fatalf("Len() = %d, want %d", m.Len(), len(watched))
+    }
+}
+
+func TestBloomMatcherAddRemove(t *testing.T) {
+    m := NewBloomMatcher(1000, 0.01)
+    addrs := randomAddresses(t, 1000)
+    for _, addr := range addrs {
+        m.Add(addr)
+    }
+    assertMatches(t, m, addrs, nil)
+
+    for _, addr := range addrs[:500] {
+        m.Remove(addr)
+    }
+    assertMatches(t, m, addrs[500:], addrs[:500])
+
+    // Removing twice or removing unknown addresses must not clear bits of watched ones.
+    for _, addr := range append(randomAddresses(t, 500), addrs[:500]...) {
+        m.Remove(addr)
+    }
+    assertMatches(t, m, addrs[500:], addrs[:500])
+}
+
+func TestBloomMatcherResize(t *testing.T) {
+    m := NewBloomMatcher(16, 0.01)
+    addrs := randomAddresses(t, 5000)
+    for i, addr := range addrs {
+        m.Add(addr)
+        if i%997 == 0 {
+            assertMatches(t, m, addrs[:i+1], nil)
+        }
+    }
+    assertMatches(t, m, addrs, nil)
+    if m.capacity < len(addrs) {
+        t.Fatalf("capacity %d below %d watched addresses", m.capacity, len(addrs))
+    }
+}
+
+func TestBloomMatcherSync(t *testing.T) {
+    m := NewBloomMatcher(100, 0.01)
+    first := randomAddresses(t, 300)
+    if added, removed := m.Sync(first); added != 300 || removed != 0 {
+        t.Fatalf("Sync() = %d added, %d removed, want 300, 0", added, removed)
+    }
+    assertMatches(t, m, first, nil)
+
+    second := append(randomAddresses(t, 200), first[100:]...)
+    if added, removed := m.Sync(second); added != 200 || removed != 100 {
+        t.Fatalf("Sync() = %d added, %d removed, want 200, 100", added, removed)
+    }
+    assertMatches(t, m, second, first[:100])
+}
+
+// Counters saturate at 255 when many addresses share a bit; the bits must then stay set.
+func TestBloomMatcherSaturatedCounters(t *testing.T) {
+    m := NewBloomMatcher(1, 0.5)
+    addrs := randomAddresses(t, 2000)
+    m.Sync(addrs)
+    m.mu.Lock()
+    m.resize(1) // squeeze every address into a single block
+    m.mu.Unlock()
+    for _, addr := range addrs[:1500] {
+        m.Remove(addr)
+    }
+    assertMatches(t, m, addrs[1500:], addrs[:1500])
+}
+
+func TestMatchersIgnoreCase(t *testing.T) {
+    matchers := map[string]ports.AddressMatcher{
+        "exact": NewSetMatcher(),
+        "bloom": NewBloomMatcher(0, 0),
+    }
+    for name, m := range matchers {
+        t.Run(name, func(t *testing.T) {
+            m.Add("0xABCDEF")
+            if !m.Match("0xabcdef") || !m.Match("0xAbCdEf") {
+                t.Fatal("address added in upper case not matched in lower case")
+            }
+            m.Sync([]string{"0xABCDEF", "0x123ABC"})
+            if !m.Match("0x123abc") || m.Len() != 2 {
+                t.Fatalf("Sync did not normalize addresses, Len() = %d", m.Len())
+            }
+            m.Remove("0xabcdef")
+            if m.Match("0xABCDEF") {
+                t.Fatal("address removed in lower case still matched")
+            }
+        })
+    }
+}
+
+// matchAllocs returns the allocations of matching every address in block, in upper case as
+// well, since addresses arrive checksummed.
+func matchAllocs(m ports.AddressMatcher, block []string) float64 {
+    upper := make([]string, len(block))
+    for i, addr := range block {
+        upper[i] = strings.ToUpper(addr)
+    }
+    return testing.AllocsPerRun(10, func() {
+        for i := range block {
+            m.Match(block[i])
+            m.Match(upper[i])
+        }
+    })
+}
+
+func TestMatchDoesNotAllocate(t *testing.T) {
+    for _, bm := range benchMatchers {
+        watched := randomAddresses(t, 100)
+        m := bm.make(len(watched))
+        m.Sync(watched)
+        block := append(randomAddresses(t, 100), watched[:10]...)
+        if allocs := matchAllocs(m, block); allocs > 0 {
+            t.Errorf("%s: Match allocates %.1f times per block", bm.name, allocs)
+        }
+    }
+}
+
+// Block-sized workloads: an Ethereum block checks the sender and recipient of every
+// transaction, a Bitcoin block checks every output.
+var workloads = []struct {
+    name    string
+    lookups int     // address lookups per block
+    hitRate float64 // share of lookups that hit a watched address
+}{
+    {name: "ethereum", lookups: 400, hitRate: 0.01},
+    {name: "bitcoin", lookups: 7500, hitRate: 0.001},
+}
+
+var benchMatchers = []struct {
+    name string
+    make func(capacity int) ports.AddressMatcher
+}{
+    {"exact", func(int) ports.AddressMatcher { return NewSetMatcher() }},
+    {"bloom", func(capacity int) ports.AddressMatcher { return NewBloomMatcher(capacity, 0.01) }},
+}
+
+var benchSizes = []int{1000, 10000, 100000}
+
+// BenchmarkMatchBlock reports the time to check one block against watch lists of several
+// sizes, e.g. go test ./internal/infra/matcher -bench MatchBlock.
+func BenchmarkMatchBlock(b *testing.B) {
+    for _, size := range benchSizes {
+        watched := randomAddresses(b, size)
+        for _, wl := range workloads {
+            hits := int(float64(wl.lookups) * wl.hitRate)
+            block := append(randomAddresses(b, wl.lookups-hits), watched[:hits]...)
+            for _, bm := range benchMatchers {
+                m := bm.make(size)
+                m.Sync(watched)
+                b.Run(strings.Join([]string{bm.name, wl.name, strconv.Itoa(size)}, "/"), func(b *testing.B) {
+                    if allocs := matchAllocs(m, block); allocs > 0 {
+                        b.Fatalf("Match allocates %.1f times per block", allocs)
+                    }
+                    b.ReportAllocs()
+                    b.ResetTimer()
+                    for i := 0; i < b.N; i++ {
+                        for _, addr := range block {
+                            m.Match(addr)
+                        }
+                    }
+                    b.ReportMetric(float64(wl.lookups)*float64(b.N)/b.Elapsed().Seconds(), "lookups/s")
+                })
+            }
+        }
+    }
+}
+
+func BenchmarkAddRemove(b *testing.B) {
+    for _, size := range benchSizes {
+        watched := randomAddresses(b, size)
+        for _, bm := range benchMatchers {
+            m := bm.make(size)
+            b.Run(bm.name+"/"+strconv.Itoa(size), func(b *testing.B) {
+                for i := 0; i < b.N; i++ {
+                    addr := watched[i%len(watched)]
+                    m.Add(addr)
+                    m.Remove(addr)
+                }
+            })
+        }
+    }
+}
@@ -0,0 +1,105 @@
+package matcher
+
+import (
+    "sync"
+
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// SetMatcher is an exact, map-backed watch list. It is the simplest matcher and a good fit
+// for small watch lists.
+type SetMatcher struct {
+    mu    sync.RWMutex
+    items map[string]struct{}
+}
+
+var _ ports.AddressMatcher = (*SetMatcher)(nil)
+
+func NewSetMatcher() *SetMatcher {
+    return &SetMatcher{items: make(map[string]struct{})}
+}
+
+func (m *SetMatcher) Add(address string) {
+    address = lower(address)
+    m.mu.Lock()
+    m.items[address] = struct{}{}
+    m.mu.Unlock()
+}
+
+func (m *SetMatcher) Remove(address string) {
+    address = lower(address)
+    m.mu.Lock()
+    delete(m.items, address)
+    m.mu.Unlock()
+}
+
+func (m *SetMatcher) Match(address string) bool {
+    var buf [foldBufLen]byte
+    key := appendLower(buf[:0], address)
+    m.mu.RLock()
+    _, ok := m.items[string(key)]
+    m.mu.RUnlock()
+    return ok
+}
+
+// Sync applies only the differences, so concurrent lookups never see a half-built set.
+func (m *SetMatcher) Sync(addresses []string) (added int, removed int) {
+    want := toSet(addresses)
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for addr := range m.items {
+        if _, ok := want[addr]; !ok {
+            delete(m.items, addr)
+            removed++
+        }
+    }
+    for addr := range want {
+        if _, ok := m.items[addr]; !ok {
+            m.items[addr] = struct{}{}
+            added++
+        }
+    }
+    return added, removed
+}
+
+func (m *SetMatcher) Len() int {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return len(m.items)
+}
+
+func toSet(addresses []string) map[string]struct{} {
+    set := make(map[string]struct{}, len(addresses))
+    for _, addr := range addresses {
+        set[lower(addr)] = struct{}{}
+    }
+    return set
+}
+
+// foldBufLen is the length of the addresses Match folds on the stack; longer ones make it
+// allocate. Bech32 addresses, the longest watched, have at most 90 characters.
+const foldBufLen = 128
+
+// lower folds the ASCII letters of address to lower case, allocating only when it has upper
+// case ones. Addresses are ASCII, so unlike strings.ToLower it leaves other bytes alone.
+func lower(address string) string {
+    for i := 0; i < len(address); i++ {
+        if c := address[i]; 'A' <= c && c <= 'Z' {
+            return string(appendLower(make([]byte, 0, len(address)), address))
+        }
+    }
+    return address
+}
+
+// appendLower appends address to buf with its ASCII letters folded to lower case. Lookups
+// fold into a buffer on the stack and index maps with string(buf), which does not allocate.
+func appendLower(buf []byte, address string) []byte {
+    for i := 0; i < len(address); i++ {
+        c := address[i]
+        if 'A' <= c && c <= 'Z' {
+            c += 'a' - 'A'
+        }
+        buf = append(buf, c)
+    }
+    return buf
+}
@@ -0,0 +1,108 @@
+// Package netguard keeps outgoing requests to user supplied URLs, such as webhooks, away
+// from the service's own network: loopback, private, link-local and cloud metadata addresses.
+package netguard
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "net/netip"
+    "syscall"
+    "time"
+)
+
+// ErrBlockedAddress is returned for hosts that resolve to an address that is not public.
+var ErrBlockedAddress = errors.New("address is not public")
+
+// blocked are the ranges besides loopback, private, link-local, multicast and unspecified
+// addresses that are not reachable on the internet or serve cloud metadata.
+var blocked = []netip.Prefix{
+    netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+    netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT, Alibaba Cloud metadata
+    netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+    netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+    netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+    netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+    netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+var (
+    nat64     = netip.MustParsePrefix("64:ff9b::/96")
+    sixToFour = netip.MustParsePrefix("2002::/16")
+)
+
+// IsPublic reports whether addr is a unicast address on the internet. Cloud metadata
+// endpoints (169.254.169.254, fd00:ec2::254, 100.100.100.200) are link-local, unique-local
+// or shared address space and so not public.
+func IsPublic(addr netip.Addr) bool {
+    addr = addr.Unmap()
+    if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+        addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+        return false
+    }
+    for _, p := range blocked {
+        if p.Contains(addr) {
+            return false
+        }
+    }
+    // NAT64 and 6to4 addresses reach the IPv4 address embedded in them.
+    b := addr.As16()
+    switch {
+    case nat64.Contains(addr):
+        return IsPublic(netip.AddrFrom4([4]byte(b[12:16])))
+    case sixToFour.Contains(addr):
+        return IsPublic(netip.AddrFrom4([4]byte(b[2:6])))
+    }
+    return true
+}
+
+// CheckHost resolves host and returns an error wrapping ErrBlockedAddress if any of its
+// addresses is not public.
+func CheckHost(ctx context.Context, host string) error {
+    if addr, err := netip.ParseAddr(host); err == nil {
+        if !IsPublic(addr) {
+            return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+        }
+        return nil
+    }
+    addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+    if err != nil {
+        return fmt.Errorf("cannot resolve %s: %w", host, err)
+    }
+    for _, addr := range addrs {
+        if !IsPublic(addr) {
+            return fmt.Errorf("%w: %s resolves to %s", ErrBlockedAddress, host, addr.Unmap())
+        }
+    }
+    return nil
+}
+
+// Control refuses connections to addresses that are not public. Used as the Control of a
+// net.Dialer it runs after DNS resolution, for every address tried, so a host cannot pass a
+// check with a public address and then be dialed on a private one.
+func Control(network, address string, _ syscall.RawConn) error {
+    addrPort, err := netip.ParseAddrPort(address)
+    if err != nil {
+        return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+    }
+    if !IsPublic(addrPort.Addr()) {
+        return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr().Unmap())
+    }
+    return nil
+}
+
+// NewTransport returns an HTTP transport that only connects to public addresses. It ignores
+// proxy settings, since a proxy would make the connection on its behalf.
+func NewTransport() *http.Transport {
+    dialer := &net.Dialer{
+        Timeout:   30 * time.Second,
+        KeepAlive: 30 * time.Second,
+        Control:   Control,
+    }
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.Proxy = nil
+    transport.DialContext = dialer.DialContext
+    return transport
+}
@@ -0,0 +1,73 @@
+package netguard
+
+import (
+    "context"
+    "errors"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/netip"
+    "testing"
+)
+
+func TestIsPublic(t *testing.T) {
+    tests := []struct {
+        addr string
+        want bool
+    }{
+        {"93.184.216.34", true},
+        {"8.8.8.8", true},
+        {"2606:4700:4700::1111", true},
+        {"127.0.0.1", false},
+        {"127.1.2.3", false},
+        {"::1", false},
+        {"10.1.2.3", false},
+        {"172.16.0.1", false},
+        {"192.168.1.1", false},
+        {"169.254.169.254", false},
+        {"100.100.100.200", false},
+        {"fd00:ec2::254", false},
+        {"fe80::1", false},
+        {"0.0.0.0", false},
+        {"::", false},
+        {"224.0.0.1", false},
+        {"255.255.255.255", false},
+        {"::ffff:127.0.0.1", false},
+        {"::ffff:8.8.8.8", true},
+        {"64:ff9b::a00:1", false},
+        {"64:ff9b::808:808", true},
+        {"2002:c0a8:101::1", false},
+    }
+    for _, tt := range tests {
+        if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+            t.Errorf("IsPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+        }
+    }
+}
+
+func TestCheckHost(t *testing.T) {
+    ctx := context.Background()
+    for _, host := range []string{"127.0.0.1", "169.254.169.254", "::1", "localhost"} {
+        if err := CheckHost(ctx, host); !errors.Is(err, ErrBlockedAddress) {
+            t.Errorf("CheckHost(%s) = %v, want ErrBlockedAddress", host, err)
+        }
+    }
+    if err := CheckHost(ctx, "93.184.216.34"); err != nil {
+        t.Errorf("CheckHost(public address) = %v", err)
+    }
+}
+
+// The transport refuses to connect to a local server, whatever the URL's host says.
+func TestTransportRefusesLocalAddresses(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    defer server.Close()
+
+    client := &http.Client{Transport: NewTransport()}
+    _, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+    for _, url := range []string{server.URL, "http://localhost:" + port} {
+        _, err := client.Get(url)
+        if !errors.Is(err, ErrBlockedAddress) {
+            t.Errorf("GET %s = %v, want ErrBlockedAddress", url, err)
+        }
+    }
+}
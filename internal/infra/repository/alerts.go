@@ -0,0 +1,136 @@
+package repository
+
+import (
+    "context"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Alerts
+type MongoAlertRepository struct{}
+
+func NewMongoAlertRepository(uri string, dbName string) (ports.AlertRepository, error) {
+    if err := initMongoDB(uri, dbName); err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := mongoDB.Collection("alerts").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "userId", Value: 1}},
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &MongoAlertRepository{}, nil
+}
+
+func (r *MongoAlertRepository) ListByUser(ctx context.Context, userID string) ([]domain.Alert, error) {
+    opts := options.Find().SetSort(bson.M{"createdAt": 1})
+    cursor, err := mongoDB.Collection("alerts").Find(ctx, bson.M{"userId": userID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var alerts []domain.Alert
+    if err = cursor.All(ctx, &alerts); err != nil {
+        return nil, err
+    }
+    return alerts, nil
+}
+
+func (r *MongoAlertRepository) Get(ctx context.Context, id string) (domain.Alert, error) {
+    var alert domain.Alert
+    err := mongoDB.Collection("alerts").FindOne(ctx, bson.M{"_id": id}).Decode(&alert)
+    if err == mongo.ErrNoDocuments {
+        return domain.Alert{}, domain.ErrAlertNotFound
+    }
+    return alert, err
+}
+
+func (r *MongoAlertRepository) Create(ctx context.Context, alert domain.Alert) (domain.Alert, error) {
+    if alert.ID == "" {
+        alert.ID = primitive.NewObjectID().Hex()
+    }
+    if alert.CreatedAt.IsZero() {
+        alert.CreatedAt = time.Now()
+    }
+    _, err := mongoDB.Collection("alerts").InsertOne(ctx, alert)
+    return alert, err
+}
+
+func (r *MongoAlertRepository) Delete(ctx context.Context, userID string, id string) error {
+    res, err := mongoDB.Collection("alerts").DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+    if err != nil {
+        return err
+    }
+    if res.DeletedCount == 0 {
+        return domain.ErrAlertNotFound
+    }
+    return nil
+}
+
+func (r *MongoAlertRepository) SetDisabled(ctx context.Context, userID string, id string, disabled bool) error {
+    update := bson.M{"$set": bson.M{"disabled": disabled}}
+    if !disabled {
+        update = bson.M{"$set": bson.M{"disabled": false, "failures": 0}, "$unset": bson.M{"lastError": ""}}
+    }
+    res, err := mongoDB.Collection("alerts").UpdateOne(ctx, bson.M{"_id": id, "userId": userID}, update)
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return domain.ErrAlertNotFound
+    }
+    return nil
+}
+
+func (r *MongoAlertRepository) Confirm(ctx context.Context, id string, code string) error {
+    res, err := mongoDB.Collection("alerts").UpdateOne(ctx,
+        bson.M{"_id": id, "unconfirmed": true, "confirmCode": code},
+        bson.M{"$set": bson.M{"disabled": false}, "$unset": bson.M{"unconfirmed": "", "confirmCode": ""}},
+    )
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return domain.ErrAlertNotFound
+    }
+    return nil
+}
+
+func (r *MongoAlertRepository) RecordFailure(ctx context.Context, id string, lastErr string, maxFailures int) (bool, error) {
+    var alert domain.Alert
+    err := mongoDB.Collection("alerts").FindOneAndUpdate(ctx,
+        bson.M{"_id": id},
+        bson.M{"$inc": bson.M{"failures": 1}, "$set": bson.M{"lastError": lastErr}},
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&alert)
+    if err == mongo.ErrNoDocuments {
+        return false, domain.ErrAlertNotFound
+    }
+    if err != nil {
+        return false, err
+    }
+    if alert.Disabled || maxFailures <= 0 || alert.Failures < maxFailures {
+        return alert.Disabled, nil
+    }
+    _, err = mongoDB.Collection("alerts").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"disabled": true}})
+    return err == nil, err
+}
+
+func (r *MongoAlertRepository) RecordSuccess(ctx context.Context, id string) error {
+    // Only touch alerts that were failing, so a healthy alert costs no write.
+    _, err := mongoDB.Collection("alerts").UpdateOne(ctx,
+        bson.M{"_id": id, "failures": bson.M{"$gt": 0}},
+        bson.M{"$set": bson.M{"failures": 0}, "$unset": bson.M{"lastError": ""}},
+    )
+    return err
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+    "context"
+    "log"
+    "sync"
+
+    "go.mongodb.org/mongo-driver/bson"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// watchlistChanges carries the writes of both subscriptions and wallets, which together make
+// up the addresses the watchers follow, see MongoSubscriptionRepository.GetUniqueAddresses.
+var watchlistChanges = newChangeFeed()
+
+// publishWatchlistChange announces a write together with the number of subscriptions and
+// wallets left on the address.
+func publishWatchlistChange(ctx context.Context, change domain.SubscriptionChange) {
+    filter := bson.M{"blockchain": change.Subscription.Blockchain, "address": change.Subscription.Address}
+    for _, name := range []string{"subscriptions", "wallets"} {
+        n, err := mongoDB.Collection(name).CountDocuments(ctx, filter)
+        if err != nil {
+            log.Printf("failed to count %s for %s %s: %v", name, change.Subscription.Blockchain, change.Subscription.Address, err)
+            return
+        }
+        change.Remaining += n
+    }
+    watchlistChanges.publish(change)
+}
+
+// changeFeed fans subscription changes out to in-process listeners.
+type changeFeed struct {
+    mu          sync.RWMutex
+    subscribers map[chan domain.SubscriptionChange]struct{}
+}
+
+func newChangeFeed() *changeFeed {
+    return &changeFeed{subscribers: make(map[chan domain.SubscriptionChange]struct{})}
+}
+
+func (f *changeFeed) publish(change domain.SubscriptionChange) {
+    f.mu.RLock()
+    defer f.mu.RUnlock()
+    for ch := range f.subscribers {
+        select {
+        case ch <- change:
+        default:
+            log.Printf("subscription change listener is full, dropping %s change for %s", change.Type, change.Subscription.Address)
+        }
+    }
+}
+
+func (f *changeFeed) subscribe() (<-chan domain.SubscriptionChange, func()) {
+    ch := make(chan domain.SubscriptionChange, 64)
+    f.mu.Lock()
+    f.subscribers[ch] = struct{}{}
+    f.mu.Unlock()
+    var once sync.Once
+    unsubscribe := func() {
+        once.Do(func() {
+            f.mu.Lock()
+            delete(f.subscribers, ch)
+            close(ch)
+            f.mu.Unlock()
+        })
+    }
+    return ch, unsubscribe
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+    "context"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Chat rules
+type MongoChatRuleRepository struct{}
+
+func NewMongoChatRuleRepository(uri string, dbName string) (ports.ChatRuleRepository, error) {
+    if err := initMongoDB(uri, dbName); err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := mongoDB.Collection("chat_rules").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "chatId", Value: 1}},
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &MongoChatRuleRepository{}, nil
+}
+
+func (r *MongoChatRuleRepository) Create(ctx context.Context, rule domain.ChatRule) (domain.ChatRule, error) {
+    if rule.ID == "" {
+        rule.ID = primitive.NewObjectID().Hex()
+    }
+    if rule.CreatedAt.IsZero() {
+        rule.CreatedAt = time.Now()
+    }
+    _, err := mongoDB.Collection("chat_rules").InsertOne(ctx, rule)
+    return rule, err
+}
+
+func (r *MongoChatRuleRepository) ListByChat(ctx context.Context, chatID string) ([]domain.ChatRule, error) {
+    opts := options.Find().SetSort(bson.M{"createdAt": 1})
+    cursor, err := mongoDB.Collection("chat_rules").Find(ctx, bson.M{"chatId": chatID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var rules []domain.ChatRule
+    if err = cursor.All(ctx, &rules); err != nil {
+        return nil, err
+    }
+    return rules, nil
+}
+
+func (r *MongoChatRuleRepository) Delete(ctx context.Context, chatID string, id string) error {
+    res, err := mongoDB.Collection("chat_rules").DeleteOne(ctx, bson.M{"_id": id, "chatId": chatID})
+    if err != nil {
+        return err
+    }
+    if res.DeletedCount == 0 {
+        return domain.ErrRuleNotFound
+    }
+    return nil
+}
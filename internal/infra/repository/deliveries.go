@@ -0,0 +1,153 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Deliveries
+type MongoDeliveryQueue struct{}
+
+func NewMongoDeliveryQueue(uri string, dbName string) (ports.DeliveryQueue, error) {
+    if err := initMongoDB(uri, dbName); err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := mongoDB.Collection("deliveries").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "nextAttemptAt", Value: 1}},
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &MongoDeliveryQueue{}, nil
+}
+
+func (q *MongoDeliveryQueue) Enqueue(ctx context.Context, d domain.Delivery) error {
+    now := time.Now()
+    if d.CreatedAt.IsZero() {
+        d.CreatedAt = now
+    }
+    if d.NextAttemptAt.IsZero() {
+        d.NextAttemptAt = now
+    }
+    _, err := mongoDB.Collection("deliveries").InsertOne(ctx, d)
+    if mongo.IsDuplicateKeyError(err) {
+        return nil
+    }
+    return err
+}
+
+func (q *MongoDeliveryQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.Delivery, error) {
+    collection := mongoDB.Collection("deliveries")
+    now := time.Now()
+    filter := bson.M{
+        "nextAttemptAt": bson.M{"$lte": now},
+        "leaseUntil":    bson.M{"$lte": now},
+    }
+    update := bson.M{"$set": bson.M{"leaseUntil": now.Add(lease)}}
+    opts := options.FindOneAndUpdate().
+        SetSort(bson.M{"nextAttemptAt": 1}).
+        SetReturnDocument(options.After)
+
+    var claimed []domain.Delivery
+    for len(claimed) < limit {
+        var d domain.Delivery
+        err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&d)
+        if errors.Is(err, mongo.ErrNoDocuments) {
+            break
+        }
+        if err != nil {
+            return claimed, err
+        }
+        claimed = append(claimed, d)
+    }
+    return claimed, nil
+}
+
+func (q *MongoDeliveryQueue) Complete(ctx context.Context, id string) error {
+    _, err := mongoDB.Collection("deliveries").DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
+
+func (q *MongoDeliveryQueue) UpdateEvent(ctx context.Context, id string, evt domain.TransactionEvent) (bool, error) {
+    res, err := mongoDB.Collection("deliveries").UpdateOne(ctx,
+        bson.M{"_id": id, "leaseUntil": bson.M{"$lte": time.Now()}},
+        bson.M{"$set": bson.M{"event": evt}},
+    )
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+func (q *MongoDeliveryQueue) Retry(ctx context.Context, id string, next time.Time, lastErr string) error {
+    _, err := mongoDB.Collection("deliveries").UpdateOne(ctx,
+        bson.M{"_id": id},
+        bson.M{
+            "$set": bson.M{"nextAttemptAt": next, "leaseUntil": time.Time{}, "lastError": lastErr},
+            "$inc": bson.M{"attempts": 1},
+        },
+    )
+    return err
+}
+
+func (q *MongoDeliveryQueue) DeadLetter(ctx context.Context, d domain.Delivery, reason string) error {
+    dl := domain.DeadLetter{Delivery: d, Reason: reason, FailedAt: time.Now()}
+    dl.LeaseUntil = time.Time{}
+    _, err := mongoDB.Collection("dead_letters").ReplaceOne(ctx,
+        bson.M{"_id": d.ID}, dl, options.Replace().SetUpsert(true))
+    if err != nil {
+        return err
+    }
+    return q.Complete(ctx, d.ID)
+}
+
+func (q *MongoDeliveryQueue) ListDeadLetters(ctx context.Context, limit int) ([]domain.DeadLetter, error) {
+    opts := options.Find().SetSort(bson.M{"failedAt": -1})
+    if limit > 0 {
+        opts.SetLimit(int64(limit))
+    }
+    cursor, err := mongoDB.Collection("dead_letters").Find(ctx, bson.M{}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var letters []domain.DeadLetter
+    if err = cursor.All(ctx, &letters); err != nil {
+        return nil, err
+    }
+    return letters, nil
+}
+
+func (q *MongoDeliveryQueue) ReplayDeadLetter(ctx context.Context, id string) error {
+    collection := mongoDB.Collection("dead_letters")
+    var dl domain.DeadLetter
+    err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&dl)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return domain.ErrDeadLetterNotFound
+    }
+    if err != nil {
+        return err
+    }
+
+    // Queue first so a failure in between leaves the dead letter in place rather than losing it.
+    d := dl.Delivery
+    d.Attempts = 0
+    d.NextAttemptAt = time.Now()
+    d.LeaseUntil = time.Time{}
+    if err := q.Enqueue(ctx, d); err != nil {
+        return err
+    }
+    _, err = collection.DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Digests
+type MongoDigestRepository struct{}
+
+func NewMongoDigestRepository(uri string, dbName string) (ports.DigestRepository, error) {
+    if err := initMongoDB(uri, dbName); err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := mongoDB.Collection("digest_entries").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "dueAt", Value: 1}},
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &MongoDigestRepository{}, nil
+}
+
+func (r *MongoDigestRepository) Add(ctx context.Context, entry domain.DigestEntry) error {
+    if entry.CreatedAt.IsZero() {
+        entry.CreatedAt = time.Now()
+    }
+    _, err := mongoDB.Collection("digest_entries").InsertOne(ctx, entry)
+    if mongo.IsDuplicateKeyError(err) {
+        return nil
+    }
+    return err
+}
+
+// Claim leases one due entry at a time with findOneAndUpdate, so concurrent schedulers never
+// get the same one, and then the unleased rest of its digest under the same claim.
+func (r *MongoDigestRepository) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.DigestEntry, error) {
+    collection := mongoDB.Collection("digest_entries")
+    claim := primitive.NewObjectID().Hex()
+    // Entries stored before leases existed have no leaseUntil, which $not matches too.
+    unleased := bson.M{"$not": bson.M{"$gt": now}}
+    update := bson.M{"$set": bson.M{"leaseUntil": now.Add(lease), "claim": claim}}
+    opts := options.FindOneAndUpdate().
+        SetSort(bson.D{{Key: "dueAt", Value: 1}, {Key: "createdAt", Value: 1}}).
+        SetReturnDocument(options.After)
+
+    for claimed := 0; claimed < limit; claimed++ {
+        var first domain.DigestEntry
+        err := collection.FindOneAndUpdate(ctx, bson.M{"dueAt": bson.M{"$lte": now}, "leaseUntil": unleased}, update, opts).Decode(&first)
+        if errors.Is(err, mongo.ErrNoDocuments) {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+        digest := bson.M{
+            "chatId":     first.ChatID,
+            "mode":       first.Mode,
+            "dueAt":      first.DueAt,
+            "leaseUntil": unleased,
+        }
+        if to := first.To(); to.Channel == domain.ChannelTelegram && to.ID == first.ChatID {
+            // The chat's own digest; entries from before entries named their recipient have none.
+            digest["recipient.id"] = bson.M{"$in": bson.A{nil, "", first.ChatID}}
+        } else {
+            digest["recipient.channel"] = to.Channel
+            digest["recipient.id"] = to.ID
+        }
+        _, err = collection.UpdateMany(ctx, digest, update)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    cursor, err := collection.Find(ctx, bson.M{"claim": claim}, options.Find().SetSort(bson.D{{Key: "dueAt", Value: 1}, {Key: "createdAt", Value: 1}}))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var entries []domain.DigestEntry
+    if err = cursor.All(ctx, &entries); err != nil {
+        return nil, err
+    }
+    return entries, nil
+}
+
+func (r *MongoDigestRepository) Delete(ctx context.Context, ids []string) error {
+    if len(ids) == 0 {
+        return nil
+    }
+    _, err := mongoDB.Collection("digest_entries").DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+    return err
+}
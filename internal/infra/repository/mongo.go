@@ -46,29 +46,6 @@ func initMongoDB(uri string, dbName string) error {
     return nil
 }
 
-type MongoWalletRepository struct{}
-
-func NewMongoWalletRepository(uri string, dbName string) (ports.WalletRepository, error) {
-    if err := initMongoDB(uri, dbName); err != nil {
-        return nil, err
-    }
-    return &MongoWalletRepository{}, nil
-}
-
-func (r *MongoWalletRepository) Create(ctx context.Context, wallet domain.Wallet) error {
-    _ = ctx
-    _ = wallet
-    return errors.New("not implemented")
-}
-
-func (r *MongoWalletRepository) ListByUser(ctx context.Context, userID string) ([]domain.Wallet, error) {
-    _ = ctx
-    _ = userID
-    return []domain.Wallet{
-        {ID: "w-demo", UserID: "u-demo", Blockchain: "ethereum", Address: "0x0000000000000000000000000000000000000000", CreatedAt: time.Now()},
-    }, nil
-}
-
 // Sessions
 type MongoSessionRepository struct{}
 
@@ -120,14 +97,69 @@ func (r *MongoSessionRepository) GetTelegramSession(ctx context.Context, chatID
     return session, err
 }
 
+func (r *MongoSessionRepository) UpdateLanguage(ctx context.Context, chatID string, language string, languageCode string) error {
+    collection := mongoDB.Collection("telegram_sessions")
+
+    filter := bson.M{"chatId": chatID}
+    update := bson.M{
+        "$set": bson.M{
+            "language":     language,
+            "languageCode": languageCode,
+            "updatedAt":    time.Now(),
+        },
+        "$setOnInsert": bson.M{
+            "chatId":    chatID,
+            "state":     domain.StateIdle,
+            "createdAt": time.Now(),
+        },
+    }
+
+    opts := options.Update().SetUpsert(true)
+    _, err := collection.UpdateOne(ctx, filter, update, opts)
+    return err
+}
+
+func (r *MongoSessionRepository) UpdateChatSettings(ctx context.Context, chatID string, settings domain.ChatSettings) error {
+    collection := mongoDB.Collection("telegram_sessions")
+    
+    filter := bson.M{"chatId": chatID}
+    update := bson.M{
+        "$set": bson.M{
+            "settings":  settings,
+            "updatedAt": time.Now(),
+        },
+        "$setOnInsert": bson.M{
+            "chatId":    chatID,
+            "state":     domain.StateIdle,
+            "createdAt": time.Now(),
+        },
+    }
+    
+    opts := options.Update().SetUpsert(true)
+    _, err := collection.UpdateOne(ctx, filter, update, opts)
+    return err
+}
+
 // Subscriptions
-type MongoSubscriptionRepository struct{}
+type MongoSubscriptionRepository struct {
+    changes *changeFeed
+}
 
 func NewMongoSubscriptionRepository(uri string, dbName string) (ports.SubscriptionRepository, error) {
     if err := initMongoDB(uri, dbName); err != nil {
         return nil, err
     }
-    return &MongoSubscriptionRepository{}, nil
+    return &MongoSubscriptionRepository{changes: watchlistChanges}, nil
+}
+
+func (r *MongoSubscriptionRepository) SubscribeChanges() (<-chan domain.SubscriptionChange, func()) {
+    return r.changes.subscribe()
+}
+
+// publishChange announces a write together with the number of subscriptions and wallets left
+// on the address.
+func (r *MongoSubscriptionRepository) publishChange(ctx context.Context, changeType domain.SubscriptionChangeType, sub domain.Subscription) {
+    publishWatchlistChange(ctx, domain.SubscriptionChange{Type: changeType, Subscription: sub})
 }
 
 func (r *MongoSubscriptionRepository) AddSubscription(ctx context.Context, sub domain.Subscription) error {
@@ -152,8 +184,11 @@ func (r *MongoSubscriptionRepository) AddSubscription(ctx context.Context, sub d
     }
     
     // Insert new subscription
-    _, err = collection.InsertOne(ctx, sub)
-    return err
+    if _, err = collection.InsertOne(ctx, sub); err != nil {
+        return err
+    }
+    r.publishChange(ctx, domain.SubscriptionAdded, sub)
+    return nil
 }
 
 func (r *MongoSubscriptionRepository) RemoveSubscription(ctx context.Context, chatID string, blockchain string, address string) error {
@@ -165,7 +200,125 @@ func (r *MongoSubscriptionRepository) RemoveSubscription(ctx context.Context, ch
         "address":    address,
     }
     
-    _, err := collection.DeleteOne(ctx, filter)
+    res, err := collection.DeleteOne(ctx, filter)
+    if err != nil {
+        return err
+    }
+    if res.DeletedCount > 0 {
+        r.publishChange(ctx, domain.SubscriptionRemoved, domain.Subscription{
+            ChatID:     chatID,
+            Blockchain: blockchain,
+            Address:    address,
+        })
+    }
+    return nil
+}
+
+func (r *MongoSubscriptionRepository) UpdateSubscriptionRules(ctx context.Context, chatID string, blockchain string, address string, rules domain.SubscriptionRules) error {
+    collection := mongoDB.Collection("subscriptions")
+    
+    filter := bson.M{
+        "chatId":     chatID,
+        "blockchain": blockchain,
+        "address":    address,
+    }
+    
+    update := bson.M{"$set": bson.M{"rules": rules}}
+    if rules.IsZero() {
+        update = bson.M{"$unset": bson.M{"rules": ""}}
+    }
+    res, err := collection.UpdateOne(ctx, filter, update)
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return mongo.ErrNoDocuments
+    }
+    return nil
+}
+
+func (r *MongoSubscriptionRepository) SetSubscriptionMode(ctx context.Context, chatID string, blockchain string, address string, mode domain.DeliveryMode) error {
+    collection := mongoDB.Collection("subscriptions")
+    
+    filter := bson.M{
+        "chatId":     chatID,
+        "blockchain": blockchain,
+        "address":    address,
+    }
+    
+    update := bson.M{"$set": bson.M{"mode": mode}}
+    if mode == "" || mode == domain.DeliveryInstant {
+        update = bson.M{"$unset": bson.M{"mode": ""}}
+    }
+    res, err := collection.UpdateOne(ctx, filter, update)
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return mongo.ErrNoDocuments
+    }
+    return nil
+}
+
+func (r *MongoSubscriptionRepository) MuteSubscription(ctx context.Context, chatID string, blockchain string, address string, until time.Time) error {
+    collection := mongoDB.Collection("subscriptions")
+    
+    filter := bson.M{
+        "chatId":     chatID,
+        "blockchain": blockchain,
+        "address":    address,
+    }
+    
+    update := bson.M{"$set": bson.M{"mutedUntil": until}}
+    if until.IsZero() {
+        update = bson.M{"$unset": bson.M{"mutedUntil": ""}}
+    }
+    res, err := collection.UpdateOne(ctx, filter, update)
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return mongo.ErrNoDocuments
+    }
+    return nil
+}
+
+func (r *MongoSubscriptionRepository) SetSubscriptionChannels(ctx context.Context, chatID string, blockchain string, address string, channels []string) error {
+    collection := mongoDB.Collection("subscriptions")
+    
+    filter := bson.M{
+        "chatId":     chatID,
+        "blockchain": blockchain,
+        "address":    address,
+    }
+    
+    update := bson.M{"$set": bson.M{"channels": channels}}
+    if len(channels) == 0 {
+        update = bson.M{"$unset": bson.M{"channels": ""}}
+    }
+    res, err := collection.UpdateOne(ctx, filter, update)
+    if err != nil {
+        return err
+    }
+    if res.MatchedCount == 0 {
+        return domain.ErrSubscriptionNotFound
+    }
+    return nil
+}
+
+func (r *MongoSubscriptionRepository) RemoveSubscriptionChannel(ctx context.Context, chatID string, channel string) error {
+    collection := mongoDB.Collection("subscriptions")
+    
+    // No channels would route a subscription everywhere, so one that only went to the
+    // deleted channel goes to the chat itself instead.
+    _, err := collection.UpdateMany(ctx,
+        bson.M{"chatId": chatID, "channels": bson.A{channel}},
+        bson.M{"$set": bson.M{"channels": bson.A{domain.ChannelTelegram}}},
+    )
+    if err != nil {
+        return err
+    }
+    _, err = collection.UpdateMany(ctx, bson.M{"chatId": chatID, "channels": channel}, bson.M{"$pull": bson.M{"channels": channel}})
     return err
 }
 
@@ -213,6 +366,8 @@ func (r *MongoSubscriptionRepository) ListSubscribersByAddress(ctx context.Conte
     return subscriptions, nil
 }
 
+// GetUniqueAddresses returns the addresses on blockchain that chats subscribed to or users
+// added as wallets.
 func (r *MongoSubscriptionRepository) GetUniqueAddresses(ctx context.Context, blockchain string) ([]string, error) {
     collection := mongoDB.Collection("subscriptions")
     
@@ -223,6 +378,10 @@ func (r *MongoSubscriptionRepository) GetUniqueAddresses(ctx context.Context, bl
     // Use aggregation to get unique addresses
     pipeline := []bson.M{
         {"$match": filter},
+        {"$unionWith": bson.M{
+            "coll":     "wallets",
+            "pipeline": []bson.M{{"$match": filter}},
+        }},
         {"$group": bson.M{
             "_id": "$address",
         }},
@@ -261,9 +420,27 @@ func NewMongoNotificationRepository(uri string, dbName string) (ports.Notificati
     if err := initMongoDB(uri, dbName); err != nil {
         return nil, err
     }
+    if err := ensureNotificationIndexes(); err != nil {
+        return nil, err
+    }
     return &MongoNotificationRepository{}, nil
 }
 
+// ensureNotificationIndexes makes (eventId, chatId) unique so an event is recorded at most
+// once per chat. Notifications saved before events had IDs are left out of the index.
+func ensureNotificationIndexes() error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    _, err := mongoDB.Collection("notifications").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "eventId", Value: 1}, {Key: "chatId", Value: 1}},
+        Options: options.Index().
+            SetUnique(true).
+            SetPartialFilterExpression(bson.M{"eventId": bson.M{"$type": "string"}}),
+    })
+    return err
+}
+
 func (r *MongoNotificationRepository) Save(ctx context.Context, n domain.Notification) error {    
     if mongoDB == nil {
         log.Printf("❌ MongoDB database is nil - connection not initialized")
@@ -278,6 +455,9 @@ func (r *MongoNotificationRepository) Save(ctx context.Context, n domain.Notific
     }
     
     _ , err := collection.InsertOne(ctx, n)
+    if mongo.IsDuplicateKeyError(err) {
+        return domain.ErrDuplicateNotification
+    }
     if err != nil {
         log.Printf("❌ MongoDB InsertOne failed: %v", err)
         return err
@@ -287,13 +467,66 @@ func (r *MongoNotificationRepository) Save(ctx context.Context, n domain.Notific
 }
 
 func (r *MongoNotificationRepository) ListByAddress(ctx context.Context, chatID string, blockchain string, address string, limit int) ([]domain.Notification, error) {
-    collection := mongoDB.Collection("notifications")
-    
     filter := bson.M{
         "chatId":     chatID,
         "blockchain": blockchain,
         "address":    address,
     }
+    return findNotifications(ctx, filter, limit)
+}
+
+func (r *MongoNotificationRepository) ListByChat(ctx context.Context, chatID string, limit int) ([]domain.Notification, error) {
+    return findNotifications(ctx, bson.M{"chatId": chatID}, limit)
+}
+
+func (r *MongoNotificationRepository) UpdateDelivery(ctx context.Context, eventID string, chatID string, key string, status domain.DeliveryStatus) error {
+    if eventID == "" {
+        return nil // recorded before events had IDs, cannot be addressed
+    }
+    if status.UpdatedAt.IsZero() {
+        status.UpdatedAt = time.Now()
+    }
+    _, err := mongoDB.Collection("notifications").UpdateOne(ctx,
+        bson.M{"eventId": eventID, "chatId": chatID},
+        bson.M{"$set": bson.M{"deliveries." + key: status}},
+    )
+    return err
+}
+
+func (r *MongoNotificationRepository) Get(ctx context.Context, eventID string, chatID string) (domain.Notification, error) {
+    var n domain.Notification
+    err := mongoDB.Collection("notifications").FindOne(ctx, bson.M{"eventId": eventID, "chatId": chatID}).Decode(&n)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return domain.Notification{}, domain.ErrNotificationNotFound
+    }
+    return n, err
+}
+
+func (r *MongoNotificationRepository) UpdateStatus(ctx context.Context, n domain.Notification, status domain.EventStatus, confirmations int) (bool, error) {
+    // Both fields are left out when the notification is saved with them empty.
+    filter := bson.M{
+        "eventId":       n.EventID,
+        "chatId":        n.ChatID,
+        "status":        bson.M{"$in": bson.A{nil, ""}},
+        "confirmations": bson.M{"$in": bson.A{nil, 0}},
+    }
+    if n.Status != "" {
+        filter["status"] = n.Status
+    }
+    if n.Confirmations != 0 {
+        filter["confirmations"] = n.Confirmations
+    }
+    res, err := mongoDB.Collection("notifications").UpdateOne(ctx, filter,
+        bson.M{"$set": bson.M{"status": status, "confirmations": confirmations}},
+    )
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+func findNotifications(ctx context.Context, filter bson.M, limit int) ([]domain.Notification, error) {
+    collection := mongoDB.Collection("notifications")
     
     opts := options.Find()
     opts.SetSort(bson.M{"timestamp": -1}) // Sort by timestamp descending (newest first)
@@ -314,5 +547,3 @@ func (r *MongoNotificationRepository) ListByAddress(ctx context.Context, chatID
     
     return notifications, nil
 }
-
-
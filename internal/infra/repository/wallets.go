@@ -0,0 +1,86 @@
+package repository
+
+import (
+    "context"
+    "time"
+
+    "go.mongodb.org/mongo-driver/bson"
+    "go.mongodb.org/mongo-driver/bson/primitive"
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// Wallets
+type MongoWalletRepository struct{}
+
+func NewMongoWalletRepository(uri string, dbName string) (ports.WalletRepository, error) {
+    if err := initMongoDB(uri, dbName); err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := mongoDB.Collection("wallets").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "blockchain", Value: 1}, {Key: "address", Value: 1}},
+        Options: options.Index().SetUnique(true),
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &MongoWalletRepository{}, nil
+}
+
+func (r *MongoWalletRepository) Create(ctx context.Context, wallet domain.Wallet) (domain.Wallet, error) {
+    wallet.ID = primitive.NewObjectID().Hex()
+    if wallet.CreatedAt.IsZero() {
+        wallet.CreatedAt = time.Now()
+    }
+    _, err := mongoDB.Collection("wallets").InsertOne(ctx, wallet)
+    if mongo.IsDuplicateKeyError(err) {
+        return domain.Wallet{}, domain.ErrWalletExists
+    }
+    if err != nil {
+        return domain.Wallet{}, err
+    }
+    publishWatchlistChange(ctx, walletChange(domain.SubscriptionAdded, wallet))
+    return wallet, nil
+}
+
+func (r *MongoWalletRepository) ListByUser(ctx context.Context, userID string) ([]domain.Wallet, error) {
+    opts := options.Find().SetSort(bson.M{"createdAt": 1})
+    cursor, err := mongoDB.Collection("wallets").Find(ctx, bson.M{"userId": userID}, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+
+    var wallets []domain.Wallet
+    if err = cursor.All(ctx, &wallets); err != nil {
+        return nil, err
+    }
+    return wallets, nil
+}
+
+func (r *MongoWalletRepository) Delete(ctx context.Context, userID string, id string) error {
+    var wallet domain.Wallet
+    err := mongoDB.Collection("wallets").FindOneAndDelete(ctx, bson.M{"_id": id, "userId": userID}).Decode(&wallet)
+    if err == mongo.ErrNoDocuments {
+        return domain.ErrWalletNotFound
+    }
+    if err != nil {
+        return err
+    }
+    publishWatchlistChange(ctx, walletChange(domain.SubscriptionRemoved, wallet))
+    return nil
+}
+
+// walletChange describes a write of wallet as a change of the address's subscriptions.
+func walletChange(changeType domain.SubscriptionChangeType, wallet domain.Wallet) domain.SubscriptionChange {
+    return domain.SubscriptionChange{
+        Type:         changeType,
+        Subscription: domain.Subscription{Blockchain: wallet.Blockchain, Address: wallet.Address},
+        UserID:       wallet.UserID,
+    }
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+    "log"
+    "sync"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// maxObserved caps the history kept per address so a very busy address cannot grow it unbounded.
+const maxObserved = 10000
+
+// observed is an event as remembered for the window aggregates.
+type observed struct {
+    id        string
+    at        time.Time
+    direction domain.Direction
+    amount    float64
+}
+
+// Engine evaluates chat rules and keeps the sliding windows of recent events per watched
+// address. Windows live in memory, so with several dispatchers each sees only its share.
+type Engine struct {
+    mu       sync.Mutex
+    programs map[string]*Program // compiled rules by expression
+    history  map[string][]observed
+}
+
+var _ ports.RuleEngine = (*Engine)(nil)
+
+func NewEngine() *Engine {
+    return &Engine{
+        programs: make(map[string]*Program),
+        history:  make(map[string][]observed),
+    }
+}
+
+func (e *Engine) Validate(expression string) error {
+    _, err := Compile(expression)
+    return err
+}
+
+func historyKey(evt domain.TransactionEvent) string {
+    return evt.Blockchain + "|" + evt.WalletID
+}
+
+// Observe records evt for the aggregates. Events seen before, e.g. redeliveries, are ignored.
+func (e *Engine) Observe(evt domain.TransactionEvent) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    key := historyKey(evt)
+    at := eventTime(evt)
+    cutoff := at.Add(-MaxWindow)
+    for _, o := range e.history[key] {
+        if evt.ID != "" && o.id == evt.ID {
+            return
+        }
+    }
+    kept := e.history[key][:0]
+    for _, o := range e.history[key] {
+        if o.at.After(cutoff) {
+            kept = append(kept, o)
+        }
+    }
+    if len(kept) >= maxObserved {
+        kept = kept[len(kept)-maxObserved+1:]
+    }
+    e.history[key] = append(kept, observed{id: evt.ID, at: at, direction: evt.Direction, amount: evt.Amount})
+}
+
+// Match returns the first enabled rule evt satisfies.
+func (e *Engine) Match(rules []domain.ChatRule, evt domain.TransactionEvent) (domain.ChatRule, bool) {
+    e.mu.Lock()
+    history := append([]observed(nil), e.history[historyKey(evt)]...)
+    e.mu.Unlock()
+
+    for _, r := range rules {
+        if !r.Enabled {
+            continue
+        }
+        prog, err := e.program(r.Expression)
+        if err != nil {
+            log.Printf("⚠️ Skipping invalid rule %s of chat %s: %v", r.ID, r.ChatID, err)
+            continue
+        }
+        if prog.Eval(evt, history) {
+            return r, true
+        }
+    }
+    return domain.ChatRule{}, false
+}
+
+func (e *Engine) program(expression string) (*Program, error) {
+    e.mu.Lock()
+    prog, ok := e.programs[expression]
+    e.mu.Unlock()
+    if ok {
+        return prog, nil
+    }
+    prog, err := Compile(expression)
+    if err != nil {
+        return nil, err
+    }
+    e.mu.Lock()
+    e.programs[expression] = prog
+    e.mu.Unlock()
+    return prog, nil
+}
@@ -0,0 +1,208 @@
+package rules
+
+import (
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// Program is a compiled rule expression.
+type Program struct {
+    src  string
+    root node
+}
+
+// Eval reports whether evt satisfies the expression. history holds the recent events of the
+// same address, including evt, for the window aggregates.
+func (p *Program) Eval(evt domain.TransactionEvent, history []observed) bool {
+    return p.root.eval(&env{evt: evt, history: history, now: eventTime(evt)}).b
+}
+
+func (p *Program) String() string { return p.src }
+
+type env struct {
+    evt     domain.TransactionEvent
+    history []observed
+    now     time.Time
+}
+
+type value struct {
+    b    bool
+    n    float64
+    s    string
+    list []string
+    // unknown marks a number that is not known, such as the value of an unpriced transfer.
+    // Every comparison with it is false.
+    unknown bool
+}
+
+type node interface {
+    typ() valueType
+    eval(e *env) value
+}
+
+type numberNode float64
+
+func (n numberNode) typ() valueType { return typeNumber }
+func (n numberNode) eval(*env) value { return value{n: float64(n)} }
+
+type stringNode string
+
+func (n stringNode) typ() valueType { return typeString }
+func (n stringNode) eval(*env) value { return value{s: string(n)} }
+
+type listNode []string
+
+func (n listNode) typ() valueType { return typeList }
+func (n listNode) eval(*env) value { return value{list: n} }
+
+type fieldNode struct {
+    name string
+    t    valueType
+}
+
+func (n fieldNode) typ() valueType { return n.t }
+
+func (n fieldNode) eval(e *env) value {
+    evt := e.evt
+    switch n.name {
+    case "amount":
+        return value{n: evt.Amount}
+    case "value":
+        return value{n: evt.FiatValue, unknown: evt.FiatCurrency == ""}
+    case "currency":
+        return value{s: evt.Currency}
+    case "direction":
+        return value{s: string(evt.Direction)}
+    case "blockchain":
+        return value{s: evt.Blockchain}
+    case "address":
+        return value{s: evt.WalletID}
+    case "counterparty":
+        return value{s: evt.Counterparty}
+    case "tx":
+        return value{s: evt.TxHash}
+    case "incoming":
+        return value{b: evt.Direction == domain.DirectionIncoming}
+    case "outgoing":
+        return value{b: evt.Direction == domain.DirectionOutgoing}
+    case "true":
+        return value{b: true}
+    }
+    return value{}
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) typ() valueType { return typeBool }
+func (n notNode) eval(e *env) value { return value{b: !n.operand.eval(e).b} }
+
+type logicalNode struct {
+    and         bool
+    left, right node
+}
+
+func (n logicalNode) typ() valueType { return typeBool }
+
+func (n logicalNode) eval(e *env) value {
+    left := n.left.eval(e).b
+    if n.and && !left || !n.and && left {
+        return value{b: left}
+    }
+    return n.right.eval(e)
+}
+
+type compareNode struct {
+    op          string
+    left, right node
+}
+
+func (n compareNode) typ() valueType { return typeBool }
+
+func (n compareNode) eval(e *env) value {
+    l, r := n.left.eval(e), n.right.eval(e)
+    var eq bool
+    switch n.left.typ() {
+    case typeNumber:
+        if l.unknown || r.unknown {
+            return value{b: false}
+        }
+        switch n.op {
+        case "<":
+            return value{b: l.n < r.n}
+        case "<=":
+            return value{b: l.n <= r.n}
+        case ">":
+            return value{b: l.n > r.n}
+        case ">=":
+            return value{b: l.n >= r.n}
+        }
+        eq = l.n == r.n
+    case typeString:
+        // Addresses, currencies and directions are compared case-insensitively.
+        eq = strings.EqualFold(l.s, r.s)
+    default:
+        eq = l.b == r.b
+    }
+    if n.op == "!=" {
+        return value{b: !eq}
+    }
+    return value{b: eq}
+}
+
+type inNode struct {
+    value  node
+    list   listNode
+    negate bool
+}
+
+func (n inNode) typ() valueType { return typeBool }
+
+func (n inNode) eval(e *env) value {
+    s := n.value.eval(e).s
+    found := false
+    for _, item := range n.list {
+        if strings.EqualFold(item, s) {
+            found = true
+            break
+        }
+    }
+    return value{b: found != n.negate}
+}
+
+// aggregateNode counts or sums the address's events within window, optionally only those
+// in one direction.
+type aggregateNode struct {
+    sum       bool
+    direction string // "", "incoming" or "outgoing"
+    window    time.Duration
+}
+
+func (n aggregateNode) typ() valueType { return typeNumber }
+
+func (n aggregateNode) eval(e *env) value {
+    since := e.now.Add(-n.window)
+    var total float64
+    for _, o := range e.history {
+        if !o.at.After(since) || o.at.After(e.now) {
+            continue
+        }
+        if n.direction != "" && string(o.direction) != n.direction {
+            continue
+        }
+        if n.sum {
+            total += o.amount
+        } else {
+            total++
+        }
+    }
+    return value{n: total}
+}
+
+func eventTime(evt domain.TransactionEvent) time.Time {
+    if evt.Timestamp == 0 {
+        return time.Now()
+    }
+    return time.Unix(evt.Timestamp, 0)
+}
@@ -0,0 +1,155 @@
+package rules
+
+import (
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+var evalNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func transfer(id string, direction domain.Direction, amount float64, ago time.Duration) domain.TransactionEvent {
+    return domain.TransactionEvent{
+        ID:           id,
+        Blockchain:   "ethereum",
+        WalletID:     "0xabc",
+        TxHash:       "0x" + id,
+        Direction:    direction,
+        Amount:       amount,
+        Currency:     "ETH",
+        Counterparty: "0xDEF",
+        Timestamp:    evalNow.Add(-ago).Unix(),
+    }
+}
+
+func TestEval(t *testing.T) {
+    evt := transfer("1", domain.DirectionOutgoing, 5, 0)
+    tests := []struct {
+        src  string
+        want bool
+    }{
+        {"amount == 5", true},
+        {"amount != 5", false},
+        {"amount > 4.5 and amount <= 5", true},
+        {"amount < 5 or amount >= 6", false},
+        {"outgoing", true},
+        {"incoming", false},
+        {"direction == \"OUTGOING\"", true},
+        {"currency == 'eth'", true},
+        {"counterparty in [\"0xdef\", \"0x123\"]", true},
+        {"counterparty not in [\"0xdef\"]", false},
+        {"address in []", false},
+        {"tx == \"0x1\" and blockchain == \"ethereum\"", true},
+
+        // and binds tighter than or, not tighter than and.
+        {"true or false and false", true},
+        {"(true or false) and false", false},
+        {"false and false or true", true},
+        {"false and (false or true)", false},
+        {"not false and false", false},
+        {"not (false and false)", true},
+        {"not not true", true},
+        {"!incoming && outgoing || false", true},
+        {"incoming or amount > 1 and currency == \"BTC\"", false},
+    }
+    for _, tt := range tests {
+        prog, err := Compile(tt.src)
+        if err != nil {
+            t.Errorf("Compile(%q): %v", tt.src, err)
+            continue
+        }
+        if got := prog.Eval(evt, nil); got != tt.want {
+            t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+        }
+    }
+}
+
+func TestEvalValue(t *testing.T) {
+    priced := transfer("1", domain.DirectionOutgoing, 5, 0).WithFiatValue(2, "usd")
+    unpriced := transfer("2", domain.DirectionOutgoing, 5, 0)
+    tests := []struct {
+        src            string
+        priced, unpriced bool
+    }{
+        {"value == 10", true, false},
+        {"value != 10", false, false},
+        {"value < 100", true, false},
+        {"value >= 100", false, false},
+        {"value > 0 or outgoing", true, true},
+        {"not (value < 100)", false, true},
+    }
+    for _, tt := range tests {
+        prog, err := Compile(tt.src)
+        if err != nil {
+            t.Fatalf("Compile(%q): %v", tt.src, err)
+        }
+        if got := prog.Eval(priced, nil); got != tt.priced {
+            t.Errorf("priced: %s = %v, want %v", tt.src, got, tt.priced)
+        }
+        if got := prog.Eval(unpriced, nil); got != tt.unpriced {
+            t.Errorf("unpriced: %s = %v, want %v", tt.src, got, tt.unpriced)
+        }
+    }
+}
+
+func TestEvalWindows(t *testing.T) {
+    engine := NewEngine()
+    history := []domain.TransactionEvent{
+        transfer("1", domain.DirectionIncoming, 100, 25*time.Hour), // older than MaxWindow, forgotten
+        transfer("2", domain.DirectionIncoming, 10, 2*time.Hour),
+        transfer("3", domain.DirectionOutgoing, 3, 30*time.Minute),
+        transfer("4", domain.DirectionIncoming, 1, 5*time.Minute),
+        transfer("5", domain.DirectionOutgoing, 2, 0),
+        transfer("6", domain.DirectionOutgoing, 50, -time.Minute), // after the evaluated event
+    }
+    for _, evt := range history {
+        engine.Observe(evt)
+    }
+    // Redeliveries are not counted twice.
+    engine.Observe(history[4])
+    evt := history[4]
+
+    tests := []struct {
+        src  string
+        want bool
+    }{
+        {"count(1m) == 1", true},
+        {"count(10m) == 2", true},
+        {"count(1h) == 3", true},
+        {"count(1d) == 4", true},
+        {"count_incoming(1d) == 2", true},
+        {"count_outgoing(1h) == 2", true},
+        {"sum(10m) == 3", true},
+        {"sum(1d) == 16", true},
+        {"sum_incoming(1d) == 11", true},
+        {"sum_outgoing(1h) == 5", true},
+        {"count_outgoing(10m) > 5 or sum_incoming(3h) >= 10", true},
+        {"count(5m) == 2", false}, // the window excludes its start
+    }
+    for _, tt := range tests {
+        rule := domain.ChatRule{ID: tt.src, Name: tt.src, Expression: tt.src, Enabled: true}
+        if _, got := engine.Match([]domain.ChatRule{rule}, evt); got != tt.want {
+            t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+        }
+    }
+}
+
+func TestMatch(t *testing.T) {
+    engine := NewEngine()
+    evt := transfer("1", domain.DirectionIncoming, 5, 0)
+    rules := []domain.ChatRule{
+        {ID: "disabled", Expression: "incoming", Enabled: false},
+        {ID: "invalid", Expression: "amount >", Enabled: true},
+        {ID: "small", Expression: "amount < 1", Enabled: true},
+        {ID: "incoming", Expression: "incoming", Enabled: true},
+        {ID: "any", Expression: "true", Enabled: true},
+    }
+    rule, ok := engine.Match(rules, evt)
+    if !ok || rule.ID != "incoming" {
+        t.Errorf("matched %q (%v), want the first enabled rule that matches", rule.ID, ok)
+    }
+    if _, ok := engine.Match(rules[:3], evt); ok {
+        t.Error("matched without a matching enabled rule")
+    }
+}
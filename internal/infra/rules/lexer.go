@@ -0,0 +1,153 @@
+package rules
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+)
+
+type tokenKind int
+
+const (
+    tokEOF tokenKind = iota
+    tokNumber
+    tokDuration
+    tokString
+    tokIdent
+    tokOp // comparison and boolean operators
+    tokLParen
+    tokRParen
+    tokLBracket
+    tokRBracket
+    tokComma
+)
+
+type token struct {
+    kind tokenKind
+    text string
+    pos  int // byte offset in the expression, for error messages
+    num  float64
+    dur  time.Duration
+}
+
+func (t token) String() string {
+    if t.kind == tokEOF {
+        return "end of expression"
+    }
+    return fmt.Sprintf("%q", t.text)
+}
+
+// symbolOps maps operator symbols to their canonical form.
+var symbolOps = map[string]string{
+    "==": "==",
+    "!=": "!=",
+    "<":  "<",
+    "<=": "<=",
+    ">":  ">",
+    ">=": ">=",
+    "&&": "and",
+    "||": "or",
+    "!":  "not",
+}
+
+// keywordOps are the word forms of the boolean operators.
+var keywordOps = map[string]string{
+    "and": "and",
+    "or":  "or",
+    "not": "not",
+    "in":  "in",
+}
+
+func lex(src string) ([]token, error) {
+    var tokens []token
+    i := 0
+    for i < len(src) {
+        c := src[i]
+        switch {
+        case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+            i++
+        case c == '(':
+            tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+            i++
+        case c == ')':
+            tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+            i++
+        case c == '[':
+            tokens = append(tokens, token{kind: tokLBracket, text: "[", pos: i})
+            i++
+        case c == ']':
+            tokens = append(tokens, token{kind: tokRBracket, text: "]", pos: i})
+            i++
+        case c == ',':
+            tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+            i++
+        case c == '"' || c == '\'':
+            end := strings.IndexByte(src[i+1:], c)
+            if end < 0 {
+                return nil, &Error{Pos: i, Msg: "unterminated string"}
+            }
+            text := src[i+1 : i+1+end]
+            tokens = append(tokens, token{kind: tokString, text: text, pos: i})
+            i += end + 2
+        case strings.ContainsRune("=!<>&|", rune(c)):
+            raw := string(c)
+            if i+1 < len(src) {
+                if _, ok := symbolOps[src[i:i+2]]; ok {
+                    raw = src[i : i+2]
+                }
+            }
+            op, ok := symbolOps[raw]
+            if !ok {
+                return nil, &Error{Pos: i, Msg: fmt.Sprintf("unknown operator %q", raw)}
+            }
+            tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+            i += len(raw)
+        case c >= '0' && c <= '9' || c == '.':
+            start := i
+            for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+                i++
+            }
+            num, err := strconv.ParseFloat(src[start:i], 64)
+            if err != nil {
+                return nil, &Error{Pos: start, Msg: fmt.Sprintf("invalid number %q", src[start:i])}
+            }
+            // A unit right after the number makes it a duration, e.g. 10m.
+            unitStart := i
+            for i < len(src) && unicode.IsLetter(rune(src[i])) {
+                i++
+            }
+            if unit := src[unitStart:i]; unit != "" {
+                d, ok := durationUnits[unit]
+                if !ok {
+                    return nil, &Error{Pos: unitStart, Msg: fmt.Sprintf("unknown duration unit %q, use s, m, h or d", unit)}
+                }
+                tokens = append(tokens, token{kind: tokDuration, text: src[start:i], pos: start, dur: time.Duration(num * float64(d))})
+                continue
+            }
+            tokens = append(tokens, token{kind: tokNumber, text: src[start:i], pos: start, num: num})
+        case c == '_' || unicode.IsLetter(rune(c)):
+            start := i
+            for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+                i++
+            }
+            word := src[start:i]
+            if op, ok := keywordOps[strings.ToLower(word)]; ok {
+                tokens = append(tokens, token{kind: tokOp, text: op, pos: start})
+                continue
+            }
+            tokens = append(tokens, token{kind: tokIdent, text: word, pos: start})
+        default:
+            return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+        }
+    }
+    return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+var durationUnits = map[string]time.Duration{
+    "s": time.Second,
+    "m": time.Minute,
+    "h": time.Hour,
+    "d": 24 * time.Hour,
+}
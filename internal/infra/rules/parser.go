@@ -0,0 +1,286 @@
+package rules
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Error is a compile error at a byte offset of the expression.
+type Error struct {
+    Pos int
+    Msg string
+}
+
+func (e *Error) Error() string {
+    return fmt.Sprintf("at position %d: %s", e.Pos+1, e.Msg)
+}
+
+type valueType int
+
+const (
+    typeBool valueType = iota
+    typeNumber
+    typeString
+    typeList
+)
+
+func (t valueType) String() string {
+    return [...]string{"boolean", "number", "string", "list"}[t]
+}
+
+// MaxWindow is the longest window aggregate functions may look back.
+const MaxWindow = 24 * time.Hour
+
+// fields are the event fields an expression can refer to.
+var fields = map[string]valueType{
+    "amount":       typeNumber,
+    "value":        typeNumber,
+    "currency":     typeString,
+    "direction":    typeString,
+    "blockchain":   typeString,
+    "address":      typeString,
+    "counterparty": typeString,
+    "tx":           typeString,
+    "incoming":     typeBool,
+    "outgoing":     typeBool,
+    "true":         typeBool,
+    "false":        typeBool,
+}
+
+// aggregates are the sliding-window functions; each takes a duration literal.
+var aggregates = map[string]bool{
+    "count":          true,
+    "count_incoming": true,
+    "count_outgoing": true,
+    "sum":            true,
+    "sum_incoming":   true,
+    "sum_outgoing":   true,
+}
+
+type parser struct {
+    tokens []token
+    pos    int
+}
+
+// Compile parses and type-checks an expression. The expression must be a boolean.
+func Compile(src string) (*Program, error) {
+    tokens, err := lex(src)
+    if err != nil {
+        return nil, err
+    }
+    p := &parser{tokens: tokens}
+    root, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if tok := p.peek(); tok.kind != tokEOF {
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+    }
+    if root.typ() != typeBool {
+        return nil, &Error{Pos: 0, Msg: fmt.Sprintf("rule must be a condition, got a %s", root.typ())}
+    }
+    return &Program{src: src, root: root}, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+    tok := p.tokens[p.pos]
+    if tok.kind != tokEOF {
+        p.pos++
+    }
+    return tok
+}
+
+func (p *parser) isOp(op string) bool {
+    tok := p.peek()
+    return tok.kind == tokOp && tok.text == op
+}
+
+func (p *parser) parseOr() (node, error) {
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    for p.isOp("or") {
+        tok := p.next()
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        if left, err = newLogical(tok, left, right); err != nil {
+            return nil, err
+        }
+    }
+    return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+    left, err := p.parseUnary()
+    if err != nil {
+        return nil, err
+    }
+    for p.isOp("and") {
+        tok := p.next()
+        right, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        if left, err = newLogical(tok, left, right); err != nil {
+            return nil, err
+        }
+    }
+    return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+    if p.isOp("not") {
+        tok := p.next()
+        operand, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        if operand.typ() != typeBool {
+            return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("cannot negate a %s", operand.typ())}
+        }
+        return notNode{operand}, nil
+    }
+    return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+    left, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    tok := p.peek()
+    if tok.kind != tokOp {
+        return left, nil
+    }
+    switch tok.text {
+    case "==", "!=", "<", "<=", ">", ">=":
+        p.next()
+        right, err := p.parsePrimary()
+        if err != nil {
+            return nil, err
+        }
+        return newCompare(tok, left, right)
+    case "in":
+        p.next()
+        return p.parseIn(tok, left, false)
+    case "not":
+        // "x not in [...]"
+        if p.tokens[p.pos+1].kind == tokOp && p.tokens[p.pos+1].text == "in" {
+            p.next()
+            p.next()
+            return p.parseIn(tok, left, true)
+        }
+    }
+    return left, nil
+}
+
+func (p *parser) parseIn(tok token, left node, negate bool) (node, error) {
+    if left.typ() != typeString {
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("in needs a string on the left, got a %s", left.typ())}
+    }
+    list, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    if list.typ() != typeList {
+        return nil, &Error{Pos: tok.pos, Msg: "in needs a list on the right, e.g. [\"0xabc\", \"0xdef\"]"}
+    }
+    return inNode{value: left, list: list.(listNode), negate: negate}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+    tok := p.next()
+    switch tok.kind {
+    case tokNumber:
+        return numberNode(tok.num), nil
+    case tokString:
+        return stringNode(tok.text), nil
+    case tokDuration:
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("duration %s can only be used as a window, e.g. count(%s)", tok.text, tok.text)}
+    case tokLParen:
+        inner, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if closing := p.next(); closing.kind != tokRParen {
+            return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\", got %s", closing)}
+        }
+        return inner, nil
+    case tokLBracket:
+        return p.parseList(tok)
+    case tokIdent:
+        name := strings.ToLower(tok.text)
+        if p.peek().kind == tokLParen {
+            return p.parseAggregate(tok, name)
+        }
+        t, ok := fields[name]
+        if !ok {
+            return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unknown field %q", tok.text)}
+        }
+        return fieldNode{name: name, t: t}, nil
+    }
+    return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+}
+
+func (p *parser) parseList(open token) (node, error) {
+    var items []string
+    for {
+        tok := p.next()
+        switch {
+        case tok.kind == tokRBracket && len(items) == 0:
+            return listNode(items), nil
+        case tok.kind != tokString:
+            return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("lists hold strings, got %s", tok)}
+        }
+        items = append(items, tok.text)
+        sep := p.next()
+        if sep.kind == tokRBracket {
+            return listNode(items), nil
+        }
+        if sep.kind != tokComma {
+            return nil, &Error{Pos: sep.pos, Msg: fmt.Sprintf("expected \",\" or \"]\", got %s", sep)}
+        }
+    }
+}
+
+func (p *parser) parseAggregate(tok token, name string) (node, error) {
+    if !aggregates[name] {
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unknown function %q", tok.text)}
+    }
+    p.next() // (
+    arg := p.next()
+    if arg.kind != tokDuration {
+        return nil, &Error{Pos: arg.pos, Msg: fmt.Sprintf("%s needs a window such as 10m, got %s", name, arg)}
+    }
+    if arg.dur <= 0 || arg.dur > MaxWindow {
+        return nil, &Error{Pos: arg.pos, Msg: fmt.Sprintf("window must be between 1s and %s", MaxWindow)}
+    }
+    if closing := p.next(); closing.kind != tokRParen {
+        return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\", got %s", closing)}
+    }
+    kind, direction, _ := strings.Cut(name, "_")
+    return aggregateNode{sum: kind == "sum", direction: direction, window: arg.dur}, nil
+}
+
+func newLogical(tok token, left, right node) (node, error) {
+    if left.typ() != typeBool || right.typ() != typeBool {
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("%s needs conditions on both sides, got %s and %s", tok.text, left.typ(), right.typ())}
+    }
+    return logicalNode{and: tok.text == "and", left: left, right: right}, nil
+}
+
+func newCompare(tok token, left, right node) (node, error) {
+    if left.typ() != right.typ() || left.typ() == typeList {
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("cannot compare %s with %s", left.typ(), right.typ())}
+    }
+    if left.typ() != typeNumber && tok.text != "==" && tok.text != "!=" {
+        return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("%s only compares numbers", tok.text)}
+    }
+    return compareNode{op: tok.text, left: left, right: right}, nil
+}
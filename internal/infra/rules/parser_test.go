@@ -0,0 +1,69 @@
+package rules
+
+import (
+    "errors"
+    "strings"
+    "testing"
+)
+
+func TestCompile(t *testing.T) {
+    valid := []string{
+        "amount > 10",
+        "outgoing and amount > 10 or counterparty not in [\"0xabc\", \"0xdef\"]",
+        "not (incoming && currency == 'ETH') || !outgoing",
+        "count_outgoing(10m) > 5",
+        "incoming and currency == \"ETH\" and sum_incoming(1h) >= 100",
+        "count(1d) >= 1",
+        "direction in []",
+        "AMOUNT > 1 AND Incoming",
+        "value >= 10000",
+    }
+    for _, src := range valid {
+        if _, err := Compile(src); err != nil {
+            t.Errorf("Compile(%q): %v", src, err)
+        }
+    }
+}
+
+func TestCompileErrors(t *testing.T) {
+    tests := []struct {
+        src  string
+        pos  int
+        want string
+    }{
+        {"", 0, "unexpected end"},
+        {"amount", 0, "must be a condition"},
+        {"amount >", 8, "unexpected end"},
+        {"amount > 10 and", 15, "unexpected end"},
+        {"(amount > 10", 12, "expected \")\""},
+        {"amount > 10)", 11, "unexpected \")\""},
+        {"amount = 10", 7, "unknown operator"},
+        {"amount > '10'", 7, "cannot compare number with string"},
+        {"currency < \"ETH\"", 9, "only compares numbers"},
+        {"currency == \"ETH", 12, "unterminated string"},
+        {"balance > 1", 0, "unknown field"},
+        {"amount > 10 and 5", 12, "needs conditions on both sides"},
+        {"not amount", 0, "cannot negate"},
+        {"amount in [\"1\"]", 7, "needs a string on the left"},
+        {"currency in \"ETH\"", 9, "needs a list"},
+        {"currency in [1]", 13, "lists hold strings"},
+        {"count(10) > 1", 6, "needs a window"},
+        {"count(2d) > 1", 6, "window must be between"},
+        {"count(10w) > 1", 8, "unknown duration unit"},
+        {"avg(1h) > 1", 0, "unknown function"},
+        {"amount > 10m", 9, "can only be used as a window"},
+        {"amount > 1.2.3", 9, "invalid number"},
+        {"amount > 1 # 2", 11, "unexpected character"},
+    }
+    for _, tt := range tests {
+        _, err := Compile(tt.src)
+        var compileErr *Error
+        if !errors.As(err, &compileErr) {
+            t.Errorf("Compile(%q) = %v, want a compile error", tt.src, err)
+            continue
+        }
+        if compileErr.Pos != tt.pos || !strings.Contains(compileErr.Msg, tt.want) {
+            t.Errorf("Compile(%q) = %q at %d, want %q at %d", tt.src, compileErr.Msg, compileErr.Pos, tt.want, tt.pos)
+        }
+    }
+}
@@ -1,12 +1,25 @@
 package ports
 
-import "github.com/you/wallet_transaction_notifier/internal/domain"
+import (
+    "context"
 
-// BlockchainAdapter defines subscriptions for wallet events and emits standardized TransactionEvent.
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// BlockchainAdapter watches a single chain for subscribed addresses and emits standardized TransactionEvent.
 type BlockchainAdapter interface {
+    // Chain returns the blockchain identifier handled by the adapter, e.g. "ethereum".
+    Chain() string
+    // Run connects to the node and processes new blocks until ctx is cancelled.
+    Run(ctx context.Context) error
     Subscribe(address string) error
     Unsubscribe(address string) error
+    // Events streams the events emitted by this adapter. The channel is closed when Run returns.
     Events() <-chan domain.TransactionEvent
 }
 
-
+// AddressWatcher forwards subscription changes to the adapter responsible for the blockchain.
+type AddressWatcher interface {
+    Watch(blockchain string, address string) error
+    Unwatch(blockchain string, address string) error
+}
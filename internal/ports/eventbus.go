@@ -8,4 +8,30 @@ type EventBus interface {
     Subscribe() (<-chan domain.TransactionEvent, func()) // returns channel and unsubscribe
 }
 
+// NamedSubscriber is implemented by buses that configure and account for subscribers by name.
+type NamedSubscriber interface {
+    SubscribeNamed(name string) (<-chan domain.TransactionEvent, func())
+}
+
+// ChangeBroadcaster is implemented by buses shared between processes that also carry
+// subscription changes, so that watchers apply changes made through the API or the bot of
+// another process right away. Changes are not kept: a process that is down misses them and
+// catches up on its next reconciliation with the stored subscriptions.
+type ChangeBroadcaster interface {
+    PublishChange(change domain.SubscriptionChange)
+    SubscribeChanges() (<-chan domain.SubscriptionChange, func())
+}
 
+// AckableEvent is an event delivered to a consumer group. Until Ack is called the bus
+// considers it in flight and delivers it again once the acknowledgement timeout expires.
+type AckableEvent struct {
+    Event domain.TransactionEvent
+    Ack   func() error
+}
+
+// AckingEventBus is an EventBus with at-least-once delivery: consumer groups keep an offset,
+// and events that are not acknowledged are redelivered, also across restarts.
+type AckingEventBus interface {
+    EventBus
+    SubscribeGroup(group string) (<-chan AckableEvent, func()) // returns channel and unsubscribe
+}
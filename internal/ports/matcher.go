@@ -0,0 +1,12 @@
+package ports
+
+// AddressMatcher is the watch list of a chain adapter. Addresses are compared
+// case-insensitively, like the lower-cased addresses of subscriptions.
+type AddressMatcher interface {
+    Add(address string)
+    Remove(address string)
+    Match(address string) bool
+    // Sync makes the watch list equal to addresses and reports how many entries changed.
+    Sync(addresses []string) (added int, removed int)
+    Len() int
+}
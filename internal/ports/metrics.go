@@ -0,0 +1,15 @@
+package ports
+
+// Metric is a single sample exposed on the metrics endpoint.
+type Metric struct {
+    Name   string
+    Help   string
+    Type   string // "counter" or "gauge"
+    Labels map[string]string
+    Value  float64
+}
+
+// MetricsProvider is implemented by components that expose metrics.
+type MetricsProvider interface {
+    Metrics() []Metric
+}
@@ -1,10 +1,36 @@
 package ports
 
-import "github.com/you/wallet_transaction_notifier/internal/domain"
+import (
+    "context"
 
-// Notifier sends alerts to the end user.
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// Notifier delivers alerts over one channel. The dispatcher resolves the recipients of an
+// event and calls Send once per recipient; Send delivers exactly one message to it and
+// returns the channel's ID for that message, if it has one.
 type Notifier interface {
-    SendAlert(userID string, event domain.TransactionEvent) error
+    // Channel names the recipients this notifier serves, e.g. domain.ChannelTelegram.
+    Channel() string
+    Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (messageID string, err error)
 }
 
+// MessageEditor is implemented by notifiers that can update a message they sent, such as a
+// Telegram alert, when the state of its transaction changes.
+type MessageEditor interface {
+    Notifier
+    Edit(ctx context.Context, to domain.Recipient, messageID string, event domain.TransactionEvent) error
+}
 
+// AlertConfirmer is implemented by notifiers that ask the target of a new alert channel,
+// such as another Telegram chat, to accept its alerts before any are sent there.
+type AlertConfirmer interface {
+    Notifier
+    RequestConfirmation(ctx context.Context, alert domain.Alert) error
+}
+
+// DigestNotifier is implemented by notifiers that can send a digest as a single message.
+type DigestNotifier interface {
+    Notifier
+    SendDigest(ctx context.Context, to domain.Recipient, digest domain.Digest) (messageID string, err error)
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+    "context"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// PriceProvider quotes assets in fiat currencies.
+type PriceProvider interface {
+    // Price returns the price of one unit of asset in the fiat currency, e.g. "usd", at the
+    // given time, or an error wrapping domain.ErrPriceUnknown when there is none.
+    Price(ctx context.Context, asset domain.Asset, fiat string, at time.Time) (float64, error)
+}
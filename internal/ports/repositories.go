@@ -2,23 +2,48 @@ package ports
 
 import (
     "context"
+    "time"
+
     "github.com/you/wallet_transaction_notifier/internal/domain"
 )
 
 // WalletRepository persists wallets.
 type WalletRepository interface {
-    Create(ctx context.Context, wallet domain.Wallet) error
+    // Create stores a wallet and returns it with its ID assigned, or domain.ErrWalletExists
+    // when the user already added the address. Wallets are watched like subscriptions: their
+    // writes are published as subscription changes.
+    Create(ctx context.Context, wallet domain.Wallet) (domain.Wallet, error)
     ListByUser(ctx context.Context, userID string) ([]domain.Wallet, error)
+    Delete(ctx context.Context, userID string, id string) error
 }
 
 // AlertRepository persists alert channels.
 type AlertRepository interface {
     ListByUser(ctx context.Context, userID string) ([]domain.Alert, error)
+    Get(ctx context.Context, id string) (domain.Alert, error)
+    // Create stores an alert and returns it with its ID assigned.
+    Create(ctx context.Context, alert domain.Alert) (domain.Alert, error)
+    Delete(ctx context.Context, userID string, id string) error
+    // SetDisabled disables or re-enables an alert; enabling clears its failures.
+    SetDisabled(ctx context.Context, userID string, id string, disabled bool) error
+    // RecordFailure counts a failed delivery and disables the alert once it has failed
+    // maxFailures times in a row. It reports whether the alert is disabled now.
+    RecordFailure(ctx context.Context, id string, lastErr string, maxFailures int) (bool, error)
+    // RecordSuccess resets the failure count after a delivery went through.
+    RecordSuccess(ctx context.Context, id string) error
+    // Confirm enables an unconfirmed alert whose ConfirmCode is code. It returns
+    // domain.ErrAlertNotFound when no unconfirmed alert has that ID and code.
+    Confirm(ctx context.Context, id string, code string) error
 }
 
 type SessionRepository interface {
     UpsertTelegramSession(ctx context.Context, s domain.TelegramSession) error
     GetTelegramSession(ctx context.Context, chatID string) (domain.TelegramSession, error)
+    // UpdateChatSettings replaces the settings of a chat, creating its session if needed.
+    UpdateChatSettings(ctx context.Context, chatID string, settings domain.ChatSettings) error
+    // UpdateLanguage stores the language a chat picked and the one its client reported,
+    // creating its session if needed.
+    UpdateLanguage(ctx context.Context, chatID string, language string, languageCode string) error
 }
 
 type SubscriptionRepository interface {
@@ -26,12 +51,76 @@ type SubscriptionRepository interface {
     RemoveSubscription(ctx context.Context, chatID string, blockchain string, address string) error
     ListSubscriptions(ctx context.Context, chatID string, blockchain string) ([]domain.Subscription, error)
     ListSubscribersByAddress(ctx context.Context, blockchain string, address string) ([]domain.Subscription, error)
+    // GetUniqueAddresses returns the addresses to watch on blockchain: those of subscriptions
+    // and of wallets.
     GetUniqueAddresses(ctx context.Context, blockchain string) ([]string, error)
+    UpdateSubscriptionRules(ctx context.Context, chatID string, blockchain string, address string, rules domain.SubscriptionRules) error
+    SetSubscriptionMode(ctx context.Context, chatID string, blockchain string, address string, mode domain.DeliveryMode) error
+    // MuteSubscription silences a subscription until the given time; the zero time unmutes it.
+    MuteSubscription(ctx context.Context, chatID string, blockchain string, address string, until time.Time) error
+    // SetSubscriptionChannels routes a subscription's alerts to the given channels, see
+    // domain.Subscription.Channels; empty routes them everywhere. It returns
+    // domain.ErrSubscriptionNotFound for unknown subscriptions.
+    SetSubscriptionChannels(ctx context.Context, chatID string, blockchain string, address string, channels []string) error
+    // RemoveSubscriptionChannel drops a deleted alert channel from the chat's subscriptions.
+    // Subscriptions it was the only channel of are routed to the chat itself rather than
+    // everywhere.
+    RemoveSubscriptionChannel(ctx context.Context, chatID string, channel string) error
+    // SubscribeChanges streams the subscription and wallet writes made by this process. It
+    // returns the channel and unsubscribe.
+    SubscribeChanges() (<-chan domain.SubscriptionChange, func())
 }
 
 type NotificationRepository interface {
     Save(ctx context.Context, n domain.Notification) error
     ListByAddress(ctx context.Context, chatID string, blockchain string, address string, limit int) ([]domain.Notification, error)
+    ListByChat(ctx context.Context, chatID string, limit int) ([]domain.Notification, error)
+    // UpdateDelivery records the delivery status of the notification of eventID for chatID to one
+    // recipient, named by domain.DeliveryKey.
+    UpdateDelivery(ctx context.Context, eventID string, chatID string, key string, status domain.DeliveryStatus) error
+    // Get returns the notification of eventID for chatID, or domain.ErrNotificationNotFound.
+    Get(ctx context.Context, eventID string, chatID string) (domain.Notification, error)
+    // UpdateStatus moves n to a new transaction state. It reports false, leaving n as it is,
+    // when n's state was changed in the meantime, e.g. by another dispatcher.
+    UpdateStatus(ctx context.Context, n domain.Notification, status domain.EventStatus, confirmations int) (bool, error)
 }
 
 
+
+// DeliveryQueue persists alerts until a notifier has sent them.
+type DeliveryQueue interface {
+    // Enqueue adds a delivery; a delivery with the same ID that is already queued is kept.
+    Enqueue(ctx context.Context, d domain.Delivery) error
+    // Claim leases up to limit deliveries that are due, hiding them from other workers until the lease ends.
+    Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.Delivery, error)
+    Complete(ctx context.Context, id string) error
+    // UpdateEvent replaces the event of a delivery that is waiting in the queue and not
+    // claimed by a worker. It reports false when there is no such delivery.
+    UpdateEvent(ctx context.Context, id string, evt domain.TransactionEvent) (bool, error)
+    // Retry releases a delivery to be attempted again at next.
+    Retry(ctx context.Context, id string, next time.Time, lastErr string) error
+    // DeadLetter removes a delivery from the queue and keeps it in the dead letters.
+    DeadLetter(ctx context.Context, d domain.Delivery, reason string) error
+    ListDeadLetters(ctx context.Context, limit int) ([]domain.DeadLetter, error)
+    // ReplayDeadLetter queues a dead letter again with a fresh attempt count.
+    ReplayDeadLetter(ctx context.Context, id string) error
+}
+
+// ChatRuleRepository persists the expression rules of chats.
+type ChatRuleRepository interface {
+    // Create stores a rule and returns it with its ID assigned.
+    Create(ctx context.Context, rule domain.ChatRule) (domain.ChatRule, error)
+    ListByChat(ctx context.Context, chatID string) ([]domain.ChatRule, error)
+    Delete(ctx context.Context, chatID string, id string) error
+}
+
+// DigestRepository keeps the events collected for digests until they are sent.
+type DigestRepository interface {
+    // Add stores an entry; adding an entry with the same ID again is a no-op.
+    Add(ctx context.Context, entry domain.DigestEntry) error
+    // Claim leases the entries of up to limit digests due at or before now, whole digests at
+    // a time, hiding them from other schedulers until the lease ends. Entries that are not
+    // deleted by then are claimed again.
+    Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.DigestEntry, error)
+    Delete(ctx context.Context, ids []string) error
+}
@@ -0,0 +1,13 @@
+package ports
+
+import "github.com/you/wallet_transaction_notifier/internal/domain"
+
+// RuleEngine evaluates chat rules written in the rule expression language.
+type RuleEngine interface {
+    // Validate explains why an expression cannot be used, or returns nil.
+    Validate(expression string) error
+    // Observe records an event for the sliding-window aggregates such as count_outgoing(10m).
+    Observe(event domain.TransactionEvent)
+    // Match returns the first enabled rule the event satisfies.
+    Match(rules []domain.ChatRule, event domain.TransactionEvent) (domain.ChatRule, bool)
+}
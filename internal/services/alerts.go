@@ -0,0 +1,344 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "log"
+    "net/mail"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/netguard"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// AlertService manages the alert channels a chat is notified through besides the bot, and
+// which of them each subscription's alerts are routed to.
+type AlertService struct {
+    repo      ports.AlertRepository
+    subs      ports.SubscriptionRepository
+    notifiers []ports.Notifier
+}
+
+func NewAlertService(repo ports.AlertRepository, subs ports.SubscriptionRepository) *AlertService {
+    return &AlertService{repo: repo, subs: subs}
+}
+
+// UseNotifiers enables Test, which sends through the notifier of the channel's type.
+func (s *AlertService) UseNotifiers(notifiers ...ports.Notifier) {
+    s.notifiers = notifiers
+}
+
+// Create validates and stores an alert channel of the given type. Webhooks get a generated
+// signing secret, which is returned here and never shown again. A telegram channel is stored
+// unconfirmed and its chat is asked to accept the alerts; nothing is sent there until it
+// does. Invalid channels are rejected with an error wrapping domain.ErrInvalidAlert.
+func (s *AlertService) Create(ctx context.Context, owner string, alertType string, config map[string]any) (domain.Alert, error) {
+    alert := domain.Alert{UserID: owner, Type: strings.ToLower(strings.TrimSpace(alertType)), Config: config}
+    switch alert.Type {
+    case domain.ChannelWebhook:
+        if err := validateWebhookURL(ctx, alert.ConfigString("url")); err != nil {
+            return domain.Alert{}, fmt.Errorf("%w: %v", domain.ErrInvalidAlert, err)
+        }
+        secret, err := newWebhookSecret()
+        if err != nil {
+            return domain.Alert{}, err
+        }
+        alert.Config = map[string]any{"url": alert.ConfigString("url"), "secret": secret}
+    case domain.ChannelSlack, domain.ChannelDiscord:
+        if err := validateChatWebhookURL(alert.Type, alert.ConfigString("url")); err != nil {
+            return domain.Alert{}, fmt.Errorf("%w: %v", domain.ErrInvalidAlert, err)
+        }
+        alert.Config = map[string]any{"url": alert.ConfigString("url")}
+    case domain.ChannelEmail:
+        to, err := normalizeEmailList(alert.ConfigString("to"))
+        if err != nil {
+            return domain.Alert{}, fmt.Errorf("%w: %v", domain.ErrInvalidAlert, err)
+        }
+        alert.Config = map[string]any{"to": to}
+    case domain.ChannelTelegram:
+        chatID, err := telegramChatID(config["chatId"], owner)
+        if err != nil {
+            return domain.Alert{}, fmt.Errorf("%w: %v", domain.ErrInvalidAlert, err)
+        }
+        alert.Config = map[string]any{"chatId": chatID}
+        return s.createUnconfirmed(ctx, alert)
+    default:
+        return domain.Alert{}, fmt.Errorf("%w: unsupported type %q", domain.ErrInvalidAlert, alertType)
+    }
+    return s.repo.Create(ctx, alert)
+}
+
+// createUnconfirmed stores a disabled alert and asks its target to confirm it. An alert
+// whose target cannot be asked, e.g. a chat the bot is not a member of, is removed again.
+func (s *AlertService) createUnconfirmed(ctx context.Context, alert domain.Alert) (domain.Alert, error) {
+    confirmer, ok := s.confirmer(alert.Type)
+    if !ok {
+        return domain.Alert{}, fmt.Errorf("%w: no %s notifier is configured", domain.ErrChannelUnavailable, alert.Type)
+    }
+    code, err := newConfirmCode()
+    if err != nil {
+        return domain.Alert{}, err
+    }
+    alert.Disabled, alert.Unconfirmed, alert.ConfirmCode = true, true, code
+    alert, err = s.repo.Create(ctx, alert)
+    if err != nil {
+        return domain.Alert{}, err
+    }
+    if err := confirmer.RequestConfirmation(ctx, alert); err != nil {
+        if err := s.repo.Delete(ctx, alert.UserID, alert.ID); err != nil {
+            log.Printf("⚠️ Failed to remove unconfirmable %s alert %s of chat %s: %v", alert.Type, alert.ID, alert.UserID, err)
+        }
+        return domain.Alert{}, fmt.Errorf("%w: cannot post to chat %s, add the bot to it first: %v", domain.ErrInvalidAlert, alert.ConfigString("chatId"), err)
+    }
+    return alert, nil
+}
+
+// Confirm accepts the alerts of the unconfirmed telegram alert with ID id on behalf of its
+// target chat, which has to be chatID and send back the alert's code. It returns the alert.
+func (s *AlertService) Confirm(ctx context.Context, chatID string, id string, code string) (domain.Alert, error) {
+    alert, err := s.repo.Get(ctx, id)
+    if err != nil {
+        return domain.Alert{}, err
+    }
+    if alert.Type != domain.ChannelTelegram || alert.ConfigString("chatId") != chatID {
+        return domain.Alert{}, domain.ErrAlertNotFound
+    }
+    if err := s.repo.Confirm(ctx, id, code); err != nil {
+        return domain.Alert{}, err
+    }
+    alert.Disabled, alert.Unconfirmed, alert.ConfirmCode = false, false, ""
+    return alert, nil
+}
+
+func (s *AlertService) confirmer(channel string) (ports.AlertConfirmer, bool) {
+    for _, n := range s.notifiers {
+        if c, ok := n.(ports.AlertConfirmer); ok && n.Channel() == channel {
+            return c, true
+        }
+    }
+    return nil, false
+}
+
+// List returns the alert channels of owner without their secrets.
+func (s *AlertService) List(ctx context.Context, owner string) ([]domain.Alert, error) {
+    alerts, err := s.repo.ListByUser(ctx, owner)
+    if err != nil {
+        return nil, err
+    }
+    for i := range alerts {
+        alerts[i] = alerts[i].Redacted()
+    }
+    return alerts, nil
+}
+
+// Delete removes an alert channel and drops it from the routes of the owner's subscriptions.
+// Subscriptions routed only to it are routed to the owning chat.
+func (s *AlertService) Delete(ctx context.Context, owner string, id string) error {
+    if err := s.repo.Delete(ctx, owner, id); err != nil {
+        return err
+    }
+    if err := s.subs.RemoveSubscriptionChannel(ctx, owner, id); err != nil {
+        log.Printf("⚠️ Failed to remove deleted alert channel %s from the subscriptions of chat %s: %v", id, owner, err)
+    }
+    return nil
+}
+
+// Enable turns a disabled alert channel back on, e.g. after its endpoint was fixed. Channels
+// their chat has not confirmed yet stay off.
+func (s *AlertService) Enable(ctx context.Context, owner string, id string) error {
+    alert, err := s.get(ctx, owner, id)
+    if err != nil {
+        return err
+    }
+    if alert.Unconfirmed {
+        return domain.ErrAlertUnconfirmed
+    }
+    return s.repo.SetDisabled(ctx, owner, id, false)
+}
+
+func (s *AlertService) Disable(ctx context.Context, owner string, id string) error {
+    return s.repo.SetDisabled(ctx, owner, id, true)
+}
+
+// Test sends a sample alert to an alert channel right away, bypassing the delivery queue,
+// and returns the error of the notifier, if any.
+func (s *AlertService) Test(ctx context.Context, owner string, id string) error {
+    alert, err := s.get(ctx, owner, id)
+    if err != nil {
+        return err
+    }
+    if alert.Unconfirmed {
+        return domain.ErrAlertUnconfirmed
+    }
+    for _, n := range s.notifiers {
+        if n.Channel() == alert.Type {
+            _, err := n.Send(ctx, alert.Recipient(), sampleEvent(time.Now()))
+            return err
+        }
+    }
+    return fmt.Errorf("%w: no %s notifier is configured", domain.ErrChannelUnavailable, alert.Type)
+}
+
+// Route sends the alerts of one subscription only to the given channels: IDs of the owner's
+// alert channels, or domain.ChannelTelegram for the chat itself. No channels routes them
+// everywhere again.
+func (s *AlertService) Route(ctx context.Context, owner string, blockchain string, address string, channels []string) error {
+    alerts, err := s.repo.ListByUser(ctx, owner)
+    if err != nil {
+        return err
+    }
+    known := map[string]bool{domain.ChannelTelegram: true}
+    for _, a := range alerts {
+        known[a.ID] = true
+    }
+    var routes []string
+    seen := make(map[string]bool)
+    for _, c := range channels {
+        if !known[c] {
+            return fmt.Errorf("%w: unknown channel %q", domain.ErrInvalidAlert, c)
+        }
+        if !seen[c] {
+            seen[c] = true
+            routes = append(routes, c)
+        }
+    }
+    return s.subs.SetSubscriptionChannels(ctx, owner, blockchain, strings.ToLower(strings.TrimSpace(address)), routes)
+}
+
+// get returns an alert channel of owner; other owners' channels are reported as not found.
+func (s *AlertService) get(ctx context.Context, owner string, id string) (domain.Alert, error) {
+    alert, err := s.repo.Get(ctx, id)
+    if err != nil {
+        return domain.Alert{}, err
+    }
+    if alert.UserID != owner {
+        return domain.Alert{}, domain.ErrAlertNotFound
+    }
+    return alert, nil
+}
+
+// sampleEvent is the made-up transfer sent by Test. Its ID marks it as a test, so webhook
+// receivers can tell it from real events.
+func sampleEvent(now time.Time) domain.TransactionEvent {
+    return domain.TransactionEvent{
+        ID:         "test_" + strconv.FormatInt(now.UnixNano(), 36),
+        Blockchain: "ethereum",
+        WalletID:   "0x0000000000000000000000000000000000000000",
+        TxHash:     "0x" + strings.Repeat("0", 64),
+        Direction:  domain.DirectionIncoming,
+        Amount:     1.2345,
+        Currency:   "ETH",
+        Timestamp:  now.Unix(),
+    }
+}
+
+// validateWebhookURL accepts absolute http and https URLs whose host resolves to public
+// addresses only. The webhook notifier checks the address again when it connects.
+func validateWebhookURL(ctx context.Context, raw string) error {
+    if raw == "" {
+        return fmt.Errorf("url is required")
+    }
+    u, err := url.Parse(raw)
+    if err != nil {
+        return fmt.Errorf("url is not valid: %v", err)
+    }
+    if (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+        return fmt.Errorf("url must be an absolute http or https url")
+    }
+    if err := netguard.CheckHost(ctx, u.Hostname()); err != nil {
+        return fmt.Errorf("url must point to a public host: %v", err)
+    }
+    return nil
+}
+
+// chatWebhookHosts are the hosts Slack and Discord issue webhook URLs on, with the path
+// those URLs start with.
+var chatWebhookHosts = map[string]map[string]string{
+    domain.ChannelSlack: {
+        "hooks.slack.com": "/services/",
+    },
+    domain.ChannelDiscord: {
+        "discord.com":        "/api/webhooks/",
+        "discordapp.com":     "/api/webhooks/",
+        "ptb.discord.com":    "/api/webhooks/",
+        "canary.discord.com": "/api/webhooks/",
+    },
+}
+
+// validateChatWebhookURL accepts only webhook URLs issued by Slack or Discord, so these
+// channels cannot be used to post to arbitrary hosts.
+func validateChatWebhookURL(channel, raw string) error {
+    if raw == "" {
+        return fmt.Errorf("url is required")
+    }
+    u, err := url.Parse(raw)
+    if err != nil {
+        return fmt.Errorf("url is not valid: %v", err)
+    }
+    prefix, ok := chatWebhookHosts[channel][strings.ToLower(u.Host)]
+    if u.Scheme != "https" || !ok || !strings.HasPrefix(u.Path, prefix) {
+        return fmt.Errorf("url must be a %s webhook url", channel)
+    }
+    return nil
+}
+
+// normalizeEmailList checks a comma separated list of recipients and returns it in a form
+// net/mail parses back.
+func normalizeEmailList(raw string) (string, error) {
+    if strings.TrimSpace(raw) == "" {
+        return "", fmt.Errorf("to is required")
+    }
+    addresses, err := mail.ParseAddressList(raw)
+    if err != nil {
+        return "", fmt.Errorf("to is not a valid list of email addresses: %v", err)
+    }
+    list := make([]string, len(addresses))
+    for i, a := range addresses {
+        list[i] = a.String()
+    }
+    return strings.Join(list, ", "), nil
+}
+
+// telegramChatID checks the chat a telegram alert forwards to, given as a string or a JSON
+// number. The bot has to be a member of that chat to post there.
+func telegramChatID(raw any, owner string) (string, error) {
+    var chatID string
+    switch v := raw.(type) {
+    case string:
+        chatID = strings.TrimSpace(v)
+    case float64:
+        chatID = strconv.FormatFloat(v, 'f', -1, 64)
+    }
+    if chatID == "" {
+        return "", fmt.Errorf("chatId is required")
+    }
+    if _, err := strconv.ParseInt(chatID, 10, 64); err != nil {
+        return "", fmt.Errorf("chatId must be a numeric telegram chat id")
+    }
+    if chatID == owner {
+        return "", fmt.Errorf("chatId is the chat the channel would belong to")
+    }
+    return chatID, nil
+}
+
+func newConfirmCode() (string, error) {
+    b := make([]byte, 8)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(b), nil
+}
+
+func newWebhookSecret() (string, error) {
+    b := make([]byte, 32)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+    ctx := context.Background()
+    for _, raw := range []string{
+        "",
+        "ftp://93.184.216.34/hook",
+        "/hook",
+        "http://127.0.0.1:8080/hook",
+        "http://localhost/hook",
+        "http://[::1]/hook",
+        "http://10.0.0.5/hook",
+        "http://169.254.169.254/latest/meta-data/",
+        "http://[fd00:ec2::254]/latest/meta-data/",
+    } {
+        if err := validateWebhookURL(ctx, raw); err == nil {
+            t.Errorf("validateWebhookURL(%q) accepted", raw)
+        }
+    }
+    for _, raw := range []string{"https://93.184.216.34/hook", "http://[2606:4700:4700::1111]:8443/hook"} {
+        if err := validateWebhookURL(ctx, raw); err != nil {
+            t.Errorf("validateWebhookURL(%q) = %v", raw, err)
+        }
+    }
+}
+
+// fakeAlerts keeps alert channels in memory.
+type fakeAlerts struct {
+    ports.AlertRepository
+    mu     sync.Mutex
+    alerts map[string]domain.Alert
+    order  []string
+}
+
+func newFakeAlerts() *fakeAlerts {
+    return &fakeAlerts{alerts: make(map[string]domain.Alert)}
+}
+
+func (f *fakeAlerts) ListByUser(ctx context.Context, userID string) ([]domain.Alert, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    var alerts []domain.Alert
+    for _, id := range f.order {
+        if a, ok := f.alerts[id]; ok && a.UserID == userID {
+            alerts = append(alerts, a)
+        }
+    }
+    return alerts, nil
+}
+
+func (f *fakeAlerts) Get(ctx context.Context, id string) (domain.Alert, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    a, ok := f.alerts[id]
+    if !ok {
+        return domain.Alert{}, domain.ErrAlertNotFound
+    }
+    return a, nil
+}
+
+func (f *fakeAlerts) Create(ctx context.Context, alert domain.Alert) (domain.Alert, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if alert.ID == "" {
+        alert.ID = fmt.Sprintf("alert-%d", len(f.order)+1)
+    }
+    f.alerts[alert.ID] = alert
+    f.order = append(f.order, alert.ID)
+    return alert, nil
+}
+
+func (f *fakeAlerts) Delete(ctx context.Context, userID string, id string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if a, ok := f.alerts[id]; !ok || a.UserID != userID {
+        return domain.ErrAlertNotFound
+    }
+    delete(f.alerts, id)
+    return nil
+}
+
+func (f *fakeAlerts) SetDisabled(ctx context.Context, userID string, id string, disabled bool) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    a, ok := f.alerts[id]
+    if !ok || a.UserID != userID {
+        return domain.ErrAlertNotFound
+    }
+    a.Disabled = disabled
+    f.alerts[id] = a
+    return nil
+}
+
+func (f *fakeAlerts) Confirm(ctx context.Context, id string, code string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    a, ok := f.alerts[id]
+    if !ok || !a.Unconfirmed || a.ConfirmCode != code {
+        return domain.ErrAlertNotFound
+    }
+    a.Disabled, a.Unconfirmed, a.ConfirmCode = false, false, ""
+    f.alerts[id] = a
+    return nil
+}
+
+// fakeConfirmer records the confirmation requests of telegram alerts.
+type fakeConfirmer struct {
+    *fakeDigestNotifier
+    requests []domain.Alert
+    err      error
+}
+
+func (f *fakeConfirmer) RequestConfirmation(ctx context.Context, alert domain.Alert) error {
+    if f.err != nil {
+        return f.err
+    }
+    f.requests = append(f.requests, alert)
+    return nil
+}
+
+func newTestAlertService(confirmer *fakeConfirmer) (*AlertService, *fakeAlerts) {
+    repo := newFakeAlerts()
+    svc := NewAlertService(repo, &fakeSubscriptions{})
+    svc.UseNotifiers(confirmer)
+    return svc, repo
+}
+
+// A telegram channel stays off until its chat sends back the code of the request it got.
+func TestTelegramAlertNeedsConfirmation(t *testing.T) {
+    ctx := context.Background()
+    confirmer := &fakeConfirmer{fakeDigestNotifier: newFakeDigestNotifier(domain.ChannelTelegram)}
+    svc, repo := newTestAlertService(confirmer)
+
+    alert, err := svc.Create(ctx, "1", domain.ChannelTelegram, map[string]any{"chatId": "-100"})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !alert.Unconfirmed || !alert.Disabled || len(confirmer.requests) != 1 {
+        t.Fatalf("alert = %+v with %d requests, want an unconfirmed alert and one request", alert, len(confirmer.requests))
+    }
+    code := confirmer.requests[0].ConfirmCode
+    if code == "" {
+        t.Fatal("confirmation request has no code")
+    }
+
+    if err := svc.Enable(ctx, "1", alert.ID); !errors.Is(err, domain.ErrAlertUnconfirmed) {
+        t.Errorf("Enable = %v, want ErrAlertUnconfirmed", err)
+    }
+    if err := svc.Test(ctx, "1", alert.ID); !errors.Is(err, domain.ErrAlertUnconfirmed) {
+        t.Errorf("Test = %v, want ErrAlertUnconfirmed", err)
+    }
+    if _, err := svc.Confirm(ctx, "1", alert.ID, code); !errors.Is(err, domain.ErrAlertNotFound) {
+        t.Errorf("owner confirmed its own channel: %v", err)
+    }
+    if _, err := svc.Confirm(ctx, "-100", alert.ID, "wrong"); !errors.Is(err, domain.ErrAlertNotFound) {
+        t.Errorf("confirmed with a wrong code: %v", err)
+    }
+
+    if _, err := svc.Confirm(ctx, "-100", alert.ID, code); err != nil {
+        t.Fatal(err)
+    }
+    if stored, _ := repo.Get(ctx, alert.ID); stored.Unconfirmed || stored.Disabled {
+        t.Errorf("confirmed alert = %+v, want it enabled", stored)
+    }
+}
+
+// A channel whose chat cannot be asked, e.g. because the bot is not a member, is not kept.
+func TestTelegramAlertRemovedWhenChatUnreachable(t *testing.T) {
+    ctx := context.Background()
+    confirmer := &fakeConfirmer{fakeDigestNotifier: newFakeDigestNotifier(domain.ChannelTelegram), err: fmt.Errorf("chat not found")}
+    svc, repo := newTestAlertService(confirmer)
+
+    if _, err := svc.Create(ctx, "1", domain.ChannelTelegram, map[string]any{"chatId": "-100"}); !errors.Is(err, domain.ErrInvalidAlert) {
+        t.Errorf("Create = %v, want ErrInvalidAlert", err)
+    }
+    if alerts, _ := repo.ListByUser(ctx, "1"); len(alerts) != 0 {
+        t.Errorf("kept %d alerts", len(alerts))
+    }
+}
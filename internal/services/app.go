@@ -2,7 +2,11 @@ package services
 
 import (
     "context"
+    "errors"
+    "fmt"
     "log"
+    "strings"
+    "time"
 
     "github.com/you/wallet_transaction_notifier/internal/domain"
     "github.com/you/wallet_transaction_notifier/internal/ports"
@@ -14,38 +18,154 @@ type AppService struct {
     notifiers []ports.Notifier
     subs      ports.SubscriptionRepository
     notifs    ports.NotificationRepository
+    queue     ports.DeliveryQueue
+    rules     *ChatRuleService
+    digests   ports.DigestRepository
+    sessions  ports.SessionRepository
+    throttle  *ChatThrottle
+    alerts    ports.AlertRepository
+    prices    ports.PriceProvider
 }
 
 func NewAppService(eventBus ports.EventBus, subs ports.SubscriptionRepository, notifs ports.NotificationRepository, notifiers ...ports.Notifier) *AppService {
     return &AppService{eventBus: eventBus, subs: subs, notifs: notifs, notifiers: notifiers}
 }
 
+// UseDeliveryQueue makes the dispatcher queue alerts for a DeliveryWorker instead of
+// sending them itself, so failed sends are retried.
+func (a *AppService) UseDeliveryQueue(q ports.DeliveryQueue) {
+    a.queue = q
+}
+
+// UseChatRules makes the dispatcher apply the expression rules of each chat.
+func (a *AppService) UseChatRules(rules *ChatRuleService) {
+    a.rules = rules
+}
+
+// UseDigests lets subscriptions in hourly or daily mode collect their alerts for a
+// DigestScheduler instead of notifying right away.
+func (a *AppService) UseDigests(digests ports.DigestRepository) {
+    a.digests = digests
+}
+
+// UseChatSettings makes the dispatcher honour the snooze, quiet hours and time zone of each chat.
+func (a *AppService) UseChatSettings(sessions ports.SessionRepository) {
+    a.sessions = sessions
+}
+
+// UseThrottle rate limits the alerts of each chat, collapsing bursts into one summary.
+// Summaries are collected as digests, so it needs UseDigests as well.
+func (a *AppService) UseThrottle(throttle *ChatThrottle) {
+    a.throttle = throttle
+}
+
+// UseAlerts makes the dispatcher also notify the alert channels a chat registered, such
+// as webhooks, through the notifier of their type.
+func (a *AppService) UseAlerts(alerts ports.AlertRepository) {
+    a.alerts = alerts
+}
+
+// UsePrices values each event in the fiat currency of the chat it is notified to, for the
+// alerts, the notification log and value rules.
+func (a *AppService) UsePrices(prices ports.PriceProvider) {
+    a.prices = prices
+}
+
+// chatSettings loads the settings of a chat; without them alerts are delivered as usual.
+func (a *AppService) chatSettings(ctx context.Context, chatID string) domain.ChatSettings {
+    if a.sessions == nil {
+        return domain.ChatSettings{}
+    }
+    session, err := a.sessions.GetTelegramSession(ctx, chatID)
+    if err != nil {
+        log.Printf("⚠️ Failed to load settings of chat %s, ignoring them: %v", chatID, err)
+        return domain.ChatSettings{}
+    }
+    return session.Settings
+}
+
+// dispatcherGroup names the dispatcher on the event bus: the consumer group shared by
+// dispatchers on buses with acknowledgements, or the subscriber name on the in-memory bus.
+const dispatcherGroup = "dispatcher"
+
 func (a *AppService) Run(ctx context.Context) {
-    ch, unsubscribe := a.eventBus.Subscribe()
+    if bus, ok := a.eventBus.(ports.AckingEventBus); ok {
+        a.runAcked(ctx, bus)
+        return
+    }
+    var (
+        ch          <-chan domain.TransactionEvent
+        unsubscribe func()
+    )
+    if bus, ok := a.eventBus.(ports.NamedSubscriber); ok {
+        ch, unsubscribe = bus.SubscribeNamed(dispatcherGroup)
+    } else {
+        ch, unsubscribe = a.eventBus.Subscribe()
+    }
     defer unsubscribe()
     for {
         select {
         case <-ctx.Done():
             return
         case evt := <-ch:
-            a.dispatch(evt)
+            _ = a.dispatch(ctx, evt)
         }
     }
 }
 
-func (a *AppService) dispatch(evt domain.TransactionEvent) {
+// runAcked acknowledges an event only once it has been dispatched, so events are delivered
+// again if the lookup of subscribers fails or the process dies mid-way.
+func (a *AppService) runAcked(ctx context.Context, bus ports.AckingEventBus) {
+    ch, unsubscribe := bus.SubscribeGroup(dispatcherGroup)
+    defer unsubscribe()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case d, ok := <-ch:
+            if !ok {
+                return
+            }
+            if err := a.dispatch(ctx, d.Event); err != nil {
+                continue
+            }
+            if err := d.Ack(); err != nil {
+                log.Printf("failed to ack event for tx %s: %v", d.Event.TxHash, err)
+            }
+        }
+    }
+}
+
+func (a *AppService) dispatch(ctx context.Context, evt domain.TransactionEvent) error {
+    // Events published before IDs existed may still be in a persistent bus.
+    evt = evt.WithID()
+
     // Look up subscribers by address on this chain and notify each chat
-    subs, err := a.subs.ListSubscribersByAddress(context.Background(), evt.Blockchain, evt.WalletID)
+    subs, err := a.subs.ListSubscribersByAddress(ctx, evt.Blockchain, evt.WalletID)
     if err != nil {
         log.Printf("list subs error: %v", err)
-        return
+        return err
     }
     
     for _, s := range subs {
+        settings := a.chatSettings(ctx, s.ChatID)
+        evt := a.withFiatValue(ctx, evt, settings.Fiat())
+        if a.rules != nil {
+            a.rules.Observe(evt)
+        }
+        if !s.Rules.Allows(evt) {
+            log.Printf("Event %s filtered out by the rules of chat %s", evt.ID, s.ChatID)
+            continue
+        }
+        if a.rules != nil && !a.rules.Allows(ctx, s.ChatID, evt) {
+            log.Printf("Event %s matched none of the rules of chat %s", evt.ID, s.ChatID)
+            continue
+        }
         log.Printf("Processing notification for chat %s, address %s", s.ChatID, evt.WalletID)
         
         // Save notification
         notification := domain.Notification{
+            EventID:    evt.ID,
             ChatID:     s.ChatID,
             Blockchain: evt.Blockchain,
             Address:    evt.WalletID,
@@ -54,33 +174,354 @@ func (a *AppService) dispatch(evt domain.TransactionEvent) {
             Amount:     evt.Amount,
             Currency:   evt.Currency,
             Timestamp:  evt.Timestamp,
+            FiatValue:    evt.FiatValue,
+            FiatCurrency: evt.FiatCurrency,
+            Status:        evt.Status,
+            Confirmations: evt.Confirmations,
         }
         
         log.Printf("Attempting to save notification: %+v", notification)
-        if err := a.notifs.Save(context.Background(), notification); err != nil {
+        err := a.notifs.Save(ctx, notification)
+        if errors.Is(err, domain.ErrDuplicateNotification) {
+            // Already delivered, e.g. a block processed again after a restart or a redelivery,
+            // unless the transaction moved on since.
+            a.advance(ctx, s.ChatID, evt)
+            continue
+        }
+        if err != nil {
             log.Printf("❌ Failed to save notification for chat %s: %v", s.ChatID, err)
         } else {
             log.Printf("✅ Successfully saved notification for chat %s", s.ChatID)
         }
-        for _, n := range a.notifiers {
-            if err := n.SendAlert(s.ChatID, evt); err != nil {
-                log.Printf("notifier error: %v", err)
+        now := time.Now()
+        a.notifyAlerts(ctx, s, evt, now, settings)
+        if !s.SendsTo(domain.ChannelTelegram) {
+            continue
+        }
+        chat := domain.Recipient{Channel: domain.ChannelTelegram, ID: s.ChatID}
+        if s.Muted(now) || settings.Snoozed(now) {
+            log.Printf("Event %s not sent: chat %s muted it", evt.ID, s.ChatID)
+            recordDelivery(ctx, a.notifs, evt.ID, s.ChatID, domain.ChannelTelegram, domain.DeliveryStatus{State: domain.DeliveryMuted})
+            continue
+        }
+        if s.Mode.IsDigest() && a.collect(ctx, s.ChatID, chat, s.Mode, evt, digestDueAt(s.Mode, now, settings), settings.Timezone) {
+            continue
+        }
+        if settings.Quiet(now, evt) {
+            due := settings.QuietHours.NextEnd(now.In(settings.Location()))
+            if settings.QuietHours.Digest && a.collect(ctx, s.ChatID, chat, domain.DeliveryHeld, evt, due, settings.Timezone) {
+                continue
+            }
+            if !settings.QuietHours.Digest {
+                log.Printf("Event %s not sent: quiet hours of chat %s", evt.ID, s.ChatID)
+                recordDelivery(ctx, a.notifs, evt.ID, s.ChatID, domain.ChannelTelegram, domain.DeliveryStatus{State: domain.DeliveryMuted})
+                continue
+            }
+        }
+        if a.throttle != nil {
+            if ok, due := a.throttle.Allow(s.ChatID, now); !ok && a.collect(ctx, s.ChatID, chat, domain.DeliveryBurst, evt, due, settings.Timezone) {
+                continue
             }
         }
+        a.notify(ctx, s.ChatID, chat, evt)
+    }
+    return nil
+}
+
+// advance edits the messages already sent about an event to show its transaction state if
+// it is a later one than the chat's notification records, rather than sending new ones. With
+// a delivery queue the edits are queued and retried, an alert still waiting in the queue is
+// sent with the new state instead, and the worker records the state once a message shows it.
+// Without one the messages are edited right away, and the state is only recorded if every
+// edit succeeded, so the next event about the transaction tries again.
+func (a *AppService) advance(ctx context.Context, chatID string, evt domain.TransactionEvent) {
+    n, err := a.notifs.Get(ctx, evt.ID, chatID)
+    if err != nil {
+        log.Printf("❌ Failed to load the notification of event %s for chat %s: %v", evt.ID, chatID, err)
+        return
+    }
+    if !evt.Advances(n.Status, n.Confirmations) {
+        log.Printf("Skipping event %s for chat %s: already notified", evt.ID, chatID)
+        return
+    }
+    queued, failed := 0, 0
+    for key, status := range n.Deliveries {
+        to := domain.DeliveryRecipient(chatID, key)
+        editor := a.editor(to.Channel)
+        if editor == nil || status.Digest {
+            continue
+        }
+        if status.State == domain.DeliveryQueued && a.queue != nil {
+            updated, err := a.queue.UpdateEvent(ctx, domain.DeliveryID(evt.ID, to), evt)
+            if err != nil {
+                log.Printf("❌ Failed to update queued delivery of event %s for %s: %v", evt.ID, to.ID, err)
+            }
+            if updated {
+                log.Printf("Queued %s alert for %s will show event %s as it is now", to.Channel, to.ID, evt.ID)
+                queued++
+                continue
+            }
+            // Claimed by a worker: edit the message once it has been sent.
+        } else if status.State != domain.DeliverySent || status.MessageID == "" {
+            continue
+        }
+        if a.queue != nil {
+            err := a.queue.Enqueue(ctx, domain.Delivery{
+                ID:        domain.DeliveryEditID(evt, to),
+                ChatID:    chatID,
+                Recipient: to,
+                Event:     evt,
+                Edit:      true,
+            })
+            if err == nil {
+                queued++
+                continue
+            }
+            log.Printf("❌ Failed to queue edit of %s message for %s, editing directly: %v", to.Channel, to.ID, err)
+        }
+        if status.State != domain.DeliverySent {
+            failed++
+            continue
+        }
+        if err := editor.Edit(ctx, to, status.MessageID, evt); err != nil {
+            log.Printf("❌ Failed to edit %s message %s for %s: %v", to.Channel, status.MessageID, to.ID, err)
+            failed++
+            continue
+        }
+        log.Printf("✅ Edited %s message %s for %s as event %s changed", to.Channel, status.MessageID, to.ID, evt.ID)
+    }
+    if queued > 0 || failed > 0 {
+        return
+    }
+    commitStatus(ctx, a.notifs, n, evt)
+}
+
+// editor returns the notifier that edits the messages of channel, or nil.
+func (a *AppService) editor(channel string) ports.MessageEditor {
+    for _, n := range a.notifiers {
+        if editor, ok := n.(ports.MessageEditor); ok && n.Channel() == channel {
+            return editor
+        }
+    }
+    return nil
+}
+
+// commitStatus records that the messages about the notification n show the transaction
+// state of evt, unless it already records a later one.
+func commitStatus(ctx context.Context, notifs ports.NotificationRepository, n domain.Notification, evt domain.TransactionEvent) {
+    chatID := n.ChatID
+    // Another message of the notification may record its state at the same time.
+    for i := 0; i < 3 && evt.Advances(n.Status, n.Confirmations); i++ {
+        updated, err := notifs.UpdateStatus(ctx, n, evt.Status, evt.Confirmations)
+        if err != nil {
+            log.Printf("❌ Failed to update the status of event %s for chat %s: %v", evt.ID, chatID, err)
+            return
+        }
+        if updated {
+            return
+        }
+        if n, err = notifs.Get(ctx, evt.ID, chatID); err != nil {
+            log.Printf("❌ Failed to load the notification of event %s for chat %s: %v", evt.ID, chatID, err)
+            return
+        }
+    }
+}
+
+// withFiatValue values the event in the fiat currency at block time. Events that cannot be
+// priced are passed on as they are.
+func (a *AppService) withFiatValue(ctx context.Context, evt domain.TransactionEvent, fiat string) domain.TransactionEvent {
+    if a.prices == nil {
+        return evt
+    }
+    asset, ok := evt.Asset()
+    if !ok {
+        return evt
+    }
+    at := time.Unix(evt.Timestamp, 0)
+    if evt.Timestamp == 0 {
+        at = time.Now()
+    }
+    price, err := a.prices.Price(ctx, asset, fiat, at)
+    if err != nil {
+        if !errors.Is(err, domain.ErrPriceUnknown) {
+            log.Printf("⚠️ Failed to price %s in %s, notifying without its value: %v", asset, fiat, err)
+        }
+        return evt
+    }
+    return evt.WithFiatValue(price, fiat)
+}
+
+// digestDueAt returns when a digest collecting an event at now is sent in the chat's time
+// zone, postponed to the end of quiet hours if it would fall inside them.
+func digestDueAt(mode domain.DeliveryMode, now time.Time, settings domain.ChatSettings) time.Time {
+    due := domain.NextDigestAt(mode, now, settings.Location())
+    if settings.QuietHours != nil && settings.QuietHours.Contains(due) {
+        due = settings.QuietHours.NextEnd(due)
+    }
+    return due
+}
+
+// collect adds the event to the digest of the given mode due at due that chatID's recipient
+// to gets. It reports false when the event could not be stored, so the caller notifies right
+// away instead.
+func (a *AppService) collect(ctx context.Context, chatID string, to domain.Recipient, mode domain.DeliveryMode, evt domain.TransactionEvent, due time.Time, timezone string) bool {
+    if a.digests == nil {
+        return false
+    }
+    key := domain.DeliveryKey(chatID, to)
+    err := a.digests.Add(ctx, domain.DigestEntry{
+        ID:        domain.DigestEntryID(evt.ID, chatID, key),
+        ChatID:    chatID,
+        Recipient: to,
+        Mode:      mode,
+        Event:     evt,
+        DueAt:     due,
+        Timezone:  timezone,
+    })
+    if err != nil {
+        log.Printf("❌ Failed to add event %s to the %s digest of %s for chat %s, notifying now: %v", evt.ID, mode, key, chatID, err)
+        return false
+    }
+    recordDelivery(ctx, a.notifs, evt.ID, chatID, key, domain.DeliveryStatus{State: domain.DeliveryQueued, Digest: true})
+    return true
+}
+
+// notifyAlerts delivers the event to the enabled alert channels of the subscription's chat
+// it is routed to. A subscription in hourly or daily mode collects the alerts of channels
+// that can take a digest, such as email, into one per channel. Mutes, quiet hours and rate
+// limits are settings of the chat itself, so they do not apply.
+func (a *AppService) notifyAlerts(ctx context.Context, s domain.Subscription, evt domain.TransactionEvent, now time.Time, settings domain.ChatSettings) {
+    if a.alerts == nil {
+        return
+    }
+    alerts, err := a.alerts.ListByUser(ctx, s.ChatID)
+    if err != nil {
+        log.Printf("❌ Failed to load alert channels of chat %s: %v", s.ChatID, err)
+        return
+    }
+    for _, alert := range alerts {
+        if alert.Disabled || !s.SendsTo(alert.ID) || !a.hasNotifier(alert.Type) {
+            continue
+        }
+        to := alert.Recipient()
+        if s.Mode.IsDigest() && a.sendsDigests(to.Channel) && a.collect(ctx, s.ChatID, to, s.Mode, evt, domain.NextDigestAt(s.Mode, now, settings.Location()), settings.Timezone) {
+            continue
+        }
+        a.notify(ctx, s.ChatID, to, evt)
+    }
+}
+
+func (a *AppService) hasNotifier(channel string) bool {
+    for _, n := range a.notifiers {
+        if n.Channel() == channel {
+            return true
+        }
+    }
+    return false
+}
+
+// sendsDigests reports whether the notifier of channel can send a digest as one message.
+func (a *AppService) sendsDigests(channel string) bool {
+    for _, n := range a.notifiers {
+        if _, ok := n.(ports.DigestNotifier); ok && n.Channel() == channel {
+            return true
+        }
+    }
+    return false
+}
+
+// notify queues the alert for the recipient or, without a queue, hands it to the notifiers
+// serving the recipient's channel, once each. The outcome is recorded on chatID's notification.
+func (a *AppService) notify(ctx context.Context, chatID string, to domain.Recipient, evt domain.TransactionEvent) {
+    if a.queue != nil {
+        // Record the delivery as queued first: a worker may send it before Enqueue returns,
+        // and its status must not be overwritten.
+        recordDelivery(ctx, a.notifs, evt.ID, chatID, domain.DeliveryKey(chatID, to), domain.DeliveryStatus{State: domain.DeliveryQueued})
+        err := a.queue.Enqueue(ctx, domain.Delivery{
+            ID:        domain.DeliveryID(evt.ID, to),
+            ChatID:    chatID,
+            Recipient: to,
+            Event:     evt,
+        })
+        if err == nil {
+            return
+        }
+        // The notification is already recorded, so a redelivered event would be skipped
+        // as a duplicate. Send right away instead of losing the alert.
+        log.Printf("❌ Failed to queue delivery for %s, sending directly: %v", to.ID, err)
+    }
+    for _, n := range a.notifiers {
+        if n.Channel() != to.Channel {
+            continue
+        }
+        messageID, err := n.Send(ctx, to, evt)
+        status := domain.DeliveryStatus{State: domain.DeliverySent, MessageID: messageID, Attempts: 1}
+        if err != nil {
+            log.Printf("❌ %s notifier failed for %s: %v", n.Channel(), to.ID, err)
+            status = domain.DeliveryStatus{State: domain.DeliveryFailed, Attempts: 1, Error: err.Error()}
+        }
+        recordDelivery(ctx, a.notifs, evt.ID, chatID, domain.DeliveryKey(chatID, to), status)
+    }
+}
+
+// recordDelivery stores a delivery status on the notification under key, see
+// domain.DeliveryKey; failures are only logged.
+func recordDelivery(ctx context.Context, notifs ports.NotificationRepository, eventID, chatID, key string, status domain.DeliveryStatus) {
+    if notifs == nil {
+        return
+    }
+    if err := notifs.UpdateDelivery(ctx, eventID, chatID, key, status); err != nil {
+        log.Printf("failed to record %s delivery status for chat %s: %v", key, chatID, err)
     }
 }
 
 // APIService defines application use cases exposed to HTTP handlers.
 type APIService struct {
     wallets ports.WalletRepository
+    notifs  ports.NotificationRepository
 }
 
-func NewAPIService(wallets ports.WalletRepository) *APIService {
-    return &APIService{wallets: wallets}
+func NewAPIService(wallets ports.WalletRepository, notifs ports.NotificationRepository) *APIService {
+    return &APIService{wallets: wallets, notifs: notifs}
 }
 
 func (s *APIService) ListUserWallets(ctx context.Context, userID string) ([]domain.Wallet, error) {
     return s.wallets.ListByUser(ctx, userID)
 }
 
+// AddWallet gives the user an address. The wallet repository announces it as a subscription
+// change, on which watchers start watching the address and the live feed streams its events
+// to the user. Invalid addresses are rejected with an error wrapping domain.ErrInvalidWallet.
+func (s *APIService) AddWallet(ctx context.Context, userID string, blockchain string, address string) (domain.Wallet, error) {
+    blockchain = strings.ToLower(strings.TrimSpace(blockchain))
+    address = strings.ToLower(strings.TrimSpace(address))
+    if !validAddress(blockchain, address) {
+        return domain.Wallet{}, fmt.Errorf("%w: %q is not a valid %s address", domain.ErrInvalidWallet, address, blockchain)
+    }
+    return s.wallets.Create(ctx, domain.Wallet{UserID: userID, Blockchain: blockchain, Address: address})
+}
+
+func (s *APIService) DeleteWallet(ctx context.Context, userID string, id string) error {
+    return s.wallets.Delete(ctx, userID, id)
+}
+
+// validAddress does a basic format check of an address on a supported chain.
+func validAddress(blockchain, address string) bool {
+    switch blockchain {
+    case "ethereum":
+        return len(address) == 42 && strings.HasPrefix(address, "0x")
+    case "bitcoin":
+        return len(address) >= 26 && len(address) <= 35
+    default:
+        return false
+    }
+}
 
+// ListChatNotifications returns a chat's latest notifications with their delivery status,
+// optionally narrowed to one address.
+func (s *APIService) ListChatNotifications(ctx context.Context, chatID string, blockchain string, address string, limit int) ([]domain.Notification, error) {
+    if address != "" {
+        return s.notifs.ListByAddress(ctx, chatID, blockchain, address, limit)
+    }
+    return s.notifs.ListByChat(ctx, chatID, limit)
+}
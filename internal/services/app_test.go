@@ -0,0 +1,498 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+
+    tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+    "github.com/you/wallet_transaction_notifier/internal/adapters/notifiers"
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/eventbus"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// fakeSubscriptions returns a fixed list of subscribers for every address. Methods the
+// dispatcher does not use are left to the embedded nil interface.
+type fakeSubscriptions struct {
+    ports.SubscriptionRepository
+    subs []domain.Subscription
+}
+
+func (f *fakeSubscriptions) ListSubscribersByAddress(ctx context.Context, blockchain string, address string) ([]domain.Subscription, error) {
+    return f.subs, nil
+}
+
+// fakeNotifications keeps notifications in memory and, like the Mongo repository, refuses a
+// second notification about the same event for a chat.
+type fakeNotifications struct {
+    ports.NotificationRepository
+    mu     sync.Mutex
+    notifs map[string]domain.Notification
+}
+
+func newFakeNotifications() *fakeNotifications {
+    return &fakeNotifications{notifs: make(map[string]domain.Notification)}
+}
+
+func (f *fakeNotifications) Save(ctx context.Context, n domain.Notification) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    key := n.EventID + "/" + n.ChatID
+    if _, ok := f.notifs[key]; ok {
+        return domain.ErrDuplicateNotification
+    }
+    f.notifs[key] = n
+    return nil
+}
+
+func (f *fakeNotifications) Get(ctx context.Context, eventID string, chatID string) (domain.Notification, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    n, ok := f.notifs[eventID+"/"+chatID]
+    if !ok {
+        return domain.Notification{}, domain.ErrNotificationNotFound
+    }
+    return n, nil
+}
+
+func (f *fakeNotifications) UpdateDelivery(ctx context.Context, eventID string, chatID string, key string, status domain.DeliveryStatus) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    n, ok := f.notifs[eventID+"/"+chatID]
+    if !ok {
+        return domain.ErrNotificationNotFound
+    }
+    deliveries := make(map[string]domain.DeliveryStatus, len(n.Deliveries)+1)
+    for k, v := range n.Deliveries {
+        deliveries[k] = v
+    }
+    deliveries[key] = status
+    n.Deliveries = deliveries
+    f.notifs[eventID+"/"+chatID] = n
+    return nil
+}
+
+func (f *fakeNotifications) UpdateStatus(ctx context.Context, n domain.Notification, status domain.EventStatus, confirmations int) (bool, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    key := n.EventID + "/" + n.ChatID
+    current, ok := f.notifs[key]
+    if !ok || current.Status != n.Status || current.Confirmations != n.Confirmations {
+        return false, nil
+    }
+    current.Status = status
+    current.Confirmations = confirmations
+    f.notifs[key] = current
+    return true, nil
+}
+
+// fakeTelegramAPI records the messages sent and edited through it. Edits fail with editErr
+// when it is set.
+type fakeTelegramAPI struct {
+    mu      sync.Mutex
+    sent    map[int64]int
+    edited  map[int64]int
+    editErr error
+    next    int
+}
+
+func newFakeTelegramAPI() *fakeTelegramAPI {
+    return &fakeTelegramAPI{sent: make(map[int64]int), edited: make(map[int64]int)}
+}
+
+func (f *fakeTelegramAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if edit, ok := c.(tgbotapi.EditMessageTextConfig); ok {
+        if f.editErr != nil {
+            return tgbotapi.Message{}, f.editErr
+        }
+        f.edited[edit.ChatID]++
+        return tgbotapi.Message{MessageID: edit.MessageID, Chat: &tgbotapi.Chat{ID: edit.ChatID}}, nil
+    }
+    msg, ok := c.(tgbotapi.MessageConfig)
+    if !ok {
+        return tgbotapi.Message{}, nil
+    }
+    f.sent[msg.ChatID]++
+    f.next++
+    return tgbotapi.Message{MessageID: f.next, Chat: &tgbotapi.Chat{ID: msg.ChatID}}, nil
+}
+
+func (f *fakeTelegramAPI) edits() map[int64]int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    edits := make(map[int64]int, len(f.edited))
+    for chatID, n := range f.edited {
+        edits[chatID] = n
+    }
+    return edits
+}
+
+func (f *fakeTelegramAPI) sends() map[int64]int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    sends := make(map[int64]int, len(f.sent))
+    for chatID, n := range f.sent {
+        sends[chatID] = n
+    }
+    return sends
+}
+
+// fakeQueue holds deliveries until they are claimed, and claimed ones until they are
+// completed or released for a retry.
+type fakeQueue struct {
+    ports.DeliveryQueue
+    mu         sync.Mutex
+    deliveries []domain.Delivery
+    claimed    map[string]domain.Delivery
+    dead       []string
+}
+
+func (f *fakeQueue) Enqueue(ctx context.Context, d domain.Delivery) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    for _, queued := range f.deliveries {
+        if queued.ID == d.ID {
+            return nil
+        }
+    }
+    f.deliveries = append(f.deliveries, d)
+    return nil
+}
+
+func (f *fakeQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]domain.Delivery, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    claimed := f.deliveries
+    f.deliveries = nil
+    if f.claimed == nil {
+        f.claimed = make(map[string]domain.Delivery)
+    }
+    for _, d := range claimed {
+        f.claimed[d.ID] = d
+    }
+    return claimed, nil
+}
+
+func (f *fakeQueue) Complete(ctx context.Context, id string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    delete(f.claimed, id)
+    return nil
+}
+
+func (f *fakeQueue) UpdateEvent(ctx context.Context, id string, evt domain.TransactionEvent) (bool, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    for i := range f.deliveries {
+        if f.deliveries[i].ID == id {
+            f.deliveries[i].Event = evt
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+func (f *fakeQueue) Retry(ctx context.Context, id string, next time.Time, lastErr string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    d, ok := f.claimed[id]
+    if !ok {
+        return nil
+    }
+    delete(f.claimed, id)
+    d.Attempts++
+    d.LastError = lastErr
+    f.deliveries = append(f.deliveries, d)
+    return nil
+}
+
+func (f *fakeQueue) DeadLetter(ctx context.Context, d domain.Delivery, reason string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    delete(f.claimed, d.ID)
+    f.dead = append(f.dead, d.ID)
+    return nil
+}
+
+func subscription(chatID string) domain.Subscription {
+    return domain.Subscription{ChatID: chatID, Blockchain: "ethereum", Address: "0xabc"}
+}
+
+func testEvent() domain.TransactionEvent {
+    return domain.TransactionEvent{
+        Blockchain: "ethereum",
+        WalletID:   "0xabc",
+        TxHash:     "0x1",
+        Direction:  "incoming",
+        Amount:     1.5,
+        Currency:   "ETH",
+        Timestamp:  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).Unix(),
+    }.WithID()
+}
+
+var dispatchTests = []struct {
+    name string
+    subs []domain.Subscription
+    want map[int64]int
+}{
+    {"one subscriber", []domain.Subscription{subscription("1")}, map[int64]int{1: 1}},
+    {"several subscribers", []domain.Subscription{subscription("1"), subscription("2"), subscription("3")}, map[int64]int{1: 1, 2: 1, 3: 1}},
+    {"duplicate subscription", []domain.Subscription{subscription("1"), subscription("2"), subscription("1")}, map[int64]int{1: 1, 2: 1}},
+}
+
+func assertSends(t *testing.T, got, want map[int64]int) {
+    t.Helper()
+    if len(got) != len(want) {
+        t.Errorf("messages sent to %v, want %v", got, want)
+    }
+    for chatID, n := range want {
+        if got[chatID] != n {
+            t.Errorf("chat %d got %d messages, want %d", chatID, got[chatID], n)
+        }
+    }
+}
+
+// Every subscribed chat gets exactly one message per event, however many other chats
+// subscribe to the address and however often the event is dispatched.
+func TestDispatchSendsOncePerChat(t *testing.T) {
+    for _, tt := range dispatchTests {
+        t.Run(tt.name, func(t *testing.T) {
+            api := newFakeTelegramAPI()
+            app := NewAppService(eventbus.NewInMemoryEventBus(), &fakeSubscriptions{subs: tt.subs}, newFakeNotifications(), notifiers.NewTelegramNotifierWithAPI(api))
+
+            ctx := context.Background()
+            evt := testEvent()
+            for i := 0; i < 2; i++ {
+                if err := app.dispatch(ctx, evt); err != nil {
+                    t.Fatal(err)
+                }
+            }
+            assertSends(t, api.sends(), tt.want)
+        })
+    }
+}
+
+func TestDispatchQueuesOncePerChat(t *testing.T) {
+    for _, tt := range dispatchTests {
+        t.Run(tt.name, func(t *testing.T) {
+            api := newFakeTelegramAPI()
+            notifs := newFakeNotifications()
+            queue := &fakeQueue{}
+            telegram := notifiers.NewTelegramNotifierWithAPI(api)
+            app := NewAppService(eventbus.NewInMemoryEventBus(), &fakeSubscriptions{subs: tt.subs}, notifs, telegram)
+            app.UseDeliveryQueue(queue)
+            worker := NewDeliveryWorker(queue, notifs, DeliveryOptions{}, telegram)
+
+            ctx := context.Background()
+            evt := testEvent()
+            for i := 0; i < 2; i++ {
+                if err := app.dispatch(ctx, evt); err != nil {
+                    t.Fatal(err)
+                }
+                deliveries, _ := queue.Claim(ctx, 0, 0)
+                for _, d := range deliveries {
+                    worker.process(ctx, d)
+                }
+            }
+            assertSends(t, api.sends(), tt.want)
+
+            for chatID := range tt.want {
+                n, err := notifs.Get(ctx, evt.ID, strconv.FormatInt(chatID, 10))
+                if err != nil {
+                    t.Fatal(err)
+                }
+                if got := n.Deliveries[domain.ChannelTelegram].State; got != domain.DeliverySent {
+                    t.Errorf("chat %d delivery is %s, want %s", chatID, got, domain.DeliverySent)
+                }
+            }
+        })
+    }
+}
+
+// workingQueue sends deliveries as soon as they are queued, like a worker that is faster
+// than the dispatcher.
+type workingQueue struct {
+    fakeQueue
+    worker *DeliveryWorker
+}
+
+func (q *workingQueue) Enqueue(ctx context.Context, d domain.Delivery) error {
+    q.worker.process(ctx, d)
+    return nil
+}
+
+func TestDispatchKeepsStatusOfFastDelivery(t *testing.T) {
+    api := newFakeTelegramAPI()
+    notifs := newFakeNotifications()
+    telegram := notifiers.NewTelegramNotifierWithAPI(api)
+    queue := &workingQueue{}
+    queue.worker = NewDeliveryWorker(queue, notifs, DeliveryOptions{}, telegram)
+    app := NewAppService(eventbus.NewInMemoryEventBus(), &fakeSubscriptions{subs: []domain.Subscription{subscription("1")}}, notifs, telegram)
+    app.UseDeliveryQueue(queue)
+
+    ctx := context.Background()
+    evt := testEvent()
+    if err := app.dispatch(ctx, evt); err != nil {
+        t.Fatal(err)
+    }
+    n, err := notifs.Get(ctx, evt.ID, "1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := n.Deliveries[domain.ChannelTelegram]; got.State != domain.DeliverySent || got.MessageID == "" {
+        t.Errorf("delivery is %+v, want it sent", got)
+    }
+}
+
+// newUpdateTest returns a dispatcher queueing alerts for chat 1 and the worker sending them.
+func newUpdateTest() (*AppService, *DeliveryWorker, *fakeQueue, *fakeTelegramAPI, *fakeNotifications) {
+    api := newFakeTelegramAPI()
+    notifs := newFakeNotifications()
+    queue := &fakeQueue{}
+    telegram := notifiers.NewTelegramNotifierWithAPI(api)
+    app := NewAppService(eventbus.NewInMemoryEventBus(), &fakeSubscriptions{subs: []domain.Subscription{subscription("1")}}, notifs, telegram)
+    app.UseDeliveryQueue(queue)
+    return app, NewDeliveryWorker(queue, notifs, DeliveryOptions{}, telegram), queue, api, notifs
+}
+
+// runQueue processes the deliveries that are queued now.
+func runQueue(ctx context.Context, queue *fakeQueue, worker *DeliveryWorker) {
+    deliveries, _ := queue.Claim(ctx, 0, 0)
+    for _, d := range deliveries {
+        worker.process(ctx, d)
+    }
+}
+
+func pendingEvent() domain.TransactionEvent {
+    evt := testEvent()
+    evt.Status = domain.StatusPending
+    return evt
+}
+
+func minedEvent(confirmations int) domain.TransactionEvent {
+    evt := testEvent()
+    evt.Confirmations = confirmations
+    return evt
+}
+
+func assertStatus(t *testing.T, notifs *fakeNotifications, status domain.EventStatus, confirmations int) {
+    t.Helper()
+    n, err := notifs.Get(context.Background(), testEvent().ID, "1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if n.Status != status || n.Confirmations != confirmations {
+        t.Errorf("notification records %q with %d confirmations, want %q with %d", n.Status, n.Confirmations, status, confirmations)
+    }
+}
+
+func TestAdvanceEditsThroughQueue(t *testing.T) {
+    app, worker, queue, api, notifs := newUpdateTest()
+    ctx := context.Background()
+
+    app.dispatch(ctx, pendingEvent())
+    runQueue(ctx, queue, worker)
+    app.dispatch(ctx, minedEvent(1))
+    assertStatus(t, notifs, domain.StatusPending, 0)
+
+    runQueue(ctx, queue, worker)
+    assertStatus(t, notifs, "", 1)
+    app.dispatch(ctx, minedEvent(1))
+    runQueue(ctx, queue, worker)
+    app.dispatch(ctx, minedEvent(12))
+    runQueue(ctx, queue, worker)
+
+    assertSends(t, api.sends(), map[int64]int{1: 1})
+    if got := api.edits()[1]; got != 2 {
+        t.Errorf("message edited %d times, want 2", got)
+    }
+    assertStatus(t, notifs, "", 12)
+}
+
+func TestAdvanceKeepsStatusUntilEditSucceeds(t *testing.T) {
+    app, worker, queue, api, notifs := newUpdateTest()
+    ctx := context.Background()
+
+    app.dispatch(ctx, pendingEvent())
+    runQueue(ctx, queue, worker)
+    api.editErr = errors.New("connection reset")
+    app.dispatch(ctx, minedEvent(1))
+    runQueue(ctx, queue, worker)
+    assertStatus(t, notifs, domain.StatusPending, 0)
+    if len(queue.deliveries) != 1 || !queue.deliveries[0].Edit {
+        t.Fatalf("queue holds %+v, want the edit to retry", queue.deliveries)
+    }
+
+    api.editErr = nil
+    runQueue(ctx, queue, worker)
+    assertStatus(t, notifs, "", 1)
+    if got := api.edits()[1]; got != 1 {
+        t.Errorf("message edited %d times, want 1", got)
+    }
+}
+
+func TestAdvanceSendsQueuedAlertWithNewState(t *testing.T) {
+    app, worker, queue, api, notifs := newUpdateTest()
+    ctx := context.Background()
+
+    app.dispatch(ctx, pendingEvent())
+    app.dispatch(ctx, minedEvent(1))
+    deliveries, _ := queue.Claim(ctx, 0, 0)
+    if len(deliveries) != 1 || deliveries[0].Edit || deliveries[0].Event.Confirmations != 1 {
+        t.Fatalf("queue holds %+v, want the alert with one confirmation", deliveries)
+    }
+    worker.process(ctx, deliveries[0])
+
+    assertSends(t, api.sends(), map[int64]int{1: 1})
+    if got := api.edits()[1]; got != 0 {
+        t.Errorf("message edited %d times, want 0", got)
+    }
+    assertStatus(t, notifs, "", 1)
+}
+
+// An alert claimed by a worker cannot be changed any more; the edit waits until it is sent.
+func TestAdvanceEditsAlertBeingSent(t *testing.T) {
+    app, worker, queue, api, notifs := newUpdateTest()
+    ctx := context.Background()
+
+    app.dispatch(ctx, pendingEvent())
+    claimed, _ := queue.Claim(ctx, 0, 0)
+    app.dispatch(ctx, minedEvent(1))
+    runQueue(ctx, queue, worker)
+    if got := api.edits()[1]; got != 0 {
+        t.Fatalf("message edited %d times before it was sent", got)
+    }
+
+    worker.process(ctx, claimed[0])
+    assertStatus(t, notifs, domain.StatusPending, 0)
+    runQueue(ctx, queue, worker)
+    if got := api.edits()[1]; got != 1 {
+        t.Errorf("message edited %d times, want 1", got)
+    }
+    assertStatus(t, notifs, "", 1)
+}
+
+func TestAdvanceEditsDirectlyWithoutQueue(t *testing.T) {
+    api := newFakeTelegramAPI()
+    notifs := newFakeNotifications()
+    app := NewAppService(eventbus.NewInMemoryEventBus(), &fakeSubscriptions{subs: []domain.Subscription{subscription("1")}}, notifs, notifiers.NewTelegramNotifierWithAPI(api))
+    ctx := context.Background()
+
+    app.dispatch(ctx, pendingEvent())
+    api.editErr = errors.New("connection reset")
+    app.dispatch(ctx, minedEvent(1))
+    assertStatus(t, notifs, domain.StatusPending, 0)
+
+    api.editErr = nil
+    app.dispatch(ctx, minedEvent(1))
+    assertStatus(t, notifs, "", 1)
+    if got := api.edits()[1]; got != 1 {
+        t.Errorf("message edited %d times, want 1", got)
+    }
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// DeliveryOptions tunes the delivery worker.
+type DeliveryOptions struct {
+    MaxAttempts  int           // attempts before a delivery is dead-lettered
+    BaseBackoff  time.Duration // wait after the first failure, doubled on every further one
+    MaxBackoff   time.Duration
+    PollInterval time.Duration
+    Lease        time.Duration // how long a claimed delivery is hidden from other workers
+    BatchSize    int
+}
+
+func (o *DeliveryOptions) setDefaults() {
+    if o.MaxAttempts <= 0 {
+        o.MaxAttempts = 8
+    }
+    if o.BaseBackoff <= 0 {
+        o.BaseBackoff = 5 * time.Second
+    }
+    if o.MaxBackoff <= 0 {
+        o.MaxBackoff = time.Hour
+    }
+    if o.PollInterval <= 0 {
+        o.PollInterval = time.Second
+    }
+    if o.Lease <= 0 {
+        o.Lease = time.Minute
+    }
+    if o.BatchSize <= 0 {
+        o.BatchSize = 50
+    }
+}
+
+// DeliveryWorker sends queued deliveries through the notifier of their channel, retrying
+// failures with exponential backoff and dead-lettering those that cannot succeed. Edits
+// update the message sent by an earlier delivery the same way.
+type DeliveryWorker struct {
+    queue     ports.DeliveryQueue
+    notifs    ports.NotificationRepository
+    notifiers map[string]ports.Notifier
+    opts      DeliveryOptions
+}
+
+func NewDeliveryWorker(queue ports.DeliveryQueue, notifs ports.NotificationRepository, opts DeliveryOptions, notifiers ...ports.Notifier) *DeliveryWorker {
+    opts.setDefaults()
+    byChannel := make(map[string]ports.Notifier)
+    for _, n := range notifiers {
+        byChannel[n.Channel()] = n
+    }
+    return &DeliveryWorker{queue: queue, notifs: notifs, notifiers: byChannel, opts: opts}
+}
+
+func (w *DeliveryWorker) Run(ctx context.Context) {
+    ticker := time.NewTicker(w.opts.PollInterval)
+    defer ticker.Stop()
+    for {
+        deliveries, err := w.queue.Claim(ctx, w.opts.BatchSize, w.opts.Lease)
+        if err != nil && ctx.Err() == nil {
+            log.Printf("❌ Failed to claim deliveries: %v", err)
+        }
+        for _, d := range deliveries {
+            w.process(ctx, d)
+        }
+        // Keep going while the queue has a backlog, otherwise wait for the next tick.
+        if len(deliveries) == w.opts.BatchSize {
+            continue
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+func (w *DeliveryWorker) process(ctx context.Context, d domain.Delivery) {
+    n, ok := w.notifiers[d.Recipient.Channel]
+    if !ok {
+        w.deadLetter(ctx, d, "no notifier for channel "+d.Recipient.Channel)
+        return
+    }
+
+    var messageID string
+    var err error
+    if d.Edit {
+        var done bool
+        if done, err = w.edit(ctx, n, d); done {
+            w.complete(ctx, d)
+            return
+        }
+    } else {
+        messageID, err = n.Send(ctx, d.Recipient, d.Event)
+    }
+    attempt := d.Attempts + 1
+    if err == nil {
+        w.complete(ctx, d)
+        w.record(ctx, d, domain.DeliveryStatus{State: domain.DeliverySent, MessageID: messageID, Attempts: attempt})
+        w.commitStatus(ctx, d)
+        return
+    }
+    if ctx.Err() != nil {
+        // Shutting down; the lease runs out and another worker picks the delivery up.
+        return
+    }
+
+    var derr *domain.DeliveryError
+    isDeliveryErr := errors.As(err, &derr)
+    if isDeliveryErr && derr.Permanent {
+        w.deadLetter(ctx, d, err.Error())
+        return
+    }
+    if attempt >= w.opts.MaxAttempts {
+        w.deadLetter(ctx, d, fmt.Sprintf("giving up after %d attempts: %v", attempt, err))
+        return
+    }
+
+    wait := w.backoff(attempt)
+    if isDeliveryErr && derr.RetryAfter > wait {
+        wait = derr.RetryAfter
+    }
+    log.Printf("⚠️ Delivery %s failed (attempt %d), retrying in %s: %v", d.ID, attempt, wait, err)
+    if err := w.queue.Retry(ctx, d.ID, time.Now().Add(wait), err.Error()); err != nil {
+        log.Printf("❌ Failed to reschedule delivery %s: %v", d.ID, err)
+    }
+    w.record(ctx, d, domain.DeliveryStatus{State: domain.DeliveryQueued, Attempts: attempt, Error: err.Error()})
+}
+
+// edit updates the message sent to the delivery's recipient about its event. It reports done
+// when there is nothing to edit: the message already shows a later state, or the alert was
+// never sent. An alert that is still being sent is waited for by failing the attempt.
+func (w *DeliveryWorker) edit(ctx context.Context, n ports.Notifier, d domain.Delivery) (done bool, err error) {
+    editor, ok := n.(ports.MessageEditor)
+    if !ok {
+        return false, domain.PermanentDeliveryError(fmt.Errorf("%s messages cannot be edited", d.Recipient.Channel))
+    }
+    if w.notifs == nil {
+        return false, domain.PermanentDeliveryError(errors.New("no notifications to find the message in"))
+    }
+    notif, err := w.notifs.Get(ctx, d.Event.ID, d.ChatID)
+    if err != nil {
+        return false, err
+    }
+    shown := domain.TransactionEvent{Status: notif.Status, Confirmations: notif.Confirmations}
+    if shown.Advances(d.Event.Status, d.Event.Confirmations) {
+        log.Printf("Skipping edit %s: the message already shows a later state", d.ID)
+        return true, nil
+    }
+    status := notif.Deliveries[domain.DeliveryKey(d.ChatID, d.Recipient)]
+    switch {
+    case status.State == domain.DeliverySent && status.MessageID != "":
+        return false, editor.Edit(ctx, d.Recipient, status.MessageID, d.Event)
+    case status.State == domain.DeliveryQueued:
+        return false, errors.New("the alert to edit has not been sent yet")
+    default:
+        log.Printf("Skipping edit %s: no %s message to edit", d.ID, d.Recipient.Channel)
+        return true, nil
+    }
+}
+
+// commitStatus records the state of the event the recipient's message now shows.
+func (w *DeliveryWorker) commitStatus(ctx context.Context, d domain.Delivery) {
+    if w.notifs == nil {
+        return
+    }
+    n, err := w.notifs.Get(ctx, d.Event.ID, d.ChatID)
+    if err != nil {
+        log.Printf("❌ Failed to load the notification of event %s for chat %s: %v", d.Event.ID, d.ChatID, err)
+        return
+    }
+    commitStatus(ctx, w.notifs, n, d.Event)
+}
+
+func (w *DeliveryWorker) complete(ctx context.Context, d domain.Delivery) {
+    if err := w.queue.Complete(ctx, d.ID); err != nil {
+        log.Printf("❌ Failed to complete delivery %s: %v", d.ID, err)
+    }
+}
+
+// record stores the outcome of a delivery on its notification. Edits leave the outcome of
+// the alert they edit as it is.
+func (w *DeliveryWorker) record(ctx context.Context, d domain.Delivery, status domain.DeliveryStatus) {
+    if d.Edit {
+        return
+    }
+    recordDelivery(ctx, w.notifs, d.Event.ID, d.ChatID, domain.DeliveryKey(d.ChatID, d.Recipient), status)
+}
+
+// backoff returns BaseBackoff doubled for every attempt after the first, capped at MaxBackoff.
+func (w *DeliveryWorker) backoff(attempt int) time.Duration {
+    wait := w.opts.BaseBackoff
+    for i := 1; i < attempt && wait < w.opts.MaxBackoff; i++ {
+        wait *= 2
+    }
+    if wait > w.opts.MaxBackoff {
+        wait = w.opts.MaxBackoff
+    }
+    return wait
+}
+
+func (w *DeliveryWorker) deadLetter(ctx context.Context, d domain.Delivery, reason string) {
+    log.Printf("❌ Moving delivery %s to dead letters: %s", d.ID, reason)
+    if err := w.queue.DeadLetter(ctx, d, reason); err != nil {
+        log.Printf("❌ Failed to dead-letter delivery %s: %v", d.ID, err)
+    }
+    w.record(ctx, d, domain.DeliveryStatus{State: domain.DeliveryFailed, Attempts: d.Attempts + 1, Error: reason})
+}
+
@@ -0,0 +1,149 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "log"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// DigestScheduler sends the collected digests once they are due, one message per chat and period.
+type DigestScheduler struct {
+    repo      ports.DigestRepository
+    notifs    ports.NotificationRepository
+    notifiers map[string]ports.DigestNotifier
+    interval  time.Duration
+}
+
+func NewDigestScheduler(repo ports.DigestRepository, notifs ports.NotificationRepository, interval time.Duration, notifiers ...ports.Notifier) *DigestScheduler {
+    if interval <= 0 {
+        interval = time.Minute
+    }
+    byChannel := make(map[string]ports.DigestNotifier)
+    for _, n := range notifiers {
+        if dn, ok := n.(ports.DigestNotifier); ok {
+            byChannel[n.Channel()] = dn
+        }
+    }
+    return &DigestScheduler{repo: repo, notifs: notifs, notifiers: byChannel, interval: interval}
+}
+
+func (s *DigestScheduler) Run(ctx context.Context) {
+    ticker := time.NewTicker(s.interval)
+    defer ticker.Stop()
+    for {
+        s.sendDue(ctx)
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+const (
+    // digestLease is how long a scheduler has to send the digests it claimed; those it could
+    // not send are claimed again, by any scheduler, once it ends.
+    digestLease = 2 * time.Minute
+    // digestBatch is how many digests are claimed at once.
+    digestBatch = 50
+)
+
+// digestKey groups the entries that go into one message.
+type digestKey struct {
+    chatID string
+    to     domain.Recipient
+    mode   domain.DeliveryMode
+    dueAt  time.Time
+}
+
+// sendDue sends the due digests batch by batch. Each batch is claimed first, so with
+// several schedulers every digest is sent by one of them.
+func (s *DigestScheduler) sendDue(ctx context.Context) {
+    for ctx.Err() == nil {
+        entries, err := s.repo.Claim(ctx, time.Now(), digestBatch, digestLease)
+        if err != nil {
+            if ctx.Err() == nil {
+                log.Printf("❌ Failed to claim due digests: %v", err)
+            }
+            return
+        }
+        if s.sendAll(ctx, entries) < digestBatch {
+            return
+        }
+    }
+}
+
+// sendAll sends the claimed entries as one digest per chat and period and returns the
+// number of digests.
+func (s *DigestScheduler) sendAll(ctx context.Context, entries []domain.DigestEntry) int {
+
+    groups := make(map[digestKey][]domain.DigestEntry)
+    var order []digestKey
+    for _, e := range entries {
+        key := digestKey{chatID: e.ChatID, to: e.To(), mode: e.Mode, dueAt: e.DueAt.UTC()}
+        if _, ok := groups[key]; !ok {
+            order = append(order, key)
+        }
+        groups[key] = append(groups[key], e)
+    }
+    for _, key := range order {
+        if ctx.Err() != nil {
+            break
+        }
+        s.send(ctx, key, groups[key])
+    }
+    return len(order)
+}
+
+// send delivers one digest. Entries stay in place after a temporary failure and are
+// retried once their lease ends; they are dropped when the chat cannot be reached at all.
+func (s *DigestScheduler) send(ctx context.Context, key digestKey, entries []domain.DigestEntry) {
+    var period time.Duration
+    switch key.mode {
+    case domain.DeliveryHourly:
+        period = time.Hour
+    case domain.DeliveryDaily:
+        period = 24 * time.Hour
+    }
+    settings := domain.ChatSettings{Timezone: entries[0].Timezone}
+    digest := domain.Digest{ChatID: key.chatID, Mode: key.mode, From: key.dueAt.Add(-period), To: key.dueAt, Location: settings.Location()}
+    ids := make([]string, len(entries))
+    for i, e := range entries {
+        // Held alerts and bursts span a window the digest does not know about, so it
+        // starts with the first entry.
+        if period == 0 && e.CreatedAt.Before(digest.From) {
+            digest.From = e.CreatedAt
+        }
+        digest.Events = append(digest.Events, e.Event)
+        ids[i] = e.ID
+    }
+
+    to := key.to
+    n, ok := s.notifiers[to.Channel]
+    if !ok {
+        log.Printf("⚠️ No digest notifier for channel %s, keeping digest of chat %s", to.Channel, key.chatID)
+        return
+    }
+    messageID, err := n.SendDigest(ctx, to, digest)
+    status := domain.DeliveryStatus{State: domain.DeliverySent, MessageID: messageID, Attempts: 1, Digest: true}
+    if err != nil {
+        var derr *domain.DeliveryError
+        if !errors.As(err, &derr) || !derr.Permanent {
+            log.Printf("⚠️ Failed to send %s digest of chat %s to %s %s, retrying later: %v", key.mode, key.chatID, to.Channel, to.ID, err)
+            return
+        }
+        log.Printf("❌ Dropping %s digest of chat %s to %s %s: %v", key.mode, key.chatID, to.Channel, to.ID, err)
+        status = domain.DeliveryStatus{State: domain.DeliveryFailed, Attempts: 1, Error: err.Error(), Digest: true}
+    }
+
+    if err := s.repo.Delete(ctx, ids); err != nil {
+        log.Printf("❌ Failed to remove sent digest entries of chat %s: %v", key.chatID, err)
+    }
+    for _, e := range entries {
+        recordDelivery(ctx, s.notifs, e.Event.ID, key.chatID, domain.DeliveryKey(key.chatID, to), status)
+    }
+}
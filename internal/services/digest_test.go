@@ -0,0 +1,205 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+)
+
+// fakeDigests claims entries like the Mongo repository: whole digests at a time, each
+// entry by one scheduler until its lease ends.
+type fakeDigests struct {
+    mu      sync.Mutex
+    entries map[string]domain.DigestEntry
+    claims  int
+}
+
+func (f *fakeDigests) Add(ctx context.Context, entry domain.DigestEntry) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if _, ok := f.entries[entry.ID]; !ok {
+        f.entries[entry.ID] = entry
+    }
+    return nil
+}
+
+func (f *fakeDigests) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.DigestEntry, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.claims++
+    claim := fmt.Sprint(f.claims)
+    digests := make(map[digestKey]bool)
+    var claimed []domain.DigestEntry
+    for id, e := range f.entries {
+        if e.DueAt.After(now) || e.LeaseUntil.After(now) {
+            continue
+        }
+        key := digestKey{chatID: e.ChatID, to: e.To(), mode: e.Mode, dueAt: e.DueAt.UTC()}
+        if !digests[key] && len(digests) == limit {
+            continue
+        }
+        digests[key] = true
+        e.LeaseUntil, e.Claim = now.Add(lease), claim
+        f.entries[id] = e
+        claimed = append(claimed, e)
+    }
+    return claimed, nil
+}
+
+func (f *fakeDigests) Delete(ctx context.Context, ids []string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    for _, id := range ids {
+        delete(f.entries, id)
+    }
+    return nil
+}
+
+// fakeDigestNotifier counts the digests sent to each recipient and how many events they held.
+type fakeDigestNotifier struct {
+    mu      sync.Mutex
+    channel string
+    digests map[string][]int
+    err     error
+}
+
+func newFakeDigestNotifier(channel string) *fakeDigestNotifier {
+    return &fakeDigestNotifier{channel: channel, digests: make(map[string][]int)}
+}
+
+func (f *fakeDigestNotifier) Channel() string { return f.channel }
+
+func (f *fakeDigestNotifier) Send(ctx context.Context, to domain.Recipient, event domain.TransactionEvent) (string, error) {
+    return "", nil
+}
+
+func (f *fakeDigestNotifier) SendDigest(ctx context.Context, to domain.Recipient, digest domain.Digest) (string, error) {
+    // Give the other scheduler time to race for the same digest.
+    time.Sleep(time.Millisecond)
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if f.err != nil {
+        return "", f.err
+    }
+    f.digests[to.ID] = append(f.digests[to.ID], len(digest.Events))
+    return "1", nil
+}
+
+func digestEntries(chats, events int, due time.Time) []domain.DigestEntry {
+    var entries []domain.DigestEntry
+    for c := 1; c <= chats; c++ {
+        for e := 0; e < events; e++ {
+            evt := domain.TransactionEvent{ID: fmt.Sprintf("evt-%d", e), Direction: domain.DirectionIncoming, Amount: 1}
+            chatID := fmt.Sprint(c)
+            entries = append(entries, domain.DigestEntry{
+                ID:     domain.DigestEntryID(evt.ID, chatID, domain.ChannelTelegram),
+                ChatID: chatID,
+                Mode:   domain.DeliveryHourly,
+                Event:  evt,
+                DueAt:  due,
+            })
+        }
+    }
+    return entries
+}
+
+// Schedulers of several replicas send each due digest once, with all of its events.
+func TestDigestSchedulersSendOnce(t *testing.T) {
+    ctx := context.Background()
+    repo := &fakeDigests{entries: make(map[string]domain.DigestEntry)}
+    now := time.Now()
+    for _, e := range digestEntries(120, 3, now.Add(-time.Minute)) {
+        repo.Add(ctx, e)
+    }
+    for _, e := range digestEntries(5, 1, now.Add(time.Hour)) {
+        e.ID += ":later"
+        repo.Add(ctx, e)
+    }
+
+    notifier := newFakeDigestNotifier("telegram")
+    var wg sync.WaitGroup
+    for i := 0; i < 3; i++ {
+        scheduler := NewDigestScheduler(repo, newFakeNotifications(), time.Minute, notifier)
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            scheduler.sendDue(ctx)
+        }()
+    }
+    wg.Wait()
+
+    if len(notifier.digests) != 120 {
+        t.Errorf("digests sent to %d chats, want 120", len(notifier.digests))
+    }
+    for chatID, sent := range notifier.digests {
+        if len(sent) != 1 || sent[0] != 3 {
+            t.Errorf("chat %s got digests of %v events, want one of 3", chatID, sent)
+        }
+    }
+    if len(repo.entries) != 5 {
+        t.Errorf("%d entries left, want the 5 not due yet", len(repo.entries))
+    }
+}
+
+// A digest that failed to send stays claimed until its lease ends and is then sent again.
+func TestDigestSchedulerRetriesAfterLease(t *testing.T) {
+    ctx := context.Background()
+    repo := &fakeDigests{entries: make(map[string]domain.DigestEntry)}
+    now := time.Now()
+    for _, e := range digestEntries(1, 2, now.Add(-time.Minute)) {
+        repo.Add(ctx, e)
+    }
+    notifier := newFakeDigestNotifier("telegram")
+    notifier.err = fmt.Errorf("telegram is down")
+    scheduler := NewDigestScheduler(repo, newFakeNotifications(), time.Minute, notifier)
+
+    scheduler.sendDue(ctx)
+    notifier.err = nil
+    scheduler.sendDue(ctx)
+    if len(notifier.digests) != 0 || len(repo.entries) != 2 {
+        t.Fatalf("digest sent again before its lease ended")
+    }
+
+    if entries, _ := repo.Claim(ctx, now.Add(digestLease+time.Minute), digestBatch, digestLease); len(entries) != 2 {
+        t.Fatalf("claimed %d entries after the lease ended, want 2", len(entries))
+    }
+}
+
+// A digest subscription collects the alerts of a channel that takes digests into a digest
+// of its own, which the scheduler sends to that channel.
+func TestDigestCollectsForAlertChannels(t *testing.T) {
+    ctx := context.Background()
+    repo := &fakeDigests{entries: make(map[string]domain.DigestEntry)}
+    sub := subscription("1")
+    sub.Mode = domain.DeliveryHourly
+    telegram, email := newFakeDigestNotifier(domain.ChannelTelegram), newFakeDigestNotifier(domain.ChannelEmail)
+    app := NewAppService(nil, &fakeSubscriptions{subs: []domain.Subscription{sub}}, newFakeNotifications(), telegram, email)
+    alerts := newFakeAlerts()
+    alerts.Create(ctx, domain.Alert{ID: "a1", UserID: "1", Type: domain.ChannelEmail})
+    app.UseAlerts(alerts)
+    app.UseDigests(repo)
+
+    if err := app.dispatch(ctx, testEvent()); err != nil {
+        t.Fatal(err)
+    }
+    if len(repo.entries) != 2 {
+        t.Fatalf("collected %d digest entries, want one for the chat and one for the email alert", len(repo.entries))
+    }
+
+    scheduler := NewDigestScheduler(repo, newFakeNotifications(), time.Minute, telegram, email)
+    entries, _ := repo.Claim(ctx, time.Now().Add(2*time.Hour), digestBatch, digestLease)
+    scheduler.sendAll(ctx, entries)
+    if got := telegram.digests["1"]; len(got) != 1 {
+        t.Errorf("chat got digests %v, want one", got)
+    }
+    if got := email.digests["a1"]; len(got) != 1 {
+        t.Errorf("email alert got digests %v, want one", got)
+    }
+    if len(telegram.digests) != 1 || len(email.digests) != 1 {
+        t.Errorf("digests went to the wrong channel: telegram %v, email %v", telegram.digests, email.digests)
+    }
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+    "context"
+    "log"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// feedSubscriber names the live feed on buses that account for subscribers by name.
+const feedSubscriber = "livefeed"
+
+// feedClientBuffer is how many events a client may fall behind before it is disconnected;
+// it can reconnect and resume from the last event it received.
+const feedClientBuffer = 64
+
+// LiveFeed streams transaction events to API clients as they are published, each client
+// seeing only the events of the wallets its user owns. The latest events are kept in memory,
+// so a client that reconnects resumes after the last event it received. The buffer belongs to
+// this process: a client resuming on another replica is told it has a gap.
+type LiveFeed struct {
+    wallets ports.WalletRepository
+    refresh time.Duration
+
+    mu      sync.Mutex
+    recent  []domain.TransactionEvent // ring buffer of the latest events
+    next    int                       // slot the next event is written to
+    full    bool
+    clients map[*feedClient]struct{}
+    dropped float64
+}
+
+type feedClient struct {
+    userID string
+    events chan domain.TransactionEvent
+    reload chan struct{} // signalled when the user's wallets changed
+}
+
+// FeedSubscription is one client's view of the feed.
+type FeedSubscription struct {
+    // Backlog holds the client's events published after the one it resumed from.
+    Backlog []domain.TransactionEvent
+    // Gap is set when the event to resume from is no longer buffered, so events may have
+    // been missed and the client should reload instead.
+    Gap bool
+    // Events delivers the client's new events. It is closed when the context ends or the
+    // client fell too far behind.
+    Events <-chan domain.TransactionEvent
+}
+
+// NewLiveFeed keeps the latest size events for resuming.
+func NewLiveFeed(wallets ports.WalletRepository, size int) *LiveFeed {
+    if size <= 0 {
+        size = 1000
+    }
+    return &LiveFeed{
+        wallets: wallets,
+        refresh: 30 * time.Second,
+        recent:  make([]domain.TransactionEvent, size),
+        clients: make(map[*feedClient]struct{}),
+    }
+}
+
+// Run feeds the events published on the bus to the connected clients until ctx ends.
+func (f *LiveFeed) Run(ctx context.Context, bus ports.EventBus) {
+    var (
+        ch          <-chan domain.TransactionEvent
+        unsubscribe func()
+    )
+    if named, ok := bus.(ports.NamedSubscriber); ok {
+        ch, unsubscribe = named.SubscribeNamed(feedSubscriber)
+    } else {
+        ch, unsubscribe = bus.Subscribe()
+    }
+    defer unsubscribe()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case evt, ok := <-ch:
+            if !ok {
+                log.Printf("❌ Live feed subscription closed, clients get no more events")
+                return
+            }
+            f.publish(evt.WithID())
+        }
+    }
+}
+
+// Follow reloads the wallets of connected clients whose user added or deleted one, so they
+// see the new wallet's events right away, until ctx ends or changes is closed.
+func (f *LiveFeed) Follow(ctx context.Context, changes <-chan domain.SubscriptionChange) {
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case change, ok := <-changes:
+            if !ok {
+                return
+            }
+            if change.UserID != "" {
+                f.walletsChanged(change.UserID)
+            }
+        }
+    }
+}
+
+func (f *LiveFeed) walletsChanged(userID string) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    for c := range f.clients {
+        if c.userID != userID {
+            continue
+        }
+        select {
+        case c.reload <- struct{}{}:
+        default: // a reload is already due
+        }
+    }
+}
+
+func (f *LiveFeed) publish(evt domain.TransactionEvent) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.recent[f.next] = evt
+    f.next = (f.next + 1) % len(f.recent)
+    if f.next == 0 {
+        f.full = true
+    }
+    for c := range f.clients {
+        select {
+        case c.events <- evt:
+        default:
+            // Too slow: disconnect rather than hold up the others.
+            delete(f.clients, c)
+            close(c.events)
+            f.dropped++
+        }
+    }
+}
+
+// Subscribe connects a client of userID. With lastEventID set, the buffered events after
+// that one are returned as the backlog.
+func (f *LiveFeed) Subscribe(ctx context.Context, userID string, lastEventID string) (*FeedSubscription, error) {
+    owned, err := f.ownedAddresses(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    client := &feedClient{
+        userID: userID,
+        events: make(chan domain.TransactionEvent, feedClientBuffer),
+        reload: make(chan struct{}, 1),
+    }
+
+    f.mu.Lock()
+    backlog, found := f.since(lastEventID)
+    f.clients[client] = struct{}{}
+    f.mu.Unlock()
+
+    sub := &FeedSubscription{Gap: lastEventID != "" && !found}
+    for _, evt := range backlog {
+        if owned[feedKey(evt.Blockchain, evt.WalletID)] {
+            sub.Backlog = append(sub.Backlog, evt)
+        }
+    }
+    out := make(chan domain.TransactionEvent)
+    sub.Events = out
+    go f.forward(ctx, userID, owned, client, out)
+    return sub, nil
+}
+
+// forward passes the client's events on, reloading its wallets when they change and now and
+// then, which picks up the changes of processes whose changes do not reach this one.
+func (f *LiveFeed) forward(ctx context.Context, userID string, owned map[string]bool, client *feedClient, out chan<- domain.TransactionEvent) {
+    defer close(out)
+    defer f.remove(client)
+    ticker := time.NewTicker(f.refresh)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if reloaded, err := f.ownedAddresses(ctx, userID); err == nil {
+                owned = reloaded
+            }
+        case <-client.reload:
+            if reloaded, err := f.ownedAddresses(ctx, userID); err == nil {
+                owned = reloaded
+            }
+        case evt, ok := <-client.events:
+            if !ok {
+                return
+            }
+            if !owned[feedKey(evt.Blockchain, evt.WalletID)] {
+                continue
+            }
+            select {
+            case out <- evt:
+            case <-ctx.Done():
+                return
+            }
+        }
+    }
+}
+
+func (f *LiveFeed) remove(client *feedClient) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if _, ok := f.clients[client]; ok {
+        delete(f.clients, client)
+        close(client.events)
+    }
+}
+
+// since returns the buffered events after the one with ID lastEventID, oldest first, and
+// whether that event was found.
+func (f *LiveFeed) since(lastEventID string) ([]domain.TransactionEvent, bool) {
+    if lastEventID == "" {
+        return nil, false
+    }
+    var ordered []domain.TransactionEvent
+    if f.full {
+        ordered = append(ordered, f.recent[f.next:]...)
+    }
+    ordered = append(ordered, f.recent[:f.next]...)
+    for i := len(ordered) - 1; i >= 0; i-- {
+        if ordered[i].ID == lastEventID {
+            return append([]domain.TransactionEvent(nil), ordered[i+1:]...), true
+        }
+    }
+    return nil, false
+}
+
+func (f *LiveFeed) ownedAddresses(ctx context.Context, userID string) (map[string]bool, error) {
+    wallets, err := f.wallets.ListByUser(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    owned := make(map[string]bool, len(wallets))
+    for _, w := range wallets {
+        owned[feedKey(w.Blockchain, w.Address)] = true
+    }
+    return owned, nil
+}
+
+func feedKey(blockchain, address string) string {
+    return blockchain + "|" + strings.ToLower(address)
+}
+
+func (f *LiveFeed) Metrics() []ports.Metric {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return []ports.Metric{
+        {Name: "livefeed_clients", Help: "Clients connected to the live event stream.", Type: "gauge", Value: float64(len(f.clients))},
+        {Name: "livefeed_dropped_clients_total", Help: "Live event stream clients disconnected for falling behind.", Type: "counter", Value: f.dropped},
+    }
+}
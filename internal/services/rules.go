@@ -0,0 +1,143 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// ChatRuleService manages the expression rules of chats and applies them to events.
+type ChatRuleService struct {
+    repo   ports.ChatRuleRepository
+    engine ports.RuleEngine
+
+    mu     sync.Mutex
+    ttl    time.Duration
+    cached map[string]cachedRules
+    prune  int // cache size at which expired chats are dropped
+}
+
+// cachedRules are the rules of a chat as loaded at loadedAt.
+type cachedRules struct {
+    rules    []domain.ChatRule
+    loadedAt time.Time
+}
+
+func NewChatRuleService(repo ports.ChatRuleRepository, engine ports.RuleEngine) *ChatRuleService {
+    return &ChatRuleService{repo: repo, engine: engine}
+}
+
+// UseCache keeps the rules of each chat for ttl instead of loading them for every event.
+// Rules changed through this service apply right away; rules changed by another process
+// apply once the cached ones expire.
+func (s *ChatRuleService) UseCache(ttl time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.ttl = ttl
+    s.cached = make(map[string]cachedRules)
+    s.prune = 1024
+}
+
+// Create validates the expression and stores the rule. Invalid expressions are rejected
+// with an error wrapping domain.ErrInvalidRule.
+func (s *ChatRuleService) Create(ctx context.Context, chatID string, name string, expression string) (domain.ChatRule, error) {
+    expression = strings.TrimSpace(expression)
+    if expression == "" {
+        return domain.ChatRule{}, fmt.Errorf("%w: expression is empty", domain.ErrInvalidRule)
+    }
+    if err := s.engine.Validate(expression); err != nil {
+        return domain.ChatRule{}, fmt.Errorf("%w: %v", domain.ErrInvalidRule, err)
+    }
+    if name = strings.TrimSpace(name); name == "" {
+        name = expression
+    }
+    defer s.forget(chatID)
+    return s.repo.Create(ctx, domain.ChatRule{
+        ChatID:     chatID,
+        Name:       name,
+        Expression: expression,
+        Enabled:    true,
+    })
+}
+
+func (s *ChatRuleService) List(ctx context.Context, chatID string) ([]domain.ChatRule, error) {
+    return s.repo.ListByChat(ctx, chatID)
+}
+
+func (s *ChatRuleService) Delete(ctx context.Context, chatID string, id string) error {
+    defer s.forget(chatID)
+    return s.repo.Delete(ctx, chatID, id)
+}
+
+// load returns the rules of a chat, from the cache while they are fresh.
+func (s *ChatRuleService) load(ctx context.Context, chatID string) ([]domain.ChatRule, error) {
+    now := time.Now()
+    s.mu.Lock()
+    entry, ok := s.cached[chatID]
+    ttl := s.ttl
+    s.mu.Unlock()
+    if ok && now.Sub(entry.loadedAt) < ttl {
+        return entry.rules, nil
+    }
+
+    rules, err := s.repo.ListByChat(ctx, chatID)
+    if err != nil || ttl <= 0 {
+        return rules, err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    // Drop expired chats whenever the cache doubled so chats that stopped getting events
+    // do not pile up.
+    if len(s.cached) >= s.prune {
+        for id, e := range s.cached {
+            if now.Sub(e.loadedAt) >= ttl {
+                delete(s.cached, id)
+            }
+        }
+        s.prune = max(1024, 2*len(s.cached))
+    }
+    s.cached[chatID] = cachedRules{rules: rules, loadedAt: now}
+    return rules, nil
+}
+
+// forget drops the cached rules of a chat after they changed.
+func (s *ChatRuleService) forget(chatID string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.cached, chatID)
+}
+
+// Observe feeds an event into the sliding windows. Repeats of an event are ignored, so it
+// can be called for each chat the event is valued for.
+func (s *ChatRuleService) Observe(evt domain.TransactionEvent) {
+    s.engine.Observe(evt)
+}
+
+// Allows reports whether chatID should be notified about evt: chats without enabled rules
+// get every event, others only those matching a rule. If the rules cannot be loaded the
+// event is let through rather than silently lost.
+func (s *ChatRuleService) Allows(ctx context.Context, chatID string, evt domain.TransactionEvent) bool {
+    rules, err := s.load(ctx, chatID)
+    if err != nil {
+        log.Printf("⚠️ Failed to load rules of chat %s, notifying anyway: %v", chatID, err)
+        return true
+    }
+    enabled := false
+    for _, r := range rules {
+        enabled = enabled || r.Enabled
+    }
+    if !enabled {
+        return true
+    }
+    rule, ok := s.engine.Match(rules, evt)
+    if ok {
+        log.Printf("Event %s matched rule %q of chat %s", evt.ID, rule.Name, chatID)
+    }
+    return ok
+}
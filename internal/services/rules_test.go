@@ -0,0 +1,99 @@
+package services
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/you/wallet_transaction_notifier/internal/domain"
+    "github.com/you/wallet_transaction_notifier/internal/infra/rules"
+)
+
+// fakeChatRules keeps rules in memory and counts how often a chat's rules are loaded.
+type fakeChatRules struct {
+    mu    sync.Mutex
+    rules []domain.ChatRule
+    loads int
+}
+
+func (f *fakeChatRules) Create(ctx context.Context, rule domain.ChatRule) (domain.ChatRule, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    rule.ID = rule.Name
+    f.rules = append(f.rules, rule)
+    return rule, nil
+}
+
+func (f *fakeChatRules) ListByChat(ctx context.Context, chatID string) ([]domain.ChatRule, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.loads++
+    var rules []domain.ChatRule
+    for _, r := range f.rules {
+        if r.ChatID == chatID {
+            rules = append(rules, r)
+        }
+    }
+    return rules, nil
+}
+
+func (f *fakeChatRules) Delete(ctx context.Context, chatID string, id string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    kept := f.rules[:0]
+    for _, r := range f.rules {
+        if r.ChatID != chatID || r.ID != id {
+            kept = append(kept, r)
+        }
+    }
+    f.rules = kept
+    return nil
+}
+
+func TestChatRulesCache(t *testing.T) {
+    ctx := context.Background()
+    repo := &fakeChatRules{}
+    service := NewChatRuleService(repo, rules.NewEngine())
+    service.UseCache(time.Hour)
+    big := domain.TransactionEvent{ID: "big", Direction: domain.DirectionIncoming, Amount: 100}
+    small := domain.TransactionEvent{ID: "small", Direction: domain.DirectionIncoming, Amount: 1}
+
+    for i := 0; i < 3; i++ {
+        if !service.Allows(ctx, "1", small) {
+            t.Fatal("chat without rules did not get the event")
+        }
+    }
+    if repo.loads != 1 {
+        t.Errorf("rules loaded %d times, want once", repo.loads)
+    }
+
+    // Changes through the service apply right away.
+    if _, err := service.Create(ctx, "1", "big", "amount > 10"); err != nil {
+        t.Fatal(err)
+    }
+    if service.Allows(ctx, "1", small) || !service.Allows(ctx, "1", big) {
+        t.Error("new rule not applied")
+    }
+    if err := service.Delete(ctx, "1", "big"); err != nil {
+        t.Fatal(err)
+    }
+    if !service.Allows(ctx, "1", small) {
+        t.Error("deleted rule still applied")
+    }
+    if repo.loads != 3 {
+        t.Errorf("rules loaded %d times, want 3", repo.loads)
+    }
+}
+
+func TestChatRulesWithoutCache(t *testing.T) {
+    ctx := context.Background()
+    repo := &fakeChatRules{}
+    service := NewChatRuleService(repo, rules.NewEngine())
+    for i := 0; i < 3; i++ {
+        service.Allows(ctx, "1", domain.TransactionEvent{})
+    }
+    if repo.loads != 3 {
+        t.Errorf("rules loaded %d times, want on every event", repo.loads)
+    }
+}
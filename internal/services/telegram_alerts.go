@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/wallet_transaction_notifier/internal/domain"
+	"github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+)
+
+// alertConfigKeys is the Config entry /addchannel fills in for each channel type.
+var alertConfigKeys = map[string]string{
+	domain.ChannelWebhook:  "url",
+	domain.ChannelSlack:    "url",
+	domain.ChannelDiscord:  "url",
+	domain.ChannelEmail:    "to",
+	domain.ChannelTelegram: "chatId",
+}
+
+const addChannelExamples = "`/addchannel slack https://hooks.slack.com/services/...`\n" +
+	"`/addchannel discord https://discord.com/api/webhooks/...`\n" +
+	"`/addchannel webhook https://example.com/hook`\n" +
+	"`/addchannel email ops@example.com, cfo@example.com`\n" +
+	"`/addchannel telegram -1001234567890`"
+
+// channelLabel names an alert channel in lists and buttons.
+func channelLabel(l i18n.Localizer, alert domain.Alert) string {
+	host := func() string {
+		u, err := url.Parse(alert.ConfigString("url"))
+		if err != nil {
+			return ""
+		}
+		return u.Host
+	}
+	switch alert.Type {
+	case domain.ChannelWebhook:
+		return l.T("channel.webhook", host())
+	case domain.ChannelSlack:
+		return "💬 Slack"
+	case domain.ChannelDiscord:
+		return "🎮 Discord"
+	case domain.ChannelEmail:
+		return "📧 " + alert.ConfigString("to")
+	case domain.ChannelTelegram:
+		return l.T("channel.telegram", alert.ConfigString("chatId"))
+	}
+	return alert.Type
+}
+
+func (t *TelegramBotService) handleListChannels(ctx context.Context, chatID string) {
+	l := i18n.FromContext(ctx)
+	if t.alerts == nil {
+		t.sendMessage(chatID, l.T("channels.unavailable"))
+		return
+	}
+	alerts, err := t.alerts.List(ctx, chatID)
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.channels"))
+		return
+	}
+	if len(alerts) == 0 {
+		t.sendMessage(chatID, l.T("channels.empty", addChannelExamples))
+		return
+	}
+
+	var msg strings.Builder
+	msg.WriteString(l.T("channels.title") + "\n\n")
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, a := range alerts {
+		n := l.Int(i + 1)
+		msg.WriteString(fmt.Sprintf("%s. %s", n, channelLabel(l, a)))
+		toggle := tgbotapi.NewInlineKeyboardButtonData(l.T("button.pause", n), "alert_pause_"+a.ID)
+		switch {
+		case a.Unconfirmed:
+			msg.WriteString(" — " + l.T("channels.unconfirmed"))
+			toggle = tgbotapi.NewInlineKeyboardButtonData(l.T("button.resume", n), "alert_resume_"+a.ID)
+		case a.Disabled:
+			if a.LastError != "" {
+				msg.WriteString(" — " + l.N("channels.paused_after", a.Failures))
+			} else {
+				msg.WriteString(" — " + l.T("channels.paused"))
+			}
+			toggle = tgbotapi.NewInlineKeyboardButtonData(l.T("button.resume", n), "alert_resume_"+a.ID)
+		}
+		msg.WriteString("\n")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.test", n), "alert_test_"+a.ID),
+			toggle,
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.delete", n), "alert_delete_"+a.ID),
+		))
+	}
+	msg.WriteString("\n" + l.T("channels.routes_hint"))
+	t.sendMessageWithKeyboard(chatID, msg.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+func (t *TelegramBotService) handleAddChannel(ctx context.Context, chatID, args string) {
+	l := i18n.FromContext(ctx)
+	if t.alerts == nil {
+		t.sendMessage(chatID, l.T("channels.unavailable"))
+		return
+	}
+	alertType, target, _ := strings.Cut(args, " ")
+	alertType = strings.ToLower(alertType)
+	key, ok := alertConfigKeys[alertType]
+	if !ok || strings.TrimSpace(target) == "" {
+		t.sendMessage(chatID, l.T("channels.usage", addChannelExamples))
+		return
+	}
+	alert, err := t.alerts.Create(ctx, chatID, alertType, map[string]any{key: strings.TrimSpace(target)})
+	if errors.Is(err, domain.ErrInvalidAlert) {
+		t.sendMessage(chatID, l.T("channels.invalid", strings.TrimPrefix(err.Error(), domain.ErrInvalidAlert.Error()+": ")))
+		return
+	}
+	if err != nil {
+		t.sendMessage(chatID, l.T("channels.save_failed"))
+		return
+	}
+
+	if alert.Unconfirmed {
+		t.sendMessage(chatID, l.T("channels.confirm_sent", channelLabel(l, alert)))
+		return
+	}
+	msg := l.T("channels.added", channelLabel(l, alert))
+	if secret := alert.ConfigString("secret"); secret != "" {
+		msg += "\n\n" + l.T("channels.secret", secret)
+	}
+	t.sendMessage(chatID, msg)
+}
+
+// handleAlertCallback runs the buttons of /channels and the confirmation button a telegram
+// alert's chat gets.
+func (t *TelegramBotService) handleAlertCallback(ctx context.Context, chatID, data string) {
+	l := i18n.FromContext(ctx)
+	if t.alerts == nil {
+		return
+	}
+	action, id, ok := strings.Cut(data, "_")
+	if !ok {
+		return
+	}
+	var err error
+	switch action {
+	case "confirm":
+		t.handleAlertConfirm(ctx, chatID, id)
+		return
+	case "test":
+		if err = t.alerts.Test(ctx, chatID, id); err == nil {
+			t.sendMessage(chatID, l.T("channels.test_sent"))
+			return
+		}
+		if !errors.Is(err, domain.ErrAlertNotFound) && !errors.Is(err, domain.ErrAlertUnconfirmed) {
+			t.sendMessage(chatID, l.T("channels.test_failed", strings.ReplaceAll(err.Error(), "`", "'")))
+			return
+		}
+	case "pause":
+		err = t.alerts.Disable(ctx, chatID, id)
+	case "resume":
+		err = t.alerts.Enable(ctx, chatID, id)
+	case "delete":
+		err = t.alerts.Delete(ctx, chatID, id)
+	default:
+		return
+	}
+	if errors.Is(err, domain.ErrAlertNotFound) {
+		t.sendMessage(chatID, l.T("channels.not_found"))
+		return
+	}
+	if errors.Is(err, domain.ErrAlertUnconfirmed) {
+		t.sendMessage(chatID, l.T("channels.not_confirmed"))
+		return
+	}
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return
+	}
+	t.handleListChannels(ctx, chatID)
+}
+
+// handleAlertConfirm accepts a telegram alert in the chat it forwards to; data is the
+// alert's ID and code from the button of the confirmation request.
+func (t *TelegramBotService) handleAlertConfirm(ctx context.Context, chatID, data string) {
+	l := i18n.FromContext(ctx)
+	id, code, _ := strings.Cut(data, "_")
+	alert, err := t.alerts.Confirm(ctx, chatID, id, code)
+	if errors.Is(err, domain.ErrAlertNotFound) {
+		t.sendMessage(chatID, l.T("channels.confirm_failed"))
+		return
+	}
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return
+	}
+	t.sendMessage(chatID, l.T("channels.confirmed", alert.UserID))
+	owner := i18n.Localizer{}
+	if session, err := t.sessions.GetTelegramSession(ctx, alert.UserID); err == nil {
+		owner = i18n.New(session.PreferredLanguage())
+	}
+	t.sendMessage(alert.UserID, owner.T("channels.confirmed_owner", channelLabel(owner, alert)))
+}
+
+// handleChannelRoutes shows which channels one subscription's alerts go to, as toggles.
+func (t *TelegramBotService) handleChannelRoutes(ctx context.Context, chatID, blockchain, indexStr string, sub domain.Subscription) {
+	l := i18n.FromContext(ctx)
+	if t.alerts == nil {
+		t.sendMessage(chatID, l.T("channels.unavailable"))
+		return
+	}
+	alerts, err := t.alerts.List(ctx, chatID)
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.channels"))
+		return
+	}
+
+	button := func(label, choice string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("route_%s_%s_%s", blockchain, indexStr, choice))
+	}
+	check := func(channel string) string {
+		if sub.SendsTo(channel) {
+			return "✅ "
+		}
+		return "⬜ "
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(button(check(domain.ChannelTelegram)+l.T("routes.this_chat"), "0")),
+	}
+	for i, a := range alerts {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button(check(a.ID)+channelLabel(l, a), strconv.Itoa(i+1))))
+	}
+	if len(sub.Channels) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button(l.T("button.all_channels"), "all")))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_settings"), fmt.Sprintf("settings_%s_%s", blockchain, indexStr)),
+	))
+
+	msg := l.T("routes.title", sub.Address)
+	t.sendMessageWithKeyboard(chatID, msg, tgbotapi.NewInlineKeyboardMarkup(rows...))
+}
+
+// handleRouteToggle turns one channel of a subscription on or off; choice is 0 for the chat
+// itself, the position of an alert channel in /channels, or "all".
+func (t *TelegramBotService) handleRouteToggle(ctx context.Context, chatID, blockchain, indexStr, choice string) {
+	l := i18n.FromContext(ctx)
+	if t.alerts == nil {
+		return
+	}
+	sub, ok := t.subscriptionAt(ctx, chatID, blockchain, indexStr)
+	if !ok {
+		return
+	}
+	alerts, err := t.alerts.List(ctx, chatID)
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.channels"))
+		return
+	}
+
+	var routes []string
+	if choice != "all" {
+		n, err := strconv.Atoi(choice)
+		if err != nil || n < 0 || n > len(alerts) {
+			t.sendMessage(chatID, l.T("channels.not_found"))
+			return
+		}
+		all := []string{domain.ChannelTelegram}
+		for _, a := range alerts {
+			all = append(all, a.ID)
+		}
+		toggled := all[n]
+		for _, c := range all {
+			if sub.SendsTo(c) != (c == toggled) {
+				routes = append(routes, c)
+			}
+		}
+		if len(routes) == 0 {
+			t.sendMessage(chatID, l.T("routes.none_left"))
+			return
+		}
+		if len(routes) == len(all) {
+			routes = nil
+		}
+	}
+	if err := t.alerts.Route(ctx, chatID, blockchain, sub.Address, routes); err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return
+	}
+	sub.Channels = routes
+	t.handleChannelRoutes(ctx, chatID, blockchain, indexStr, sub)
+}
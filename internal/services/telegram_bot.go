@@ -9,6 +9,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/you/wallet_transaction_notifier/internal/domain"
+	"github.com/you/wallet_transaction_notifier/internal/infra/i18n"
 	"github.com/you/wallet_transaction_notifier/internal/ports"
 )
 
@@ -17,6 +18,10 @@ type TelegramBotService struct {
 	sessions ports.SessionRepository
 	subs     ports.SubscriptionRepository
 	notifs   ports.NotificationRepository
+	rules    *ChatRuleService
+	alerts   *AlertService
+	// templateSets are the message template sets chats can pick, the default first.
+	templateSets []string
 }
 
 func NewTelegramBotService(botToken string, sessions ports.SessionRepository, subs ports.SubscriptionRepository, notifs ports.NotificationRepository) (*TelegramBotService, error) {
@@ -32,38 +37,76 @@ func NewTelegramBotService(botToken string, sessions ports.SessionRepository, su
 	bot.Debug = false
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
+	return NewTelegramBotServiceWithAPI(bot, sessions, subs, notifs), nil
+}
+
+// NewTelegramBotServiceWithAPI runs the bot on an existing client, e.g. the one the Telegram
+// notifier sends alerts with. A nil client gives a bot that does nothing.
+func NewTelegramBotServiceWithAPI(bot *tgbotapi.BotAPI, sessions ports.SessionRepository, subs ports.SubscriptionRepository, notifs ports.NotificationRepository) *TelegramBotService {
+	if bot == nil {
+		return &TelegramBotService{}
+	}
 	return &TelegramBotService{
 		bot:      bot,
 		sessions: sessions,
 		subs:     subs,
 		notifs:   notifs,
-	}, nil
+	}
+}
+
+// UseChatRules enables the /rules, /addrule and /delrule commands.
+func (t *TelegramBotService) UseChatRules(rules *ChatRuleService) {
+	t.rules = rules
+}
+
+// UseAlerts enables the /channels and /addchannel commands and routing addresses to channels.
+func (t *TelegramBotService) UseAlerts(alerts *AlertService) {
+	t.alerts = alerts
+}
+
+// UseTemplateSets enables the /template command, offering the given template sets.
+func (t *TelegramBotService) UseTemplateSets(names []string) {
+	t.templateSets = names
 }
 
+// Run receives updates by long polling until ctx is done. Telegram refuses to hand out
+// updates while a webhook is set, so a webhook left from webhook mode is removed first.
 func (t *TelegramBotService) Run(ctx context.Context) error {
 	if t.bot == nil {
 		return nil
 	}
+	if _, err := t.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("⚠️ Failed to remove the Telegram webhook before long polling: %v", err)
+	}
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
 	updates := t.bot.GetUpdatesChan(u)
+	defer t.bot.StopReceivingUpdates()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case update := <-updates:
-			if update.Message != nil {
-				t.handleMessage(ctx, update.Message)
-			} else if update.CallbackQuery != nil {
-				t.handleCallbackQuery(ctx, update.CallbackQuery)
-			}
+			t.HandleUpdate(ctx, update)
 		}
 	}
 }
 
+// HandleUpdate handles one update from Telegram, received by long polling or a webhook.
+func (t *TelegramBotService) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
+	if t.bot == nil {
+		return
+	}
+	if update.Message != nil {
+		t.handleMessage(ctx, update.Message)
+	} else if update.CallbackQuery != nil {
+		t.handleCallbackQuery(ctx, update.CallbackQuery)
+	}
+}
+
 func (t *TelegramBotService) handleMessage(ctx context.Context, message *tgbotapi.Message) {
 	chatID := fmt.Sprintf("%d", message.Chat.ID)
 	text := strings.TrimSpace(message.Text)
@@ -80,6 +123,7 @@ func (t *TelegramBotService) handleMessage(ctx context.Context, message *tgbotap
 		}
 		t.sessions.UpsertTelegramSession(ctx, session)
 	}
+	ctx = t.withLanguage(ctx, &session, message.From)
 
 	// Handle commands
 	if strings.HasPrefix(text, "/") {
@@ -96,23 +140,68 @@ func (t *TelegramBotService) handleCommand(ctx context.Context, chatID, text str
 
 	switch command {
 	case "/start":
-		t.sendWelcomeMessage(chatID)
+		t.sendWelcomeMessage(ctx, chatID)
 		session.State = domain.StateIdle
 		t.sessions.UpsertTelegramSession(ctx, *session)
 
 	case "/help":
-		t.sendHelpMessage(chatID)
+		t.sendHelpMessage(ctx, chatID)
 
 	case "/menu":
-		t.sendMainMenu(chatID)
+		t.sendMainMenu(ctx, chatID)
 		session.State = domain.StateIdle
 		t.sessions.UpsertTelegramSession(ctx, *session)
 
+	case "/rules":
+		t.handleListChatRules(ctx, chatID)
+
+	case "/addrule":
+		t.handleAddChatRule(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)))
+
+	case "/delrule":
+		t.handleDeleteChatRule(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)))
+
+	case "/snooze":
+		t.handleSnooze(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)), session)
+
+	case "/quiet":
+		t.handleQuietHours(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)), session)
+
+	case "/timezone":
+		t.handleTimezone(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)), session)
+
+	case "/language":
+		t.handleLanguage(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)), session)
+
+	case "/template":
+		t.handleTemplate(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)), session)
+
+	case "/currency":
+		t.handleCurrency(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)), session)
+
+	case "/channels":
+		t.handleListChannels(ctx, chatID)
+
+	case "/addchannel":
+		t.handleAddChannel(ctx, chatID, strings.TrimSpace(strings.TrimPrefix(text, command)))
+
 	default:
-		t.sendMessage(chatID, "Unknown command. Use /help to see available commands.")
+		t.sendMessage(chatID, i18n.FromContext(ctx).T("bot.unknown_command"))
 	}
 }
 
+// withLanguage remembers the language of the user's Telegram client when it changed and
+// returns ctx with the Localizer of the chat's language.
+func (t *TelegramBotService) withLanguage(ctx context.Context, session *domain.TelegramSession, from *tgbotapi.User) context.Context {
+	if from != nil && from.LanguageCode != "" && from.LanguageCode != session.LanguageCode {
+		session.LanguageCode = from.LanguageCode
+		if err := t.sessions.UpdateLanguage(ctx, session.ChatID, session.Language, session.LanguageCode); err != nil {
+			log.Printf("⚠️ Failed to save the language of chat %s: %v", session.ChatID, err)
+		}
+	}
+	return i18n.WithLocalizer(ctx, i18n.New(session.PreferredLanguage()))
+}
+
 func (t *TelegramBotService) handleStateResponse(ctx context.Context, chatID, text string, session *domain.TelegramSession) {
 	log.Printf("Handling state response for chat %s, state: %s, text: %s", chatID, session.State, text)
 	
@@ -124,9 +213,11 @@ func (t *TelegramBotService) handleStateResponse(ctx context.Context, chatID, te
 		t.handleRemoveAddress(ctx, chatID, text, session)
 	case domain.StateViewNotifications:
 		t.handleViewNotifications(ctx, chatID, text, session)
+	case domain.StateEditRule:
+		t.handleEditRule(ctx, chatID, text, session)
 	default:
 		log.Printf("Unknown state %s for chat %s, sending generic message", session.State, chatID)
-		t.sendMessage(chatID, "Please use the menu buttons or type /help for available commands.")
+		t.sendMessage(chatID, i18n.FromContext(ctx).T("bot.use_menu"))
 	}
 }
 
@@ -141,9 +232,10 @@ func (t *TelegramBotService) handleCallbackQuery(ctx context.Context, query *tgb
 	// Get user session
 	session, err := t.sessions.GetTelegramSession(ctx, chatID)
 	if err != nil {
-		t.sendMessage(chatID, "Session error. Please restart with /start")
+		t.sendMessage(chatID, i18n.New(query.From.LanguageCode).T("bot.session_error"))
 		return
 	}
+	ctx = t.withLanguage(ctx, &session, query.From)
 
 	switch {
 	case strings.HasPrefix(data, "blockchain_"):
@@ -159,19 +251,19 @@ func (t *TelegramBotService) handleCallbackQuery(ctx context.Context, query *tgb
 		blockchain := strings.TrimPrefix(data, "view_notifications_")
 		t.handleViewNotificationsForBlockchain(ctx, chatID, blockchain, &session)
 	case data == "main_menu":
-		t.sendMainMenu(chatID)
+		t.sendMainMenu(ctx, chatID)
 		session.State = domain.StateIdle
 		t.sessions.UpsertTelegramSession(ctx, session)
 	case data == "list_subscriptions":
 		t.handleListSubscriptions(ctx, chatID, &session)
 	case data == "add_address_menu":
-		t.sendBlockchainSelection(chatID)
+		t.sendBlockchainSelection(ctx, chatID)
 		session.LastAction = ""
 		t.sessions.UpsertTelegramSession(ctx, session)
 	case data == "remove_address_menu":
-		t.sendBlockchainSelection(chatID)
+		t.sendBlockchainSelection(ctx, chatID)
 	case data == "view_notifications_menu":
-		t.sendBlockchainSelection(chatID)
+		t.sendBlockchainSelection(ctx, chatID)
 	case strings.HasPrefix(data, "list_"):
 		blockchain := strings.TrimPrefix(data, "list_")
 		t.handleListSubscriptionsForBlockchain(ctx, chatID, blockchain, &session)
@@ -183,6 +275,34 @@ func (t *TelegramBotService) handleCallbackQuery(ctx context.Context, query *tgb
 			index := parts[2]
 			t.handleRemoveSpecificAddress(ctx, chatID, blockchain, index, &session)
 		}
+	case strings.HasPrefix(data, "settings_"):
+		// Handle settings menu for specific address
+		parts := strings.Split(data, "_")
+		if len(parts) >= 3 {
+			t.handleSubscriptionSettings(ctx, chatID, parts[1], parts[2], &session)
+		}
+	case strings.HasPrefix(data, "rule_"):
+		// Handle editing one rule of specific address
+		parts := strings.Split(data, "_")
+		if len(parts) >= 4 {
+			t.handleRuleSelection(ctx, chatID, parts[1], parts[2], parts[3], &session)
+		}
+	case strings.HasPrefix(data, "snooze_"):
+		t.handleSnoozeCallback(ctx, chatID, strings.TrimPrefix(data, "snooze_"), &session)
+	case strings.HasPrefix(data, "quiet_"):
+		t.handleQuietCallback(ctx, chatID, strings.TrimPrefix(data, "quiet_"), &session)
+	case strings.HasPrefix(data, "language_"):
+		t.handleLanguage(ctx, chatID, strings.TrimPrefix(data, "language_"), &session)
+	case strings.HasPrefix(data, "template_"):
+		t.handleTemplate(ctx, chatID, strings.TrimPrefix(data, "template_"), &session)
+	case strings.HasPrefix(data, "alert_"):
+		t.handleAlertCallback(ctx, chatID, strings.TrimPrefix(data, "alert_"))
+	case strings.HasPrefix(data, "route_"):
+		// Handle turning one channel of specific address on or off
+		parts := strings.Split(data, "_")
+		if len(parts) >= 4 {
+			t.handleRouteToggle(ctx, chatID, parts[1], parts[2], parts[3])
+		}
 	case strings.HasPrefix(data, "notifications_"):
 		// Handle view notifications for specific address
 		parts := strings.Split(data, "_")
@@ -194,74 +314,43 @@ func (t *TelegramBotService) handleCallbackQuery(ctx context.Context, query *tgb
 	}
 }
 
-func (t *TelegramBotService) sendWelcomeMessage(chatID string) {
-	msg := `🚀 *Welcome to Wallet Transaction Notifier!*
-
-I'll help you monitor your cryptocurrency wallet addresses and notify you about incoming and outgoing transactions.
-
-*Features:*
-• 📊 Monitor multiple blockchain networks
-• 🔔 Real-time transaction notifications  
-• 📝 View transaction history
-• ⚡ Easy address management
-
-Use the buttons below to get started!`
-
+func (t *TelegramBotService) sendWelcomeMessage(ctx context.Context, chatID string) {
+	l := i18n.FromContext(ctx)
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 Main Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.main_menu"), "main_menu"),
 		),
 	)
 
-	t.sendMessageWithKeyboard(chatID, msg, keyboard)
+	t.sendMessageWithKeyboard(chatID, l.T("bot.welcome"), keyboard)
 }
 
-func (t *TelegramBotService) sendHelpMessage(chatID string) {
-	msg := `*Available Commands:*
-
-/start - Start the bot and show welcome message
-/help - Show this help message
-/menu - Show main menu
-
-*How to use:*
-1. Select a blockchain network
-2. Add wallet addresses to monitor
-3. Receive real-time notifications
-4. View transaction history
-
-Use the menu buttons for easy navigation!`
-
-	t.sendMessage(chatID, msg)
+func (t *TelegramBotService) sendHelpMessage(ctx context.Context, chatID string) {
+	t.sendMessage(chatID, i18n.FromContext(ctx).T("bot.help"))
 }
 
-func (t *TelegramBotService) sendMainMenu(chatID string) {
-	msg := `🎯 *Main Menu*
-
-Choose what you'd like to do:`
-
+func (t *TelegramBotService) sendMainMenu(ctx context.Context, chatID string) {
+	l := i18n.FromContext(ctx)
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➕ Add Address", "add_address_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_address"), "add_address_menu"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 List Subscriptions", "list_subscriptions"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.list_subscriptions"), "list_subscriptions"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🗑️ Remove Address", "remove_address_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.remove_address"), "remove_address_menu"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 View Notifications", "view_notifications_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.view_notifications"), "view_notifications_menu"),
 		),
 	)
 
-	t.sendMessageWithKeyboard(chatID, msg, keyboard)
+	t.sendMessageWithKeyboard(chatID, l.T("menu.title"), keyboard)
 }
 
-func (t *TelegramBotService) sendBlockchainSelection(chatID string) {
-	msg := `🔗 *Select Blockchain Network*
-
-Choose the blockchain you want to work with:`
-
+func (t *TelegramBotService) sendBlockchainSelection(ctx context.Context, chatID string) {
+	l := i18n.FromContext(ctx)
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🔷 Ethereum", "blockchain_ethereum"),
@@ -270,31 +359,32 @@ Choose the blockchain you want to work with:`
 			tgbotapi.NewInlineKeyboardButtonData("🟠 Bitcoin", "blockchain_bitcoin"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
-	t.sendMessageWithKeyboard(chatID, msg, keyboard)
+	t.sendMessageWithKeyboard(chatID, l.T("blockchain.select"), keyboard)
 }
 
 func (t *TelegramBotService) handleBlockchainSelection(ctx context.Context, chatID, blockchain string, session *domain.TelegramSession) {
-	msg := fmt.Sprintf("✅ Selected *%s* network\n\nWhat would you like to do?", strings.Title(blockchain))
+	l := i18n.FromContext(ctx)
+	msg := l.T("blockchain.selected", strings.Title(blockchain))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➕ Add Address", fmt.Sprintf("add_address_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_address"), fmt.Sprintf("add_address_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📋 List Addresses", fmt.Sprintf("list_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.list_addresses"), fmt.Sprintf("list_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🗑️ Remove Address", fmt.Sprintf("remove_address_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.remove_address"), fmt.Sprintf("remove_address_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 View Notifications", fmt.Sprintf("view_notifications_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.view_notifications"), fmt.Sprintf("view_notifications_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
@@ -302,7 +392,8 @@ func (t *TelegramBotService) handleBlockchainSelection(ctx context.Context, chat
 }
 
 func (t *TelegramBotService) handleAddAddressForBlockchain(ctx context.Context, chatID, blockchain string, session *domain.TelegramSession) {
-	msg := fmt.Sprintf("📝 *Add %s Address*\n\nPlease send me the wallet address you want to monitor:", strings.Title(blockchain))
+	l := i18n.FromContext(ctx)
+	msg := l.T("address.add_prompt", strings.Title(blockchain))
 	t.sendMessage(chatID, msg)
 	
 	log.Printf("Setting state to StateAddAddress for chat %s, blockchain: %s", chatID, blockchain)
@@ -319,6 +410,7 @@ func (t *TelegramBotService) handleAddAddressForBlockchain(ctx context.Context,
 }
 
 func (t *TelegramBotService) handleAddAddress(ctx context.Context, chatID, address string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	blockchain := session.LastAction
 	address = strings.ToLower(strings.TrimSpace(address))
 
@@ -332,8 +424,8 @@ func (t *TelegramBotService) handleAddAddress(ctx context.Context, chatID, addre
 			_ = t.sessions.UpsertTelegramSession(ctx, *session)
 		} else {
 			log.Printf("Blockchain not chosen and could not auto-detect for address %s. Prompting selection.", address)
-			t.sendMessage(chatID, "Please choose the blockchain for this address.")
-			t.sendBlockchainSelection(chatID)
+			t.sendMessage(chatID, l.T("address.choose_blockchain"))
+			t.sendBlockchainSelection(ctx, chatID)
 			session.State = domain.StateSelectBlockchain
 			_ = t.sessions.UpsertTelegramSession(ctx, *session)
 			return
@@ -345,7 +437,7 @@ func (t *TelegramBotService) handleAddAddress(ctx context.Context, chatID, addre
 	// Basic address validation
 	if !t.isValidAddress(address, blockchain) {
 		log.Printf("Invalid address format: %s for blockchain: %s", address, blockchain)
-		t.sendMessage(chatID, "❌ Invalid address format. Please try again with a valid address.")
+		t.sendMessage(chatID, l.T("address.invalid"))
 		return
 	}
 
@@ -360,20 +452,19 @@ func (t *TelegramBotService) handleAddAddress(ctx context.Context, chatID, addre
 	err := t.subs.AddSubscription(ctx, subscription)
 	if err != nil {
 		log.Printf("Failed to add subscription: %v", err)
-		t.sendMessage(chatID, "❌ Failed to add subscription. Please try again.")
+		t.sendMessage(chatID, l.T("address.add_failed"))
 		return
 	}
 
 	log.Printf("Successfully added subscription for chat %s", chatID)
-	msg := fmt.Sprintf("✅ Successfully added address to monitor!\n\n🔗 *Network:* %s\n📍 *Address:* `%s`",
-		strings.Title(blockchain), address)
+	msg := l.T("address.added", strings.Title(blockchain), address)
 	
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➕ Add Another", fmt.Sprintf("add_address_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_another"), fmt.Sprintf("add_address_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
@@ -383,15 +474,16 @@ func (t *TelegramBotService) handleAddAddress(ctx context.Context, chatID, addre
 }
 
 func (t *TelegramBotService) handleListSubscriptions(ctx context.Context, chatID string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// Get all subscriptions for this chat
 	ethereumSubs, _ := t.subs.ListSubscriptions(ctx, chatID, "ethereum")
 	bitcoinSubs, _ := t.subs.ListSubscriptions(ctx, chatID, "bitcoin")
 
 	if len(ethereumSubs) == 0 && len(bitcoinSubs) == 0 {
-		msg := "📋 *Your Subscriptions*\n\nNo addresses are being monitored yet.\n\nUse the menu to add some addresses!"
+		msg := l.T("subscriptions.empty")
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("➕ Add Address", "add_address_menu"),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_address"), "add_address_menu"),
 			),
 		)
 		t.sendMessageWithKeyboard(chatID, msg, keyboard)
@@ -399,7 +491,7 @@ func (t *TelegramBotService) handleListSubscriptions(ctx context.Context, chatID
 	}
 
 	var msg strings.Builder
-	msg.WriteString("📋 *Your Subscriptions*\n\n")
+	msg.WriteString(l.T("subscriptions.title") + "\n\n")
 
 	if len(ethereumSubs) > 0 {
 		msg.WriteString("🔷 *Ethereum:*\n")
@@ -418,10 +510,10 @@ func (t *TelegramBotService) handleListSubscriptions(ctx context.Context, chatID
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➕ Add More", "add_address_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_more"), "add_address_menu"),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
@@ -429,13 +521,14 @@ func (t *TelegramBotService) handleListSubscriptions(ctx context.Context, chatID
 }
 
 func (t *TelegramBotService) handleRemoveAddressForBlockchain(ctx context.Context, chatID, blockchain string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// Get subscriptions for this blockchain
 	subs, err := t.subs.ListSubscriptions(ctx, chatID, blockchain)
 	if err != nil || len(subs) == 0 {
-		msg := fmt.Sprintf("📋 *Remove %s Address*\n\nNo addresses found for %s network.", strings.Title(blockchain), strings.Title(blockchain))
+		msg := l.T("address.remove_none", strings.Title(blockchain))
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 			),
 		)
 		t.sendMessageWithKeyboard(chatID, msg, keyboard)
@@ -443,7 +536,7 @@ func (t *TelegramBotService) handleRemoveAddressForBlockchain(ctx context.Contex
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("🗑️ *Remove %s Address*\n\nSelect an address to remove:\n\n", strings.Title(blockchain)))
+	msg.WriteString(l.T("address.remove_select", strings.Title(blockchain)) + "\n\n")
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup()
 	for i, sub := range subs {
@@ -455,20 +548,21 @@ func (t *TelegramBotService) handleRemoveAddressForBlockchain(ctx context.Contex
 		))
 	}
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+		tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 	))
 
 	t.sendMessageWithKeyboard(chatID, msg.String(), keyboard)
 }
 
 func (t *TelegramBotService) handleViewNotificationsForBlockchain(ctx context.Context, chatID, blockchain string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// Get subscriptions for this blockchain
 	subs, err := t.subs.ListSubscriptions(ctx, chatID, blockchain)
 	if err != nil || len(subs) == 0 {
-		msg := fmt.Sprintf("📊 *%s Notifications*\n\nNo addresses found for %s network.", strings.Title(blockchain), strings.Title(blockchain))
+		msg := l.T("notifications.no_addresses", strings.Title(blockchain))
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 			),
 		)
 		t.sendMessageWithKeyboard(chatID, msg, keyboard)
@@ -476,7 +570,7 @@ func (t *TelegramBotService) handleViewNotificationsForBlockchain(ctx context.Co
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("📊 *%s Notifications*\n\nSelect an address to view notifications:\n\n", strings.Title(blockchain)))
+	msg.WriteString(l.T("notifications.select", strings.Title(blockchain)) + "\n\n")
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup()
 	for i, sub := range subs {
@@ -488,36 +582,31 @@ func (t *TelegramBotService) handleViewNotificationsForBlockchain(ctx context.Co
 		))
 	}
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+		tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 	))
 
 	t.sendMessageWithKeyboard(chatID, msg.String(), keyboard)
 }
 
 func (t *TelegramBotService) handleRemoveAddress(ctx context.Context, chatID, text string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// This would be called when user types an address to remove
 	// For now, we'll handle this through callback queries
-	t.sendMessage(chatID, "Please use the menu buttons to remove addresses.")
+	t.sendMessage(chatID, l.T("address.remove_use_menu"))
 }
 
 func (t *TelegramBotService) handleViewNotifications(ctx context.Context, chatID, text string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// This would be called when user types an address to view notifications
 	// For now, we'll handle this through callback queries
-	t.sendMessage(chatID, "Please use the menu buttons to view notifications.")
+	t.sendMessage(chatID, l.T("notifications.use_menu"))
 }
 
 func (t *TelegramBotService) isValidAddress(address, blockchain string) bool {
 	// Basic validation - in a real implementation, you'd want more robust validation
 
 	log.Printf("Validating address: %s for blockchain: %s", address, blockchain)
-	switch blockchain {
-	case "ethereum":
-		return len(address) == 42 && strings.HasPrefix(address, "0x")
-	case "bitcoin":
-		return len(address) >= 26 && len(address) <= 35
-	default:
-		return false
-	}
+	return validAddress(blockchain, address)
 }
 
 func (t *TelegramBotService) sendMessage(chatID, text string) {
@@ -540,15 +629,16 @@ func (t *TelegramBotService) sendMessageWithKeyboard(chatID, text string, keyboa
 }
 
 func (t *TelegramBotService) handleListSubscriptionsForBlockchain(ctx context.Context, chatID, blockchain string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	subs, err := t.subs.ListSubscriptions(ctx, chatID, blockchain)
 	if err != nil || len(subs) == 0 {
-		msg := fmt.Sprintf("📋 *%s Addresses*\n\nNo addresses found for %s network.", strings.Title(blockchain), strings.Title(blockchain))
+		msg := l.T("addresses.none", strings.Title(blockchain))
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("➕ Add Address", fmt.Sprintf("add_address_%s", blockchain)),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_address"), fmt.Sprintf("add_address_%s", blockchain)),
 			),
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 			),
 		)
 		t.sendMessageWithKeyboard(chatID, msg, keyboard)
@@ -556,17 +646,32 @@ func (t *TelegramBotService) handleListSubscriptionsForBlockchain(ctx context.Co
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("📋 *%s Addresses*\n\n", strings.Title(blockchain)))
+	msg.WriteString(l.T("addresses.title", strings.Title(blockchain)) + "\n\n")
 	for i, sub := range subs {
 		msg.WriteString(fmt.Sprintf("%d. `%s`\n", i+1, sub.Address))
+		if !sub.Rules.IsZero() {
+			msg.WriteString("   ⚙️ " + describeRules(l, sub.Rules, session.Settings.Fiat()) + "\n")
+		}
+		if sub.Muted(time.Now()) {
+			msg.WriteString("   " + l.T("addresses.muted") + "\n")
+		}
 	}
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	for i, sub := range subs {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("⚙️ %s", sub.Address[:8]+"..."),
+				fmt.Sprintf("settings_%s_%d", blockchain, i),
+			),
+		))
+	}
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard,
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➕ Add More", fmt.Sprintf("add_address_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.add_more"), fmt.Sprintf("add_address_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
@@ -574,36 +679,36 @@ func (t *TelegramBotService) handleListSubscriptionsForBlockchain(ctx context.Co
 }
 
 func (t *TelegramBotService) handleRemoveSpecificAddress(ctx context.Context, chatID, blockchain, indexStr string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// Get subscriptions for this blockchain
 	subs, err := t.subs.ListSubscriptions(ctx, chatID, blockchain)
 	if err != nil {
-		t.sendMessage(chatID, "❌ Error retrieving subscriptions.")
+		t.sendMessage(chatID, l.T("error.subscriptions"))
 		return
 	}
 
 	// Parse index
 	var index int
 	if _, err := fmt.Sscanf(indexStr, "%d", &index); err != nil || index < 0 || index >= len(subs) {
-		t.sendMessage(chatID, "❌ Invalid address selection.")
+		t.sendMessage(chatID, l.T("error.address_selection"))
 		return
 	}
 
 	address := subs[index].Address
 	err = t.subs.RemoveSubscription(ctx, chatID, blockchain, address)
 	if err != nil {
-		t.sendMessage(chatID, "❌ Failed to remove subscription.")
+		t.sendMessage(chatID, l.T("address.remove_failed"))
 		return
 	}
 
-	msg := fmt.Sprintf("✅ Successfully removed address!\n\n🔗 *Network:* %s\n📍 *Address:* `%s`", 
-		strings.Title(blockchain), address)
+	msg := l.T("address.removed", strings.Title(blockchain), address)
 	
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🗑️ Remove Another", fmt.Sprintf("remove_address_%s", blockchain)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.remove_another"), fmt.Sprintf("remove_address_%s", blockchain)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
@@ -611,32 +716,33 @@ func (t *TelegramBotService) handleRemoveSpecificAddress(ctx context.Context, ch
 }
 
 func (t *TelegramBotService) handleViewSpecificNotifications(ctx context.Context, chatID, blockchain, indexStr string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
 	// Get subscriptions for this blockchain
 	subs, err := t.subs.ListSubscriptions(ctx, chatID, blockchain)
 	if err != nil {
-		t.sendMessage(chatID, "❌ Error retrieving subscriptions.")
+		t.sendMessage(chatID, l.T("error.subscriptions"))
 		return
 	}
 
 	// Parse index
 	var index int
 	if _, err := fmt.Sscanf(indexStr, "%d", &index); err != nil || index < 0 || index >= len(subs) {
-		t.sendMessage(chatID, "❌ Invalid address selection.")
+		t.sendMessage(chatID, l.T("error.address_selection"))
 		return
 	}
 
 	address := subs[index].Address
 	notifications, err := t.notifs.ListByAddress(ctx, chatID, blockchain, address, 10)
 	if err != nil {
-		t.sendMessage(chatID, "❌ Error retrieving notifications.")
+		t.sendMessage(chatID, l.T("error.notifications"))
 		return
 	}
 
 	if len(notifications) == 0 {
-		msg := fmt.Sprintf("📊 *Notifications for %s*\n\nNo notifications found for this address yet.", address[:8]+"...")
+		msg := l.T("notifications.empty", address[:8]+"...")
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+				tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 			),
 		)
 		t.sendMessageWithKeyboard(chatID, msg, keyboard)
@@ -644,24 +750,50 @@ func (t *TelegramBotService) handleViewSpecificNotifications(ctx context.Context
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("📊 *Notifications for %s*\n\n", address[:8]+"..."))
+	msg.WriteString(l.T("notifications.title", address[:8]+"...") + "\n\n")
 	
 	for i, notif := range notifications {
 		direction := "📥"
 		if notif.Direction == domain.DirectionOutgoing {
 			direction = "📤"
 		}
-		timestamp := time.Unix(notif.Timestamp, 0).Format("2006-01-02 15:04:05")
-		msg.WriteString(fmt.Sprintf("%d. %s %s %s %s\n   `%s`\n   %s\n\n", 
-			i+1, direction, fmt.Sprintf("%.6f", notif.Amount), notif.Currency, 
+		timestamp := formatLocal(l, time.Unix(notif.Timestamp, 0), session.Settings)
+		amount := l.Number(notif.Amount) + " " + notif.Currency
+		if notif.FiatCurrency != "" {
+			amount += " (≈ " + l.Money(notif.FiatValue, notif.FiatCurrency) + ")"
+		}
+		msg.WriteString(fmt.Sprintf("%s. %s %s %s\n   `%s`\n   %s\n", 
+			l.Int(i+1), direction, amount, 
 			notif.TxHash[:8]+"...", notif.TxHash, timestamp))
+		if status, ok := notif.Deliveries[domain.ChannelTelegram]; ok {
+			msg.WriteString("   " + deliveryStatusLabel(l, status) + "\n")
+		}
+		msg.WriteString("\n")
 	}
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_menu"), "main_menu"),
 		),
 	)
 
 	t.sendMessageWithKeyboard(chatID, msg.String(), keyboard)
 }
+
+// deliveryStatusLabel describes a delivery status for the history view. Error texts are
+// left out as they may break the Markdown formatting; the API exposes them.
+func deliveryStatusLabel(l i18n.Localizer, status domain.DeliveryStatus) string {
+	switch status.State {
+	case domain.DeliverySent:
+		return l.T("delivery.sent")
+	case domain.DeliveryFailed:
+		return l.N("delivery.failed", status.Attempts)
+	case domain.DeliveryMuted:
+		return l.T("delivery.muted")
+	default:
+		if status.Attempts > 0 {
+			return l.N("delivery.retrying", status.Attempts)
+		}
+		return l.T("delivery.queued")
+	}
+}
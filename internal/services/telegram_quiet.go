@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/wallet_transaction_notifier/internal/domain"
+	"github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+)
+
+// snoozePresets are offered as buttons by /snooze, keyed by their callback suffix, which
+// also names their label in the message catalogs.
+var snoozePresets = []struct {
+	key string
+	d   time.Duration
+}{
+	{"1h", time.Hour},
+	{"8h", 8 * time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// parseSnooze parses durations such as "30m", "8h" or "2d".
+func parseSnooze(text string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", text)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", text)
+	}
+	return d, nil
+}
+
+// formatLocal shows a time in the chat's time zone and language.
+func formatLocal(l i18n.Localizer, t time.Time, settings domain.ChatSettings) string {
+	return l.DateTime(t.In(settings.Location()))
+}
+
+func (t *TelegramBotService) saveChatSettings(ctx context.Context, chatID string, session *domain.TelegramSession, settings domain.ChatSettings) bool {
+	l := i18n.FromContext(ctx)
+	if err := t.sessions.UpdateChatSettings(ctx, chatID, settings); err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return false
+	}
+	session.Settings = settings
+	return true
+}
+
+func (t *TelegramBotService) handleTimezone(ctx context.Context, chatID, args string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	if args == "" {
+		zone := session.Settings.Timezone
+		if zone == "" {
+			zone = "UTC"
+		}
+		t.sendMessage(chatID, l.T("timezone.current", zone))
+		return
+	}
+	if _, err := time.LoadLocation(args); err != nil {
+		t.sendMessage(chatID, l.T("timezone.unknown"))
+		return
+	}
+	settings := session.Settings
+	settings.Timezone = args
+	if args == "UTC" {
+		settings.Timezone = ""
+	}
+	if t.saveChatSettings(ctx, chatID, session, settings) {
+		t.sendMessage(chatID, l.T("timezone.saved", args))
+	}
+}
+
+// handleTemplate shows the template sets to pick from, or switches the chat to the named one.
+func (t *TelegramBotService) handleTemplate(ctx context.Context, chatID, name string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	if len(t.templateSets) == 0 {
+		t.sendMessage(chatID, l.T("template.unavailable"))
+		return
+	}
+	current := session.Settings.Template
+	if current == "" {
+		current = t.templateSets[0]
+	}
+	if name == "" {
+		rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(t.templateSets))
+		for _, set := range t.templateSets {
+			label := set
+			if set == current {
+				label = "✅ " + set
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "template_"+set)))
+		}
+		t.sendMessageWithKeyboard(chatID, l.T("template.current", current),
+			tgbotapi.NewInlineKeyboardMarkup(rows...))
+		return
+	}
+
+	found := false
+	for _, set := range t.templateSets {
+		found = found || set == name
+	}
+	if !found {
+		t.sendMessage(chatID, l.T("template.unknown", strings.Join(t.templateSets, ", ")))
+		return
+	}
+	settings := session.Settings
+	settings.Template = name
+	if name == t.templateSets[0] {
+		settings.Template = ""
+	}
+	if t.saveChatSettings(ctx, chatID, session, settings) {
+		t.sendMessage(chatID, l.T("template.saved", name))
+	}
+}
+
+// handleCurrency shows the fiat currency transfers are valued in, or switches it to the given
+// ISO 4217 code. Whether a price exists for that currency is up to the price provider.
+func (t *TelegramBotService) handleCurrency(ctx context.Context, chatID, code string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	if code == "" {
+		t.sendMessage(chatID, l.T("currency.current", strings.ToUpper(session.Settings.Fiat())))
+		return
+	}
+	code = strings.ToLower(code)
+	if len(code) != 3 || strings.Trim(code, "abcdefghijklmnopqrstuvwxyz") != "" {
+		t.sendMessage(chatID, l.T("currency.invalid"))
+		return
+	}
+	settings := session.Settings
+	settings.FiatCurrency = code
+	if code == domain.DefaultFiatCurrency {
+		settings.FiatCurrency = ""
+	}
+	if t.saveChatSettings(ctx, chatID, session, settings) {
+		t.sendMessage(chatID, l.T("currency.saved", strings.ToUpper(code)))
+	}
+}
+
+// handleLanguage shows the languages to pick from, or switches the chat to the given one.
+func (t *TelegramBotService) handleLanguage(ctx context.Context, chatID, lang string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	if lang == "" {
+		rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(i18n.Languages))
+		for _, code := range i18n.Languages {
+			label := i18n.New(code).T("language.name")
+			if code == l.Lang() {
+				label = "✅ " + label
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "language_"+code)))
+		}
+		t.sendMessageWithKeyboard(chatID, l.T("language.current", l.T("language.name")), tgbotapi.NewInlineKeyboardMarkup(rows...))
+		return
+	}
+
+	lang = strings.ToLower(lang)
+	if i18n.Match(lang) != lang {
+		t.sendMessage(chatID, l.T("language.unknown", strings.Join(i18n.Languages, ", ")))
+		return
+	}
+	if err := t.sessions.UpdateLanguage(ctx, chatID, lang, session.LanguageCode); err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return
+	}
+	session.Language = lang
+	l = i18n.New(lang)
+	t.sendMessage(chatID, l.T("language.saved", l.T("language.name")))
+}
+
+func (t *TelegramBotService) handleQuietHours(ctx context.Context, chatID, args string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		t.sendQuietHours(ctx, chatID, session.Settings)
+		return
+	}
+	settings := session.Settings
+	if fields[0] == "off" {
+		settings.QuietHours = nil
+		if t.saveChatSettings(ctx, chatID, session, settings) {
+			t.sendMessage(chatID, l.T("quiet.turned_off"))
+		}
+		return
+	}
+
+	quiet, err := domain.ParseQuietHours(fields[0])
+	if err != nil {
+		t.sendMessage(chatID, l.T("quiet.invalid", err.Error()))
+		return
+	}
+	if len(fields) > 1 {
+		quiet.MinAmount, err = strconv.ParseFloat(fields[1], 64)
+		if err != nil || quiet.MinAmount < 0 {
+			t.sendMessage(chatID, l.T("quiet.invalid_amount"))
+			return
+		}
+	}
+	if settings.QuietHours != nil {
+		quiet.Digest = settings.QuietHours.Digest
+	}
+	settings.QuietHours = &quiet
+	if t.saveChatSettings(ctx, chatID, session, settings) {
+		t.sendQuietHours(ctx, chatID, settings)
+	}
+}
+
+func (t *TelegramBotService) sendQuietHours(ctx context.Context, chatID string, settings domain.ChatSettings) {
+	l := i18n.FromContext(ctx)
+	zone := settings.Timezone
+	if zone == "" {
+		zone = "UTC"
+	}
+	quiet := settings.QuietHours
+	if quiet == nil {
+		t.sendMessage(chatID, l.T("quiet.off", zone))
+		return
+	}
+
+	held := l.T("quiet.held_dropped")
+	if quiet.Digest {
+		held = l.T("quiet.held_digest")
+	}
+	through := l.T("rules.none")
+	if quiet.MinAmount > 0 {
+		through = l.T("quiet.through", quiet.MinAmount)
+	}
+	msg := l.T("quiet.title", quiet.String(), zone, through, held)
+
+	digestLabel := l.T("button.quiet_digest")
+	if quiet.Digest {
+		digestLabel = l.T("button.quiet_drop")
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(digestLabel, "quiet_digest")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(l.T("button.quiet_off"), "quiet_off")),
+	)
+	t.sendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (t *TelegramBotService) handleQuietCallback(ctx context.Context, chatID, action string, session *domain.TelegramSession) {
+	settings := session.Settings
+	if settings.QuietHours == nil {
+		t.sendQuietHours(ctx, chatID, settings)
+		return
+	}
+	quiet := *settings.QuietHours
+	switch action {
+	case "digest":
+		quiet.Digest = !quiet.Digest
+		settings.QuietHours = &quiet
+	case "off":
+		settings.QuietHours = nil
+	default:
+		return
+	}
+	if t.saveChatSettings(ctx, chatID, session, settings) {
+		t.sendQuietHours(ctx, chatID, settings)
+	}
+}
+
+func (t *TelegramBotService) handleSnooze(ctx context.Context, chatID, args string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	if args == "" {
+		msg := l.T("snooze.title")
+		if session.Settings.Snoozed(time.Now()) {
+			msg = l.T("snooze.until", formatLocal(l, session.Settings.SnoozedUntil, session.Settings))
+		}
+		msg += "\n\n" + l.T("snooze.pick")
+
+		row := tgbotapi.NewInlineKeyboardRow()
+		for _, p := range snoozePresets {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(l.T("snooze.preset."+p.key), "snooze_"+p.key))
+		}
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(row,
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(l.T("button.snooze_off"), "snooze_off")),
+		)
+		t.sendMessageWithKeyboard(chatID, msg, keyboard)
+		return
+	}
+	if args == "off" {
+		t.snoozeFor(ctx, chatID, 0, session)
+		return
+	}
+	d, err := parseSnooze(args)
+	if err != nil {
+		t.sendMessage(chatID, l.T("snooze.invalid"))
+		return
+	}
+	t.snoozeFor(ctx, chatID, d, session)
+}
+
+func (t *TelegramBotService) handleSnoozeCallback(ctx context.Context, chatID, key string, session *domain.TelegramSession) {
+	if key == "off" {
+		t.snoozeFor(ctx, chatID, 0, session)
+		return
+	}
+	for _, p := range snoozePresets {
+		if p.key == key {
+			t.snoozeFor(ctx, chatID, p.d, session)
+			return
+		}
+	}
+}
+
+// snoozeFor silences the chat for d; zero resumes alerts.
+func (t *TelegramBotService) snoozeFor(ctx context.Context, chatID string, d time.Duration, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	settings := session.Settings
+	settings.SnoozedUntil = time.Time{}
+	if d > 0 {
+		settings.SnoozedUntil = time.Now().Add(d)
+	}
+	if !t.saveChatSettings(ctx, chatID, session, settings) {
+		return
+	}
+	if d == 0 {
+		t.sendMessage(chatID, l.T("snooze.resumed"))
+		return
+	}
+	t.sendMessage(chatID, l.T("snooze.saved", formatLocal(l, settings.SnoozedUntil, settings)))
+}
+
+// handleMuteSelection mutes or unmutes one subscription from its settings menu.
+func (t *TelegramBotService) handleMuteSelection(ctx context.Context, chatID, blockchain, indexStr string, sub domain.Subscription, field string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	var until time.Time
+	switch field {
+	case ruleMute:
+		until = time.Now().Add(24 * time.Hour)
+	case ruleMuteAll:
+		until = domain.MutedForever
+	}
+	if err := t.subs.MuteSubscription(ctx, chatID, blockchain, sub.Address, until); err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return
+	}
+	sub.MutedUntil = until
+	t.sendMessage(chatID, "✅ "+t.describeMute(ctx, chatID, sub))
+	t.handleSubscriptionSettings(ctx, chatID, blockchain, indexStr, session)
+}
+
+// describeMute tells whether a subscription is muted and until when.
+func (t *TelegramBotService) describeMute(ctx context.Context, chatID string, sub domain.Subscription) string {
+	l := i18n.FromContext(ctx)
+	switch {
+	case !sub.Muted(time.Now()):
+		return l.T("mute.off")
+	case !sub.MutedUntil.Before(domain.MutedForever):
+		return l.T("mute.forever")
+	}
+	session, err := t.sessions.GetTelegramSession(ctx, chatID)
+	if err != nil {
+		session = domain.TelegramSession{}
+	}
+	return l.T("mute.until", formatLocal(l, sub.MutedUntil, session.Settings))
+}
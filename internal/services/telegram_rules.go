@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/you/wallet_transaction_notifier/internal/domain"
+	"github.com/you/wallet_transaction_notifier/internal/infra/i18n"
+)
+
+// Rule fields as used in callback data and in the session while waiting for a value.
+const (
+	ruleMin       = "min"
+	ruleMax       = "max"
+	ruleMinValue  = "minv"
+	ruleMaxValue  = "maxv"
+	ruleDirection = "dir"
+	ruleCurrency  = "cur"
+	ruleAllow     = "allow"
+	ruleBlock     = "block"
+	ruleReset     = "reset"
+	ruleMode      = "mode"
+	ruleMute      = "mute"
+	ruleMuteAll   = "muteall"
+	ruleUnmute    = "unmute"
+	ruleChannels  = "chan"
+)
+
+// subscriptionAt returns the subscription shown at index in the address lists.
+func (t *TelegramBotService) subscriptionAt(ctx context.Context, chatID, blockchain, indexStr string) (domain.Subscription, bool) {
+	l := i18n.FromContext(ctx)
+	subs, err := t.subs.ListSubscriptions(ctx, chatID, blockchain)
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.subscriptions"))
+		return domain.Subscription{}, false
+	}
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 0 || index >= len(subs) {
+		t.sendMessage(chatID, l.T("error.address_selection"))
+		return domain.Subscription{}, false
+	}
+	return subs[index], true
+}
+
+func (t *TelegramBotService) handleSubscriptionSettings(ctx context.Context, chatID, blockchain, indexStr string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	sub, ok := t.subscriptionAt(ctx, chatID, blockchain, indexStr)
+	if !ok {
+		return
+	}
+
+	fiat := t.chatFiat(ctx, chatID, session)
+	msg := l.T("settings.title", sub.Address, describeRulesLong(l, sub.Rules, fiat), deliveryModeLabel(l, sub.Mode), t.describeMute(ctx, chatID, sub))
+	button := func(label, field string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("rule_%s_%s_%s", blockchain, indexStr, field))
+	}
+	muteRow := tgbotapi.NewInlineKeyboardRow(button(l.T("button.mute_24h"), ruleMute), button(l.T("button.mute"), ruleMuteAll))
+	if sub.Muted(time.Now()) {
+		muteRow = tgbotapi.NewInlineKeyboardRow(button(l.T("button.unmute"), ruleUnmute))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.min_amount"), ruleMin), button(l.T("button.max_amount"), ruleMax)),
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.min_value"), ruleMinValue), button(l.T("button.max_value"), ruleMaxValue)),
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.direction"), ruleDirection), button(l.T("button.currencies"), ruleCurrency)),
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.allowlist"), ruleAllow), button(l.T("button.blocklist"), ruleBlock)),
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.delivery", deliveryModeLabel(l, sub.Mode)), ruleMode)),
+		muteRow,
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.channels"), ruleChannels)),
+		tgbotapi.NewInlineKeyboardRow(button(l.T("button.reset_all"), ruleReset)),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.back_to_addresses"), fmt.Sprintf("list_%s", blockchain)),
+		),
+	)
+	t.sendMessageWithKeyboard(chatID, msg, keyboard)
+}
+
+func (t *TelegramBotService) handleRuleSelection(ctx context.Context, chatID, blockchain, indexStr, field string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	sub, ok := t.subscriptionAt(ctx, chatID, blockchain, indexStr)
+	if !ok {
+		return
+	}
+
+	// Direction and reset apply right away, the other rules ask for a value.
+	switch field {
+	case ruleDirection:
+		switch sub.Rules.Direction {
+		case "":
+			sub.Rules.Direction = domain.DirectionIncoming
+		case domain.DirectionIncoming:
+			sub.Rules.Direction = domain.DirectionOutgoing
+		default:
+			sub.Rules.Direction = ""
+		}
+		t.saveRules(ctx, chatID, blockchain, indexStr, sub)
+		return
+	case ruleReset:
+		sub.Rules = domain.SubscriptionRules{}
+		t.saveRules(ctx, chatID, blockchain, indexStr, sub)
+		return
+	case ruleMode:
+		mode := domain.DeliveryHourly
+		switch sub.Mode {
+		case domain.DeliveryHourly:
+			mode = domain.DeliveryDaily
+		case domain.DeliveryDaily:
+			mode = domain.DeliveryInstant
+		}
+		if err := t.subs.SetSubscriptionMode(ctx, chatID, blockchain, sub.Address, mode); err != nil {
+			t.sendMessage(chatID, l.T("error.save_settings"))
+			return
+		}
+		t.sendMessage(chatID, l.T("settings.mode_saved", deliveryModeLabel(l, mode)))
+		t.handleSubscriptionSettings(ctx, chatID, blockchain, indexStr, session)
+		return
+	case ruleMute, ruleMuteAll, ruleUnmute:
+		t.handleMuteSelection(ctx, chatID, blockchain, indexStr, sub, field, session)
+		return
+	case ruleChannels:
+		t.handleChannelRoutes(ctx, chatID, blockchain, indexStr, sub)
+		return
+	}
+
+	switch field {
+	case ruleMin, ruleMax, ruleCurrency, ruleAllow, ruleBlock:
+		t.sendMessage(chatID, l.T("rule.prompt."+field))
+	case ruleMinValue, ruleMaxValue:
+		t.sendMessage(chatID, l.T("rule.prompt."+field, strings.ToUpper(session.Settings.Fiat())))
+	default:
+		t.sendMessage(chatID, l.T("rule.unknown"))
+		return
+	}
+
+	session.State = domain.StateEditRule
+	session.LastAction = fmt.Sprintf("%s_%s_%s", blockchain, indexStr, field)
+	t.sessions.UpsertTelegramSession(ctx, *session)
+}
+
+func (t *TelegramBotService) handleEditRule(ctx context.Context, chatID, text string, session *domain.TelegramSession) {
+	l := i18n.FromContext(ctx)
+	parts := strings.Split(session.LastAction, "_")
+	if len(parts) != 3 {
+		t.sendMessage(chatID, l.T("bot.use_menu"))
+		return
+	}
+	blockchain, indexStr, field := parts[0], parts[1], parts[2]
+	sub, ok := t.subscriptionAt(ctx, chatID, blockchain, indexStr)
+	if !ok {
+		return
+	}
+
+	text = strings.TrimSpace(text)
+	switch field {
+	case ruleMin, ruleMax, ruleMinValue, ruleMaxValue:
+		amount, err := strconv.ParseFloat(text, 64)
+		if err != nil || amount < 0 {
+			t.sendMessage(chatID, l.T("rule.invalid_amount"))
+			return
+		}
+		switch field {
+		case ruleMin:
+			sub.Rules.MinAmount = amount
+		case ruleMax:
+			sub.Rules.MaxAmount = amount
+		case ruleMinValue:
+			sub.Rules.MinValue = amount
+		case ruleMaxValue:
+			sub.Rules.MaxValue = amount
+		}
+		if sub.Rules.MaxAmount > 0 && sub.Rules.MinAmount > sub.Rules.MaxAmount ||
+			sub.Rules.MaxValue > 0 && sub.Rules.MinValue > sub.Rules.MaxValue {
+			t.sendMessage(chatID, l.T("rule.min_above_max"))
+			return
+		}
+	case ruleCurrency:
+		sub.Rules.Currencies = parseList(text, strings.ToUpper)
+	case ruleAllow, ruleBlock:
+		addresses := parseList(text, strings.ToLower)
+		for _, addr := range addresses {
+			if !t.isValidAddress(addr, blockchain) {
+				t.sendMessage(chatID, l.T("rule.invalid_address", addr, strings.Title(blockchain)))
+				return
+			}
+		}
+		if field == ruleAllow {
+			sub.Rules.AllowCounterparties = addresses
+		} else {
+			sub.Rules.BlockCounterparties = addresses
+		}
+	}
+
+	session.State = domain.StateIdle
+	session.LastAction = ""
+	t.sessions.UpsertTelegramSession(ctx, *session)
+	t.saveRules(ctx, chatID, blockchain, indexStr, sub)
+}
+
+func (t *TelegramBotService) saveRules(ctx context.Context, chatID, blockchain, indexStr string, sub domain.Subscription) {
+	l := i18n.FromContext(ctx)
+	if err := t.subs.UpdateSubscriptionRules(ctx, chatID, blockchain, sub.Address, sub.Rules); err != nil {
+		t.sendMessage(chatID, l.T("error.save_settings"))
+		return
+	}
+	t.sendMessage(chatID, l.T("settings.saved"))
+	t.handleSubscriptionSettings(ctx, chatID, blockchain, indexStr, nil)
+}
+
+// chatFiat returns the currency the chat's transfers are valued in, loading its session when
+// none is at hand.
+func (t *TelegramBotService) chatFiat(ctx context.Context, chatID string, session *domain.TelegramSession) string {
+	if session == nil {
+		s, err := t.sessions.GetTelegramSession(ctx, chatID)
+		if err != nil {
+			return domain.DefaultFiatCurrency
+		}
+		session = &s
+	}
+	return session.Settings.Fiat()
+}
+
+func deliveryModeLabel(l i18n.Localizer, mode domain.DeliveryMode) string {
+	switch mode {
+	case domain.DeliveryHourly:
+		return l.T("mode.hourly")
+	case domain.DeliveryDaily:
+		return l.T("mode.daily")
+	default:
+		return l.T("mode.instant")
+	}
+}
+
+// parseList splits a comma or space separated list; "-" clears it.
+func parseList(text string, normalize func(string) string) []string {
+	if text == "-" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		items = append(items, normalize(item))
+	}
+	return items
+}
+
+// describeRules summarises rules on one line for the address list, with values in fiat.
+func describeRules(l i18n.Localizer, r domain.SubscriptionRules, fiat string) string {
+	var parts []string
+	if r.MinAmount > 0 {
+		parts = append(parts, "≥ "+l.Number(r.MinAmount))
+	}
+	if r.MaxAmount > 0 {
+		parts = append(parts, "≤ "+l.Number(r.MaxAmount))
+	}
+	if r.MinValue > 0 {
+		parts = append(parts, "≥ "+l.Money(r.MinValue, fiat))
+	}
+	if r.MaxValue > 0 {
+		parts = append(parts, "≤ "+l.Money(r.MaxValue, fiat))
+	}
+	if r.Direction != "" {
+		parts = append(parts, l.T("rules.only_"+string(r.Direction)))
+	}
+	if len(r.Currencies) > 0 {
+		parts = append(parts, strings.Join(r.Currencies, "/"))
+	}
+	if len(r.AllowCounterparties) > 0 {
+		parts = append(parts, l.N("rules.allowed", len(r.AllowCounterparties)))
+	}
+	if len(r.BlockCounterparties) > 0 {
+		parts = append(parts, l.N("rules.blocked", len(r.BlockCounterparties)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeRulesLong lists every rule for the settings menu, with values in fiat.
+func describeRulesLong(l i18n.Localizer, r domain.SubscriptionRules, fiat string) string {
+	amount := func(v float64) string {
+		if v == 0 {
+			return l.T("rules.none")
+		}
+		return l.Number(v)
+	}
+	value := func(v float64) string {
+		if v == 0 {
+			return l.T("rules.none")
+		}
+		return l.Money(v, fiat)
+	}
+	addresses := func(items []string) string {
+		if len(items) == 0 {
+			return l.T("rules.none")
+		}
+		return "`" + strings.Join(items, "`, `") + "`"
+	}
+	direction := l.T("rules.any")
+	if r.Direction != "" {
+		direction = l.T("direction." + string(r.Direction))
+	}
+	currencies := strings.Join(r.Currencies, ", ")
+	if currencies == "" {
+		currencies = l.T("rules.any")
+	}
+	return l.T("rules.long", amount(r.MinAmount), amount(r.MaxAmount), value(r.MinValue), value(r.MaxValue), direction, currencies,
+		addresses(r.AllowCounterparties), addresses(r.BlockCounterparties))
+}
+
+const ruleExamples = "`outgoing and amount > 10`\n" +
+	"`outgoing and value >= 10000`\n" +
+	"`outgoing and counterparty not in [\"0xabc...\"]`\n" +
+	"`count_outgoing(10m) > 5`\n" +
+	"`incoming and currency == \"ETH\" and sum_incoming(1h) >= 100`"
+
+func (t *TelegramBotService) handleListChatRules(ctx context.Context, chatID string) {
+	l := i18n.FromContext(ctx)
+	if t.rules == nil {
+		t.sendMessage(chatID, l.T("rules.unavailable"))
+		return
+	}
+	rules, err := t.rules.List(ctx, chatID)
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.rules"))
+		return
+	}
+	if len(rules) == 0 {
+		t.sendMessage(chatID, l.T("chatrules.empty", ruleExamples))
+		return
+	}
+
+	var msg strings.Builder
+	msg.WriteString(l.T("chatrules.title") + "\n\n")
+	for i, r := range rules {
+		msg.WriteString(fmt.Sprintf("%s. `%s`\n   `%s`\n", l.Int(i+1), r.Name, r.Expression))
+	}
+	msg.WriteString("\n" + l.T("chatrules.delete_hint"))
+	t.sendMessage(chatID, msg.String())
+}
+
+func (t *TelegramBotService) handleAddChatRule(ctx context.Context, chatID, args string) {
+	l := i18n.FromContext(ctx)
+	if t.rules == nil {
+		t.sendMessage(chatID, l.T("rules.unavailable"))
+		return
+	}
+	if args == "" {
+		t.sendMessage(chatID, l.T("chatrules.usage", ruleExamples))
+		return
+	}
+	name, expression, ok := strings.Cut(args, ":")
+	if !ok {
+		name, expression = "", args
+	}
+	rule, err := t.rules.Create(ctx, chatID, name, expression)
+	if errors.Is(err, domain.ErrInvalidRule) {
+		t.sendMessage(chatID, l.T("chatrules.invalid", strings.ReplaceAll(err.Error(), "`", "'")))
+		return
+	}
+	if err != nil {
+		t.sendMessage(chatID, l.T("chatrules.save_failed"))
+		return
+	}
+	t.sendMessage(chatID, l.T("chatrules.added", rule.Expression))
+}
+
+func (t *TelegramBotService) handleDeleteChatRule(ctx context.Context, chatID, args string) {
+	l := i18n.FromContext(ctx)
+	if t.rules == nil {
+		t.sendMessage(chatID, l.T("rules.unavailable"))
+		return
+	}
+	rules, err := t.rules.List(ctx, chatID)
+	if err != nil {
+		t.sendMessage(chatID, l.T("error.rules"))
+		return
+	}
+	index, err := strconv.Atoi(args)
+	if err != nil || index < 1 || index > len(rules) {
+		t.sendMessage(chatID, l.T("chatrules.invalid_number"))
+		return
+	}
+	if err := t.rules.Delete(ctx, chatID, rules[index-1].ID); err != nil {
+		t.sendMessage(chatID, l.T("chatrules.delete_failed"))
+		return
+	}
+	t.sendMessage(chatID, l.T("chatrules.deleted"))
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TelegramWebhookPath is where the API receives the bot's updates in webhook mode. It is
+// derived from the secret rather than being the secret itself, which would end up in the
+// access log, and only keeps others from guessing the endpoint: updates are authenticated
+// by the secret in the X-Telegram-Bot-Api-Secret-Token header.
+func TelegramWebhookPath(secret string) string {
+	sum := sha256.Sum256([]byte("telegram-webhook:" + secret))
+	return "/telegram/webhook/" + hex.EncodeToString(sum[:16])
+}
+
+// validWebhookSecret reports whether Telegram accepts secret as the secret token of a
+// webhook: 1 to 256 letters, digits, underscores and hyphens.
+func validWebhookSecret(secret string) bool {
+	if secret == "" || len(secret) > 256 {
+		return false
+	}
+	for _, r := range secret {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// RegisterWebhook makes Telegram deliver the bot's updates to the webhook endpoint under
+// baseURL, the public HTTPS URL of the API, instead of waiting for long polling. Every
+// replica registers the same URL, so any of them can receive an update.
+func (t *TelegramBotService) RegisterWebhook(baseURL, secret string) error {
+	if t.bot == nil {
+		return nil
+	}
+	if !validWebhookSecret(secret) {
+		return errors.New("the webhook secret must be 1-256 letters, digits, _ or -")
+	}
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || base.Scheme != "https" || base.Host == "" {
+		return fmt.Errorf("the webhook URL must be an https URL, got %q", baseURL)
+	}
+	endpoint := base.String() + TelegramWebhookPath(secret)
+
+	// The secret_token parameter is newer than the client's WebhookConfig.
+	params := tgbotapi.Params{
+		"url":             endpoint,
+		"secret_token":    secret,
+		"allowed_updates": `["message","callback_query"]`,
+	}
+	if _, err := t.bot.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set the Telegram webhook: %w", err)
+	}
+	log.Printf("✅ Telegram webhook set on %s", base.String())
+	return nil
+}
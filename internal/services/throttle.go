@@ -0,0 +1,98 @@
+package services
+
+import (
+    "sync"
+    "time"
+
+    "golang.org/x/time/rate"
+
+    "github.com/you/wallet_transaction_notifier/internal/ports"
+)
+
+// ChatThrottle limits the alerts sent to each chat with a token bucket. Alerts over the
+// limit are collapsed into one burst summary per chat, sent when the burst window ends.
+// Limits are kept in memory, so each dispatcher process enforces them on its own.
+type ChatThrottle struct {
+    mu        sync.Mutex
+    limit     rate.Limit
+    burst     int
+    window    time.Duration
+    chats     map[string]*chatBucket
+    lastSweep time.Time
+    throttled float64
+}
+
+type chatBucket struct {
+    limiter  *rate.Limiter
+    burstDue time.Time // when the summary of the open burst is sent, zero when none is open
+    lastSeen time.Time
+}
+
+// NewChatThrottle allows perMinute alerts per chat with bursts of up to burst alerts.
+// Alerts over the limit are summarised after window.
+func NewChatThrottle(perMinute int, burst int, window time.Duration) *ChatThrottle {
+    if perMinute <= 0 {
+        perMinute = 20
+    }
+    if burst <= 0 {
+        burst = 1
+    }
+    if window <= 0 {
+        window = 5 * time.Minute
+    }
+    return &ChatThrottle{
+        limit:  rate.Limit(float64(perMinute) / 60),
+        burst:  burst,
+        window: window,
+        chats:  make(map[string]*chatBucket),
+    }
+}
+
+// Allow takes a token for an alert to chatID at now. When the chat is over its limit, or a
+// burst is still open, it returns false and the time the burst summary is due.
+func (t *ChatThrottle) Allow(chatID string, now time.Time) (bool, time.Time) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.sweep(now)
+
+    b, ok := t.chats[chatID]
+    if !ok {
+        b = &chatBucket{limiter: rate.NewLimiter(t.limit, t.burst)}
+        t.chats[chatID] = b
+    }
+    b.lastSeen = now
+    // Keep collecting while a burst is open, so its summary covers the whole burst.
+    if now.Before(b.burstDue) {
+        t.throttled++
+        return false, b.burstDue
+    }
+    if b.limiter.AllowN(now, 1) {
+        return true, time.Time{}
+    }
+    b.burstDue = now.Add(t.window)
+    t.throttled++
+    return false, b.burstDue
+}
+
+// sweep forgets chats that have been idle long enough for their bucket to refill.
+func (t *ChatThrottle) sweep(now time.Time) {
+    if now.Sub(t.lastSweep) < time.Minute {
+        return
+    }
+    t.lastSweep = now
+    idle := t.window + time.Duration(float64(t.burst)/float64(t.limit)*float64(time.Second))
+    for chatID, b := range t.chats {
+        if now.Sub(b.lastSeen) > idle && !now.Before(b.burstDue) {
+            delete(t.chats, chatID)
+        }
+    }
+}
+
+func (t *ChatThrottle) Metrics() []ports.Metric {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    return []ports.Metric{
+        {Name: "alerts_throttled_total", Help: "Alerts collapsed into burst summaries because a chat exceeded its rate limit.", Type: "counter", Value: t.throttled},
+        {Name: "alerts_throttled_chats", Help: "Chats with a rate limit bucket in memory.", Type: "gauge", Value: float64(len(t.chats))},
+    }
+}